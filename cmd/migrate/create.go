@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// migrationIDLayout is the timestamp format of migration IDs
+const migrationIDLayout = "20060102150405"
+
+var nonIdentifier = regexp.MustCompile(`[^a-z0-9]+`)
+
+var migrationTemplate = template.Must(template.New("migration").Parse(`package migrations
+
+import "gorm.io/gorm"
+
+func init() {
+	register(&Migration{
+		ID:   "{{.ID}}",
+		Name: "{{.Name}}",
+		Migrate: func(tx *gorm.DB) error {
+			// TODO: apply the change
+			return nil
+		},
+		Rollback: func(tx *gorm.DB) error {
+			// TODO: undo the change
+			return nil
+		},
+	})
+}
+`))
+
+// migrationName turns a free-form description into a file-name friendly
+// identifier.
+func migrationName(name string) (string, error) {
+	name = strings.Trim(nonIdentifier.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return "", fmt.Errorf("migration name must contain letters or digits")
+	}
+	return name, nil
+}
+
+// createMigration writes a Go migration skeleton named after the current time
+// and returns its path. The migration registers itself, so the file only
+// needs to be filled in.
+func createMigration(dir, name string, now time.Time) ([]string, error) {
+	name, err := migrationName(name)
+	if err != nil {
+		return nil, err
+	}
+	id := now.Format(migrationIDLayout)
+
+	var buf bytes.Buffer
+	if err := migrationTemplate.Execute(&buf, struct{ ID, Name string }{id, name}); err != nil {
+		return nil, err
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(dir, id+"_"+name+".go")
+	if err := writeNewFile(path, src); err != nil {
+		return nil, err
+	}
+	return []string{path}, nil
+}
+
+// createSQLMigration writes empty up and down files for a SQL migration in
+// the sql directory under dir and returns their paths. They are embedded into
+// the binary on the next build.
+func createSQLMigration(dir, name string, now time.Time) ([]string, error) {
+	name, err := migrationName(name)
+	if err != nil {
+		return nil, err
+	}
+	base := filepath.Join(dir, "sql", now.Format(migrationIDLayout)+"_"+name)
+
+	files := []struct{ path, body string }{
+		{base + ".up.sql", "-- Apply the change\n"},
+		{base + ".down.sql", "-- Undo the change; delete this file if it cannot be undone\n"},
+	}
+	paths := make([]string, 0, len(files))
+	for _, file := range files {
+		if err := writeNewFile(file.path, []byte(file.body)); err != nil {
+			return paths, err
+		}
+		paths = append(paths, file.path)
+	}
+	return paths, nil
+}
+
+// writeNewFile writes data to path, refusing to overwrite an existing
+// migration.
+func writeNewFile(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
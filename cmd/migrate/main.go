@@ -0,0 +1,205 @@
+// Command migrate applies, rolls back and inspects database migrations, and
+// scaffolds new ones.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jixlox0/studoto-backend/internal/config"
+	"github.com/jixlox0/studoto-backend/internal/database"
+	"github.com/jixlox0/studoto-backend/internal/logging"
+)
+
+const usage = `Usage: migrate [flags] <command> [arguments]
+
+Commands:
+  up              apply all pending migrations
+  down [N]        roll back the last N applied migrations (default 1)
+  to <id>         migrate forward or roll back until <id> is the last applied migration
+  status          list migrations as applied or pending, with the time they were applied
+  redo            roll back the last applied migration and apply it again
+  create <name>   scaffold a new Go migration, or SQL files with -sql
+
+SQL migrations are also picked up from internal/database/migrations/sql as
+<id>_<name>.up.sql and <id>_<name>.down.sql. Changes run under a Postgres
+advisory lock, and are refused if an applied SQL migration has been edited.
+
+Flags:
+`
+
+func main() {
+	dir := flag.String("dir", "internal/database/migrations", "directory that create writes new migrations to")
+	sqlFiles := flag.Bool("sql", false, "make create write .up.sql and .down.sql files instead of a Go migration")
+	dryRun := flag.Bool("dry-run", false, "print the SQL that up, down, to or redo would execute, without applying it")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	command, args := args[0], args[1:]
+
+	// create only writes a file, so it works without configuration or a
+	// database
+	if command == "create" {
+		if len(args) != 1 {
+			log.Fatal("Usage: migrate create <name>")
+		}
+		create := createMigration
+		if *sqlFiles {
+			create = createSQLMigration
+		}
+		paths, err := create(*dir, args[0], time.Now().UTC())
+		if err != nil {
+			log.Fatalf("Failed to create migration: %v", err)
+		}
+		for _, path := range paths {
+			fmt.Println("Created", path)
+		}
+		return
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
+	logger := logging.NewLogger(cfg.Log)
+	slog.SetDefault(logger)
+	gormLogger := logging.NewGormLogger(logger, time.Duration(cfg.Log.SlowQueryThresholdMs)*time.Millisecond, cfg.Log.SQLParams)
+
+	db, err := database.NewConnection(cfg.Database, logger, gormLogger)
+	if err != nil {
+		fatal(logger, "Failed to connect to database", err)
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		defer sqlDB.Close()
+	}
+
+	// An interrupt cancels the statement in progress rather than leaving the
+	// process to be killed between a migration and its bookkeeping
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	migrator := database.NewMigrator(db)
+	if *dryRun {
+		migrator = database.NewDryRunMigrator(db, os.Stdout)
+	}
+	if err := run(ctx, migrator, command, args, logger); err != nil {
+		stop()
+		fatal(logger, "Migration command failed", err, "command", command)
+	}
+	if *dryRun && command != "status" {
+		logger.Info("Dry run complete, no changes were applied")
+	}
+}
+
+func run(ctx context.Context, migrator database.Migrator, command string, args []string, logger *slog.Logger) error {
+	switch command {
+	case "up":
+		if len(args) != 0 {
+			return fmt.Errorf("up takes no arguments")
+		}
+		if err := migrator.Up(ctx); err != nil {
+			return err
+		}
+		logger.Info("Database migrations completed successfully")
+
+	case "down":
+		n := 1
+		if len(args) > 1 {
+			return fmt.Errorf("down takes at most one argument")
+		}
+		if len(args) == 1 {
+			var err error
+			if n, err = strconv.Atoi(args[0]); err != nil {
+				return fmt.Errorf("invalid number of migrations %q", args[0])
+			}
+		}
+		rolledBack, err := migrator.Down(ctx, n)
+		for _, id := range rolledBack {
+			logger.Info("Rolled back migration", "id", id)
+		}
+		return err
+
+	case "to":
+		if len(args) != 1 {
+			return fmt.Errorf("to takes exactly one migration ID")
+		}
+		if err := migrator.To(ctx, args[0]); err != nil {
+			return err
+		}
+		logger.Info("Database is at migration", "id", args[0])
+
+	case "status":
+		if len(args) != 0 {
+			return fmt.Errorf("status takes no arguments")
+		}
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		return printStatus(statuses)
+
+	case "redo":
+		if len(args) != 0 {
+			return fmt.Errorf("redo takes no arguments")
+		}
+		id, err := migrator.Redo(ctx)
+		if err != nil {
+			return err
+		}
+		logger.Info("Redid migration", "id", id)
+
+	default:
+		return fmt.Errorf("unknown command %q", command)
+	}
+	return nil
+}
+
+func printStatus(statuses []database.MigrationStatus) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tSTATUS\tAPPLIED AT")
+	for _, s := range statuses {
+		state, appliedAt := "pending", "-"
+		if s.Applied {
+			state = "applied"
+			if s.Missing {
+				state = "applied, missing from code"
+			} else if s.Modified {
+				state = "applied, modified since"
+			}
+			if s.AppliedAt != nil {
+				appliedAt = s.AppliedAt.UTC().Format(time.RFC3339)
+			} else {
+				appliedAt = "unknown"
+			}
+		}
+		name := s.Name
+		if name == "" {
+			name = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", s.ID, name, state, appliedAt)
+	}
+	return w.Flush()
+}
+
+func fatal(logger *slog.Logger, msg string, err error, args ...any) {
+	logger.Error(msg, append([]any{"error", err}, args...)...)
+	os.Exit(1)
+}
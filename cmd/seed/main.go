@@ -0,0 +1,138 @@
+// Command seed fills a development or demo database with sample data. It
+// refuses to run in production.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jixlox0/studoto-backend/internal/config"
+	"github.com/jixlox0/studoto-backend/internal/database"
+	"github.com/jixlox0/studoto-backend/internal/logging"
+	"github.com/jixlox0/studoto-backend/internal/seed"
+)
+
+const usage = `Usage: seed [flags] [set ...]
+
+Creates the named seed sets, or all of them. Sets are idempotent: existing
+rows are kept and only missing ones are added. Seeding is refused when
+APP_ENV is anything but development, test or staging, and in staging
+unless -allow-staging is given. Staging also needs an -admin-password of its
+own when the admin set runs, since the default one is public.
+
+Flags:
+`
+
+func main() {
+	defaults := seed.DefaultOptions()
+	list := flag.Bool("list", false, "list the seed sets and exit")
+	allowStaging := flag.Bool("allow-staging", false, "allow seeding when APP_ENV is staging")
+	adminEmail := flag.String("admin-email", defaults.AdminEmail, "email of the seeded administrator")
+	adminPassword := flag.String("admin-password", defaults.AdminPassword, "password of the seeded administrator, if it is created")
+	studentPassword := flag.String("student-password", defaults.StudentPassword, "password of the sample students")
+	students := flag.Int("students", defaults.Students, "number of sample students")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	registry := seed.NewDefaultRegistry(seed.Options{
+		AdminEmail:      *adminEmail,
+		AdminPassword:   *adminPassword,
+		StudentPassword: *studentPassword,
+		Students:        *students,
+	})
+	if *list {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, set := range registry.Sets() {
+			fmt.Fprintf(w, "%s\t%s\n", set.Name(), set.Description())
+		}
+		w.Flush()
+		return
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+	if err := seed.Guard(cfg.Env, *allowStaging); err != nil {
+		if errors.Is(err, seed.ErrStaging) {
+			log.Fatal("Refusing to seed staging without -allow-staging")
+		}
+		log.Fatalf("Refusing to seed %q: %v", cfg.Env, err)
+	}
+	if cfg.Env == config.EnvStaging && seedsAdmin(flag.Args()) &&
+		(!flagSet("admin-password") || *adminPassword == defaults.AdminPassword) {
+		log.Fatal("Refusing to seed an administrator in staging without -admin-password")
+	}
+
+	logger := logging.NewLogger(cfg.Log)
+	slog.SetDefault(logger)
+	gormLogger := logging.NewGormLogger(logger, time.Duration(cfg.Log.SlowQueryThresholdMs)*time.Millisecond, cfg.Log.SQLParams)
+
+	db, err := database.NewConnection(cfg.Database, logger, gormLogger)
+	if err != nil {
+		fatal(logger, "Failed to connect to database", err)
+	}
+	if sqlDB, err := db.DB(); err == nil {
+		defer sqlDB.Close()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// Seeds are written against the current schema
+	pending, err := database.PendingMigrations(ctx, db)
+	if err != nil {
+		fatal(logger, "Failed to check migrations", err)
+	}
+	if len(pending) > 0 {
+		fatal(logger, "Database is not migrated; run the migrate command first",
+			fmt.Errorf("%d pending migration(s): %s", len(pending), strings.Join(pending, ", ")))
+	}
+
+	names := flag.Args()
+	if err := registry.Run(ctx, db, cfg.Env, names...); err != nil {
+		stop()
+		fatal(logger, "Seeding failed", err)
+	}
+	if len(names) == 0 {
+		for _, set := range registry.Sets() {
+			names = append(names, set.Name())
+		}
+	}
+	logger.Info("Seeding completed", "sets", names, "env", cfg.Env)
+}
+
+// seedsAdmin reports whether the named sets include the admin set.
+func seedsAdmin(names []string) bool {
+	return len(names) == 0 || slices.Contains(names, "admin")
+}
+
+// flagSet reports whether the named flag was given on the command line.
+func flagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+func fatal(logger *slog.Logger, msg string, err error) {
+	logger.Error(msg, "error", err)
+	os.Exit(1)
+}
@@ -1,38 +1,122 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jixlox0/studoto-backend/internal/config"
 	"github.com/jixlox0/studoto-backend/internal/database"
+	"github.com/jixlox0/studoto-backend/internal/lifecycle"
+	"github.com/jixlox0/studoto-backend/internal/logging"
+	"github.com/jixlox0/studoto-backend/internal/metrics"
+	"github.com/jixlox0/studoto-backend/internal/service"
+	"github.com/jixlox0/studoto-backend/internal/tracing"
+	"github.com/jixlox0/studoto-backend/internal/worker"
+	"github.com/jixlox0/studoto-backend/pkg/cache"
+	"github.com/redis/go-redis/extra/redisotel/v9"
+	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
 )
 
 // App holds the application dependencies.
 // This struct is used by Wire to inject all dependencies.
 type App struct {
-	Router *gin.Engine
-	DB     *gorm.DB
+	Router      *gin.Engine
+	Metrics     *metrics.Metrics
+	DB          *gorm.DB
+	Redis       *redis.Client
+	Readiness   *lifecycle.Readiness
+	PurgeWorker *worker.Periodic
+	Logger      *slog.Logger
+	Tracer      *tracing.Provider
 }
 
 // NewApp creates a new App instance.
 // This function is used by Wire as a provider to construct the App.
-func NewApp(router *gin.Engine, db *gorm.DB) *App {
+func NewApp(router *gin.Engine, m *metrics.Metrics, db *gorm.DB, redisClient *redis.Client, readiness *lifecycle.Readiness, purgeWorker *worker.Periodic, logger *slog.Logger, tracer *tracing.Provider) *App {
 	return &App{
-		Router: router,
-		DB:     db,
+		Router:      router,
+		Metrics:     m,
+		DB:          db,
+		Redis:       redisClient,
+		Readiness:   readiness,
+		PurgeWorker: purgeWorker,
+		Logger:      logger,
+		Tracer:      tracer,
 	}
 }
 
+// provideAccountPurgeWorker builds the background job that erases accounts
+// whose deletion grace period has ended.
+func provideAccountPurgeWorker(accountService service.AccountService, cfg config.AccountConfig) *worker.Periodic {
+	interval := time.Duration(cfg.PurgeIntervalMinutes) * time.Minute
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	return worker.NewPeriodic("account-purge", interval, accountService.PurgeDue)
+}
+
+// provideDatabase opens the database connection and instruments it for tracing.
+func provideDatabase(cfg config.DatabaseConfig, logger *slog.Logger, gormLogger gormlogger.Interface, tracer *tracing.Provider) (*gorm.DB, error) {
+	db, err := database.NewConnection(cfg, logger, gormLogger)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Use(tracing.NewGormPlugin(tracer)); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// provideRedisClient connects to Redis and instruments the client for tracing.
+func provideRedisClient(cfg cache.RedisConfig, tracer *tracing.Provider) (*redis.Client, error) {
+	client, err := cache.NewRedisClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := redisotel.InstrumentTracing(client, redisotel.WithTracerProvider(tracer)); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// provideGormLogger routes GORM's query log through the application logger.
+func provideGormLogger(logger *slog.Logger, cfg config.LogConfig) gormlogger.Interface {
+	slowThreshold := time.Duration(cfg.SlowQueryThresholdMs) * time.Millisecond
+	return logging.NewGormLogger(logger, slowThreshold, cfg.SQLParams)
+}
+
 func main() {
+	printConfig := flag.Bool("print-config", false, "print the effective configuration with secrets redacted, then exit")
+	skipMigrations := flag.Bool("skip-migrations", false, "do not apply pending migrations at startup; run the migrate command instead")
+	flag.Parse()
+
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
 		log.Fatalf("Failed to load configuration: %v", err)
 	}
 
+	if *printConfig {
+		if err := cfg.Dump(os.Stdout); err != nil {
+			log.Fatalf("Failed to print configuration: %v", err)
+		}
+		return
+	}
+
+	gin.SetMode(cfg.GinMode())
+
 	// Initialize application using Wire dependency injection
 	// InitializeApp is generated by Wire in wire_gen.go
 	app, err := InitializeApp(cfg)
@@ -40,26 +124,131 @@ func main() {
 		log.Fatalf("Failed to initialize application: %v", err)
 	}
 
+	// Route the remaining stdlib and package-level slog output through the
+	// structured logger as well
+	logger := app.Logger
+	slog.SetDefault(logger)
+
 	// Get underlying sql.DB for proper connection closing
 	sqlDB, err := app.DB.DB()
 	if err != nil {
-		log.Fatalf("Failed to get database instance: %v", err)
+		fatal(logger, "Failed to get database instance", err)
 	}
-	defer sqlDB.Close()
 
-	// Run migrations
-	if err := database.RunMigrations(app.DB); err != nil {
-		log.Fatalf("Failed to run migrations: %v", err)
+	// Resources are released in registration order once the HTTP server has
+	// drained: background workers first, then the stores they depend on
+	shutdown := lifecycle.NewManager()
+	shutdown.OnShutdown("worker "+app.PurgeWorker.Name(), app.PurgeWorker.Stop)
+	shutdown.OnShutdown("redis", func(ctx context.Context) error {
+		return app.Redis.Close()
+	})
+	shutdown.OnShutdown("database", func(ctx context.Context) error {
+		return sqlDB.Close()
+	})
+	shutdown.OnShutdown("tracing", app.Tracer.Shutdown)
+
+	// Run migrations, unless the deploy applies them as a separate step. The
+	// readiness probe reports pending migrations either way
+	if *skipMigrations {
+		logger.Info("Skipping database migrations")
+	} else if err := database.RunMigrations(context.Background(), app.DB); err != nil {
+		fatal(logger, "Failed to run migrations", err)
+	}
+
+	// Start background workers
+	app.PurgeWorker.Start()
+
+	srv := &http.Server{
+		Addr:              ":" + cfg.Server.Port,
+		Handler:           app.Router,
+		ReadTimeout:       seconds(cfg.Server.ReadTimeoutSeconds),
+		ReadHeaderTimeout: seconds(cfg.Server.ReadHeaderTimeoutSeconds),
+		WriteTimeout:      seconds(cfg.Server.WriteTimeoutSeconds),
+		IdleTimeout:       seconds(cfg.Server.IdleTimeoutSeconds),
+	}
+
+	// Metrics may be kept off the public listener on an address of their own
+	var metricsSrv *http.Server
+	if cfg.Metrics.Enabled && cfg.Metrics.Addr != "" {
+		mux := http.NewServeMux()
+		mux.Handle(cfg.Metrics.Path, app.Metrics.Handler(cfg.Metrics.Token))
+		metricsSrv = &http.Server{
+			Addr:              cfg.Metrics.Addr,
+			Handler:           mux,
+			ReadHeaderTimeout: seconds(cfg.Server.ReadHeaderTimeoutSeconds),
+		}
 	}
 
-	// Start server
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	// Start servers
+	serverErr := make(chan error, 2)
+	go func() {
+		logger.Info("Server starting", "port", cfg.Server.Port)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+	if metricsSrv != nil {
+		go func() {
+			logger.Info("Metrics server starting", "addr", cfg.Metrics.Addr)
+			if err := metricsSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				serverErr <- fmt.Errorf("metrics server: %w", err)
+			}
+		}()
 	}
+	app.Readiness.SetReady(true)
+
+	// Wait for a termination signal or for the server to fail
+	signalCtx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
-	log.Printf("Server starting on port %s", port)
-	if err := app.Router.Run(":" + port); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	exitCode := 0
+	select {
+	case <-signalCtx.Done():
+		logger.Info("Shutdown signal received")
+	case err := <-serverErr:
+		logger.Error("Server failed", "error", err)
+		exitCode = 1
 	}
+	stop()
+
+	// Report not-ready first and keep serving for the drain delay, so load
+	// balancers stop sending new requests before the listener closes
+	app.Readiness.SetReady(false)
+	if exitCode == 0 {
+		if delay := seconds(cfg.Server.DrainDelaySeconds); delay > 0 {
+			logger.Info("Draining before shutdown", "delay", delay.String())
+			time.Sleep(delay)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), seconds(cfg.Server.ShutdownTimeoutSeconds))
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		logger.Error("Server shutdown did not complete", "error", err)
+		exitCode = 1
+	}
+	// Metrics stay scrapeable while the API drains
+	if metricsSrv != nil {
+		if err := metricsSrv.Shutdown(ctx); err != nil {
+			logger.Error("Metrics server shutdown did not complete", "error", err)
+			exitCode = 1
+		}
+	}
+	if err := shutdown.Shutdown(ctx); err != nil {
+		logger.Error("Shutdown hooks failed", "error", err)
+		exitCode = 1
+	}
+
+	logger.Info("Server stopped")
+	os.Exit(exitCode)
+}
+
+func fatal(logger *slog.Logger, msg string, err error) {
+	logger.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+func seconds(n int) time.Duration {
+	return time.Duration(n) * time.Second
 }
@@ -11,13 +11,22 @@ import (
 	"github.com/google/wire"
 	"github.com/jixlox0/studoto-backend/internal/api"
 	"github.com/jixlox0/studoto-backend/internal/config"
-	"github.com/jixlox0/studoto-backend/internal/database"
+	"github.com/jixlox0/studoto-backend/internal/health"
+	"github.com/jixlox0/studoto-backend/internal/i18n"
+	"github.com/jixlox0/studoto-backend/internal/idempotency"
+	"github.com/jixlox0/studoto-backend/internal/lifecycle"
+	"github.com/jixlox0/studoto-backend/internal/logging"
+	"github.com/jixlox0/studoto-backend/internal/metrics"
 	"github.com/jixlox0/studoto-backend/internal/middleware"
+	"github.com/jixlox0/studoto-backend/internal/privacy"
+	"github.com/jixlox0/studoto-backend/internal/ratelimit"
 	"github.com/jixlox0/studoto-backend/internal/repository"
 	"github.com/jixlox0/studoto-backend/internal/service"
+	"github.com/jixlox0/studoto-backend/internal/tracing"
 	"github.com/jixlox0/studoto-backend/pkg/auth"
 	"github.com/jixlox0/studoto-backend/pkg/cache"
 	"github.com/jixlox0/studoto-backend/pkg/oauth"
+	"go.opentelemetry.io/otel/trace"
 )
 
 //go:generate go run -mod=mod github.com/google/wire/cmd/wire
@@ -33,27 +42,66 @@ func InitializeApp(cfg *config.Config) (*App, error) {
 		provideJWTSecretKey,
 		provideJWTExpirationHours,
 		provideOAuthConfig,
+		provideSessionConfig,
+		provideAccountConfig,
+		provideHealthConfig,
+		provideLogConfig,
+		provideTracingConfig,
+		provideRateLimitConfig,
+		provideIdempotencyConfig,
+
+		// Logging, metrics & tracing
+		logging.NewLogger,
+		provideGormLogger,
+		metrics.NewMetrics,
+		wire.Bind(new(auth.CacheObserver), new(*metrics.Metrics)),
+		tracing.NewProvider,
+		wire.Bind(new(trace.TracerProvider), new(*tracing.Provider)),
 
 		// Cache layer
-		cache.NewRedisClient,
+		provideRedisClient,
 		cache.NewRedisCache,
+		idempotency.NewRedisStore,
 
 		// Database layer
-		database.NewConnection,
+		provideDatabase,
 
 		// Repository layer
 		repository.NewUserRepository,
+		repository.NewAccessTokenRepository,
+		repository.NewAuditEventRepository,
+		repository.NewTxManager,
 
 		// Authentication & Authorization
 		auth.NewJWTAuth,
 		oauth.NewOAuthService,
 
 		// Service layer
+		service.NewAuditor,
 		service.NewUserService,
 		service.NewAuthService,
+		service.NewAccessTokenService,
+		service.NewAccountService,
+
+		// Privacy
+		privacy.NewDefaultRegistry,
+
+		// Background workers
+		provideAccountPurgeWorker,
+
+		// Localization
+		i18n.NewBundle,
 
 		// Middleware
+		middleware.NewSessionCookies,
 		middleware.NewAuthMiddleware,
+		ratelimit.NewLimiter,
+		middleware.NewRateLimiter,
+		middleware.NewIdempotency,
+
+		// Lifecycle & health checks
+		lifecycle.NewReadiness,
+		health.NewDefaultProber,
 
 		// API handlers
 		api.NewHandlers,
@@ -92,6 +140,41 @@ func provideOAuthConfig(cfg *config.Config) config.OAuthConfig {
 	return cfg.OAuth
 }
 
+// provideSessionConfig extracts the session cookie configuration from the main config.
+func provideSessionConfig(cfg *config.Config) config.SessionConfig {
+	return cfg.Session
+}
+
+// provideAccountConfig extracts the account lifecycle configuration from the main config.
+func provideAccountConfig(cfg *config.Config) config.AccountConfig {
+	return cfg.Account
+}
+
+// provideHealthConfig extracts the health probe configuration from the main config.
+func provideHealthConfig(cfg *config.Config) config.HealthConfig {
+	return cfg.Health
+}
+
+// provideLogConfig extracts the logging configuration from the main config.
+func provideLogConfig(cfg *config.Config) config.LogConfig {
+	return cfg.Log
+}
+
+// provideTracingConfig extracts the tracing configuration from the main config.
+func provideTracingConfig(cfg *config.Config) config.TracingConfig {
+	return cfg.Tracing
+}
+
+// provideRateLimitConfig extracts the rate limiting configuration from the main config.
+func provideRateLimitConfig(cfg *config.Config) config.RateLimitConfig {
+	return cfg.RateLimit
+}
+
+// provideIdempotencyConfig extracts the Idempotency-Key configuration from the main config.
+func provideIdempotencyConfig(cfg *config.Config) config.IdempotencyConfig {
+	return cfg.Idempotency
+}
+
 // provideRedisConfig extracts the Redis configuration from the main config.
 func provideRedisConfig(cfg *config.Config) cache.RedisConfig {
 	return cache.RedisConfig{
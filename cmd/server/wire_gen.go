@@ -9,10 +9,18 @@ package main
 import (
 	"github.com/jixlox0/studoto-backend/internal/api"
 	"github.com/jixlox0/studoto-backend/internal/config"
-	"github.com/jixlox0/studoto-backend/internal/database"
+	"github.com/jixlox0/studoto-backend/internal/health"
+	"github.com/jixlox0/studoto-backend/internal/i18n"
+	"github.com/jixlox0/studoto-backend/internal/idempotency"
+	"github.com/jixlox0/studoto-backend/internal/lifecycle"
+	"github.com/jixlox0/studoto-backend/internal/logging"
+	"github.com/jixlox0/studoto-backend/internal/metrics"
 	"github.com/jixlox0/studoto-backend/internal/middleware"
+	"github.com/jixlox0/studoto-backend/internal/privacy"
+	"github.com/jixlox0/studoto-backend/internal/ratelimit"
 	"github.com/jixlox0/studoto-backend/internal/repository"
 	"github.com/jixlox0/studoto-backend/internal/service"
+	"github.com/jixlox0/studoto-backend/internal/tracing"
 	"github.com/jixlox0/studoto-backend/pkg/auth"
 	"github.com/jixlox0/studoto-backend/pkg/cache"
 	"github.com/jixlox0/studoto-backend/pkg/oauth"
@@ -25,7 +33,15 @@ import (
 // The generated code will be in wire_gen.go
 func InitializeApp(cfg *config.Config) (*App, error) {
 	databaseConfig := provideDatabaseConfig(cfg)
-	db, err := database.NewConnection(databaseConfig)
+	logConfig := provideLogConfig(cfg)
+	logger := logging.NewLogger(logConfig)
+	gormLogger := provideGormLogger(logger, logConfig)
+	tracingConfig := provideTracingConfig(cfg)
+	provider, err := tracing.NewProvider(tracingConfig)
+	if err != nil {
+		return nil, err
+	}
+	db, err := provideDatabase(databaseConfig, logger, gormLogger, provider)
 	if err != nil {
 		return nil, err
 	}
@@ -33,20 +49,54 @@ func InitializeApp(cfg *config.Config) (*App, error) {
 	string2 := provideJWTSecretKey(cfg)
 	int2 := provideJWTExpirationHours(cfg)
 	redisConfig := provideRedisConfig(cfg)
-	client, err := cache.NewRedisClient(redisConfig)
+	client, err := provideRedisClient(redisConfig, provider)
 	if err != nil {
 		return nil, err
 	}
 	tokenCache := cache.NewRedisCache(client)
-	jwtAuth := auth.NewJWTAuth(string2, int2, tokenCache)
-	userService := service.NewUserService(userRepository, jwtAuth)
+	metricsMetrics, err := metrics.NewMetrics(db, client)
+	if err != nil {
+		return nil, err
+	}
+	jwtAuth := auth.NewJWTAuth(string2, int2, tokenCache, metricsMetrics)
+	auditEventRepository := repository.NewAuditEventRepository(db)
+	auditor := service.NewAuditor(auditEventRepository)
+	userService := service.NewUserService(userRepository, jwtAuth, auditor)
 	oAuthConfig := provideOAuthConfig(cfg)
-	oAuthService := oauth.NewOAuthService(oAuthConfig)
-	authService := service.NewAuthService(userRepository, jwtAuth, oAuthService)
-	authMiddleware := middleware.NewAuthMiddleware(jwtAuth)
-	handlers := api.NewHandlers(userService, authService, authMiddleware)
-	engine := api.NewRouter(handlers, cfg)
-	app := NewApp(engine, db)
+	oAuthService := oauth.NewOAuthService(oAuthConfig, provider)
+	txManager := repository.NewTxManager(db)
+	authService := service.NewAuthService(userRepository, txManager, jwtAuth, oAuthService, auditor, metricsMetrics)
+	accessTokenRepository := repository.NewAccessTokenRepository(db)
+	accessTokenService := service.NewAccessTokenService(accessTokenRepository, userRepository)
+	sessionConfig := provideSessionConfig(cfg)
+	sessionCookies := middleware.NewSessionCookies(sessionConfig)
+	authMiddleware := middleware.NewAuthMiddleware(jwtAuth, accessTokenService, userService, sessionCookies)
+	store := idempotency.NewRedisStore(client)
+	registry := privacy.NewDefaultRegistry(userRepository, accessTokenRepository, auditEventRepository, store)
+	accountConfig := provideAccountConfig(cfg)
+	accountService := service.NewAccountService(userRepository, txManager, jwtAuth, registry, auditor, accountConfig)
+	readiness := lifecycle.NewReadiness()
+	healthConfig := provideHealthConfig(cfg)
+	prober := health.NewDefaultProber(db, client, oAuthConfig, healthConfig)
+	handlers := api.NewHandlers(userService, authService, accessTokenService, accountService, auditor, authMiddleware, readiness, prober, healthConfig)
+	bundle, err := i18n.NewBundle()
+	if err != nil {
+		return nil, err
+	}
+	rateLimitConfig := provideRateLimitConfig(cfg)
+	limiter := ratelimit.NewLimiter(client, rateLimitConfig, logger)
+	rateLimiter, err := middleware.NewRateLimiter(limiter, rateLimitConfig, metricsMetrics)
+	if err != nil {
+		return nil, err
+	}
+	idempotencyConfig := provideIdempotencyConfig(cfg)
+	middlewareIdempotency := middleware.NewIdempotency(store, idempotencyConfig)
+	engine, err := api.NewRouter(handlers, cfg, logger, metricsMetrics, provider, bundle, rateLimiter, middlewareIdempotency)
+	if err != nil {
+		return nil, err
+	}
+	periodic := provideAccountPurgeWorker(accountService, accountConfig)
+	app := NewApp(engine, metricsMetrics, db, client, readiness, periodic, logger, provider)
 	return app, nil
 }
 
@@ -72,6 +122,41 @@ func provideOAuthConfig(cfg *config.Config) config.OAuthConfig {
 	return cfg.OAuth
 }
 
+// provideSessionConfig extracts the session cookie configuration from the main config.
+func provideSessionConfig(cfg *config.Config) config.SessionConfig {
+	return cfg.Session
+}
+
+// provideAccountConfig extracts the account lifecycle configuration from the main config.
+func provideAccountConfig(cfg *config.Config) config.AccountConfig {
+	return cfg.Account
+}
+
+// provideHealthConfig extracts the health probe configuration from the main config.
+func provideHealthConfig(cfg *config.Config) config.HealthConfig {
+	return cfg.Health
+}
+
+// provideLogConfig extracts the logging configuration from the main config.
+func provideLogConfig(cfg *config.Config) config.LogConfig {
+	return cfg.Log
+}
+
+// provideTracingConfig extracts the tracing configuration from the main config.
+func provideTracingConfig(cfg *config.Config) config.TracingConfig {
+	return cfg.Tracing
+}
+
+// provideRateLimitConfig extracts the rate limiting configuration from the main config.
+func provideRateLimitConfig(cfg *config.Config) config.RateLimitConfig {
+	return cfg.RateLimit
+}
+
+// provideIdempotencyConfig extracts the Idempotency-Key configuration from the main config.
+func provideIdempotencyConfig(cfg *config.Config) config.IdempotencyConfig {
+	return cfg.Idempotency
+}
+
 // provideRedisConfig extracts the Redis configuration from the main config.
 func provideRedisConfig(cfg *config.Config) cache.RedisConfig {
 	return cache.RedisConfig{
@@ -0,0 +1,74 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jixlox0/studoto-backend/internal/middleware"
+	"github.com/jixlox0/studoto-backend/internal/models"
+	"github.com/jixlox0/studoto-backend/internal/pagination"
+	"github.com/jixlox0/studoto-backend/internal/service"
+)
+
+// Admin handlers
+func (h *Handlers) ListAuditEvents(c *gin.Context) {
+	var filter models.AuditEventFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		middleware.AbortWithBindError(c, err)
+		return
+	}
+
+	page, err := h.auditor.Query(c.Request.Context(), &filter)
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(page))
+}
+
+func (h *Handlers) ListUsers(c *gin.Context) {
+	params, err := service.UserPagination.Parse(c.Request.URL.Query())
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	page, err := h.userService.ListUsers(c.Request.Context(), params)
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, pagination.NewResponse(c.Request.URL, page))
+}
+
+func (h *Handlers) UpdateUserRole(c *gin.Context) {
+	admin, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateUserRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithBindError(c, err)
+		return
+	}
+
+	user, previousRole, err := h.userService.UpdateRole(c.Request.Context(), c.Param("id"), req.Role)
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	if previousRole != user.Role {
+		h.auditor.Record(c.Request.Context(), &models.AuditEvent{
+			ActorID:  admin.UserID,
+			TargetID: user.UUID,
+			Action:   models.AuditActionAdminRoleChanged,
+			Metadata: models.JSONMap{"from": previousRole, "to": user.Role},
+		})
+	}
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(user))
+}
@@ -0,0 +1,9 @@
+// Kept out of index.html so that the page's Content-Security-Policy can
+// refuse inline scripts.
+window.onload = function () {
+  window.ui = SwaggerUIBundle({
+    url: "/openapi.json",
+    dom_id: "#swagger-ui",
+    deepLinking: true,
+  });
+};
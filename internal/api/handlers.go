@@ -1,26 +1,43 @@
 package api
 
 import (
+	stderrors "errors"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jixlox0/studoto-backend/internal/config"
 	"github.com/jixlox0/studoto-backend/internal/errors"
+	"github.com/jixlox0/studoto-backend/internal/health"
+	"github.com/jixlox0/studoto-backend/internal/lifecycle"
 	"github.com/jixlox0/studoto-backend/internal/middleware"
 	"github.com/jixlox0/studoto-backend/internal/models"
 	"github.com/jixlox0/studoto-backend/internal/service"
 )
 
 type Handlers struct {
-	userService    service.UserService
-	authService    service.AuthService
-	authMiddleware *middleware.AuthMiddleware
+	userService        service.UserService
+	authService        service.AuthService
+	accessTokenService service.AccessTokenService
+	accountService     service.AccountService
+	auditor            service.Auditor
+	authMiddleware     *middleware.AuthMiddleware
+	readiness          *lifecycle.Readiness
+	prober             *health.Prober
+	healthConfig       config.HealthConfig
 }
 
-func NewHandlers(userService service.UserService, authService service.AuthService, authMiddleware *middleware.AuthMiddleware) *Handlers {
+func NewHandlers(userService service.UserService, authService service.AuthService, accessTokenService service.AccessTokenService, accountService service.AccountService, auditor service.Auditor, authMiddleware *middleware.AuthMiddleware, readiness *lifecycle.Readiness, prober *health.Prober, healthConfig config.HealthConfig) *Handlers {
 	return &Handlers{
-		userService:    userService,
-		authService:    authService,
-		authMiddleware: authMiddleware,
+		userService:        userService,
+		authService:        authService,
+		accessTokenService: accessTokenService,
+		accountService:     accountService,
+		auditor:            auditor,
+		authMiddleware:     authMiddleware,
+		readiness:          readiness,
+		prober:             prober,
+		healthConfig:       healthConfig,
 	}
 }
 
@@ -28,13 +45,28 @@ func NewHandlers(userService service.UserService, authService service.AuthServic
 func (h *Handlers) Signup(c *gin.Context) {
 	var req models.CreateUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, models.NewErrorsResponse(http.StatusBadRequest, err.Error()))
+		middleware.AbortWithBindError(c, err)
 		return
 	}
 
-	response, err := h.authService.Signup(&req)
+	// A retry of a signup that already went through gets a new session for
+	// the account it created
+	var response *models.SuccessResponse
+	var err error
+	if userUUID, ok := middleware.ReplayedSubject(c); ok {
+		response, err = h.authService.ResumeSignup(c.Request.Context(), userUUID)
+	} else {
+		response, err = h.authService.Signup(c.Request.Context(), &req)
+	}
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.NewErrorsResponse(http.StatusBadRequest, err.Error()))
+		middleware.AbortWithError(c, err)
+		return
+	}
+	if payload, ok := response.Data.(*models.AuthResponse); ok && payload.User != nil {
+		middleware.RebuildOnReplay(c, payload.User.UUID)
+	}
+
+	if !h.startSession(c, response) {
 		return
 	}
 
@@ -44,29 +76,57 @@ func (h *Handlers) Signup(c *gin.Context) {
 func (h *Handlers) Signin(c *gin.Context) {
 	var req models.LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, models.NewErrorsResponse(http.StatusBadRequest, err.Error()))
+		middleware.AbortWithBindError(c, err)
 		return
 	}
 
-	response, err := h.authService.Signin(&req)
+	response, err := h.authService.Signin(c.Request.Context(), &req)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, models.NewErrorsResponse(http.StatusUnauthorized, err.Error()))
+		// Unknown emails and wrong passwords are reported alike, so that
+		// sign-in cannot be used to find out which emails have accounts
+		if stderrors.Is(err, errors.ErrUserNotFound) || stderrors.Is(err, errors.ErrInvalidPassword) {
+			err = errors.ErrInvalidCredentials
+		}
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	if !h.startSession(c, response) {
 		return
 	}
 
 	c.JSON(http.StatusOK, response)
 }
 
+func (h *Handlers) Signout(c *gin.Context) {
+	principal, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	// Access tokens are revoked through their own endpoint, so only
+	// interactive sessions carry a token to invalidate here
+	if principal.Token != "" {
+		if err := h.authService.Signout(c.Request.Context(), principal.UserID, principal.Token); err != nil {
+			middleware.AbortWithError(c, err)
+			return
+		}
+	}
+
+	h.authMiddleware.EndSession(c)
+	c.Status(http.StatusNoContent)
+}
+
 func (h *Handlers) GetOAuthURL(c *gin.Context) {
 	provider := c.Param("provider")
 	if provider != "google" && provider != "github" {
-		c.JSON(http.StatusBadRequest, models.NewErrorsResponse(http.StatusBadRequest, errors.ErrInvalidProvider.Error()))
+		middleware.AbortWithError(c, errors.ErrInvalidProvider)
 		return
 	}
 
 	url, err := h.authService.GetOAuthURL(provider)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.NewErrorsResponse(http.StatusBadRequest, err.Error()))
+		middleware.AbortWithError(c, err)
 		return
 	}
 
@@ -79,7 +139,7 @@ func (h *Handlers) OAuthCallback(c *gin.Context) {
 	state := c.Query("state")
 
 	if code == "" {
-		c.JSON(http.StatusBadRequest, models.NewErrorsResponse(http.StatusBadRequest, errors.ErrCodeRequired.Error()))
+		middleware.AbortWithError(c, errors.ErrCodeRequired)
 
 		return
 	}
@@ -87,43 +147,210 @@ func (h *Handlers) OAuthCallback(c *gin.Context) {
 	// Validate state if needed
 	_ = state
 
-	response, err := h.authService.OAuthLogin(provider, code)
+	response, err := h.authService.OAuthLogin(c.Request.Context(), provider, code)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, models.NewErrorsResponse(http.StatusBadRequest, err.Error()))
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	if !h.startSession(c, response) {
 		return
 	}
 
-	c.JSON(http.StatusOK, models.NewSuccessResponse(response))
+	// The legacy alias keeps the doubly enveloped body its clients were
+	// written against
+	if middleware.DeprecatedRoute(c) {
+		c.JSON(http.StatusOK, models.NewSuccessResponse(response))
+		return
+	}
+	c.JSON(http.StatusOK, response)
 }
 
 // User handlers
 func (h *Handlers) GetProfile(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, models.NewErrorsResponse(http.StatusUnauthorized, errors.ErrNotAuthenticated.Error()))
+	principal, ok := currentUser(c)
+	if !ok {
 		return
 	}
 
-	var userIDUint uint
-	switch v := userID.(type) {
-	case uint:
-		userIDUint = v
-	case int:
-		userIDUint = uint(v)
-	default:
-		c.JSON(http.StatusInternalServerError, models.NewErrorsResponse(http.StatusInternalServerError, errors.ErrInvalidUserIDType.Error()))
+	user, err := h.userService.GetUserByUUID(c.Request.Context(), principal.UserID)
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(user))
+}
+
+func (h *Handlers) ChangePassword(c *gin.Context) {
+	principal, ok := currentUser(c)
+	if !ok {
 		return
 	}
 
-	user, err := h.userService.GetUserByID(userIDUint)
+	var req models.ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithBindError(c, err)
+		return
+	}
+
+	if err := h.userService.ChangePassword(c.Request.Context(), principal.UserID, &req); err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	// The current session was revoked with the others
+	h.authMiddleware.EndSession(c)
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handlers) GetSecurityActivity(c *gin.Context) {
+	principal, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	events, err := h.userService.RecentSecurityActivity(c.Request.Context(), principal.UserID, limit)
 	if err != nil {
-		c.JSON(http.StatusNotFound, models.NewErrorsResponse(http.StatusNotFound, errors.ErrUserNotFound.Error()))
+		middleware.AbortWithError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, models.NewSuccessResponse(user))
+	c.JSON(http.StatusOK, models.NewSuccessResponse(events))
 }
 
-func (h *Handlers) HealthCheck(c *gin.Context) {
-	c.JSON(http.StatusOK, models.NewSuccessResponse(map[string]any{"code": http.StatusOK, "status": "ok", "message": "Server is running"}))
+// Account lifecycle handlers
+func (h *Handlers) DeleteAccount(c *gin.Context) {
+	principal, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var req models.DeleteAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithBindError(c, err)
+		return
+	}
+
+	deletion, err := h.accountService.RequestDeletion(c.Request.Context(), principal.UserID, &req)
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, models.NewSuccessResponse(deletion))
+}
+
+func (h *Handlers) CancelAccountDeletion(c *gin.Context) {
+	principal, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	if err := h.accountService.CancelDeletion(c.Request.Context(), principal.UserID); err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handlers) ExportAccount(c *gin.Context) {
+	principal, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	archive, err := h.accountService.Export(c.Request.Context(), principal.UserID, c.Query("format"))
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+archive.Filename+`"`)
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, archive.ContentType, archive.Data)
+}
+
+// Access token handlers
+func (h *Handlers) ListAccessTokens(c *gin.Context) {
+	principal, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	tokens, err := h.accessTokenService.List(c.Request.Context(), principal.UserID)
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, models.NewSuccessResponse(tokens))
+}
+
+func (h *Handlers) CreateAccessToken(c *gin.Context) {
+	principal, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var req models.CreateAccessTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		middleware.AbortWithBindError(c, err)
+		return
+	}
+
+	token, err := h.accessTokenService.Create(c.Request.Context(), principal.UserID, principal, &req)
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, models.NewSuccessResponse(token))
+}
+
+func (h *Handlers) RevokeAccessToken(c *gin.Context) {
+	principal, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	if err := h.accessTokenService.Revoke(c.Request.Context(), principal.UserID, c.Param("id")); err != nil {
+		middleware.AbortWithError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// startSession moves the issued token into the session cookie when cookie
+// mode is enabled, so that it is not exposed to scripts in the response body.
+// When the cookies cannot be set an error response is written and false is returned.
+func (h *Handlers) startSession(c *gin.Context, response *models.SuccessResponse) bool {
+	payload, ok := response.Data.(*models.AuthResponse)
+	if !ok {
+		return true
+	}
+
+	inCookie, err := h.authMiddleware.StartSession(c, payload.Token)
+	if err != nil {
+		middleware.AbortWithError(c, err)
+		return false
+	}
+	if inCookie {
+		payload.Token = ""
+	}
+	return true
+}
+
+// currentUser returns the authenticated caller set by AuthMiddleware.
+// When it is missing an error response is written and false is returned.
+func currentUser(c *gin.Context) (*middleware.Principal, bool) {
+	principal, ok := middleware.CurrentUser(c)
+	if !ok {
+		middleware.AbortWithError(c, errors.ErrNotAuthenticated)
+		return nil, false
+	}
+	return principal, true
 }
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jixlox0/studoto-backend/internal/errors"
+	"github.com/jixlox0/studoto-backend/internal/health"
+	"github.com/jixlox0/studoto-backend/internal/middleware"
+	"github.com/jixlox0/studoto-backend/internal/models"
+)
+
+// HealthDetailsHeader carries the token that unlocks the per-check report
+const HealthDetailsHeader = "X-Health-Token"
+
+// Health handlers
+
+// LivenessCheck reports that the process is up and serving HTTP. It does not
+// look at dependencies, so an outage elsewhere never gets the pod restarted.
+func (h *Handlers) LivenessCheck(c *gin.Context) {
+	c.JSON(http.StatusOK, models.NewSuccessResponse(map[string]any{"status": "ok"}))
+}
+
+// ReadinessCheck reports whether the service should receive traffic: it is
+// not ready while starting up or draining, or when a critical dependency
+// check fails.
+func (h *Handlers) ReadinessCheck(c *gin.Context) {
+	// Report unavailable while starting up or draining so that load
+	// balancers stop routing new requests here
+	if !h.readiness.Ready() {
+		middleware.AbortWithError(c, errors.ErrServiceUnavailable)
+		return
+	}
+
+	report := h.prober.Check(c.Request.Context())
+
+	// The per-check report reveals infrastructure details, so anonymous
+	// callers only see the overall status
+	authorized := h.healthDetailsAuthorized(c)
+
+	if !report.Healthy() {
+		if !authorized {
+			middleware.AbortWithError(c, errors.ErrServiceUnavailable)
+			return
+		}
+		_ = c.Error(errors.ErrServiceUnavailable)
+		c.Header("Content-Type", middleware.ProblemContentType)
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, &unhealthyProblem{
+			Problem: middleware.NewProblem(c, errors.ErrServiceUnavailable),
+			Report:  report,
+		})
+		return
+	}
+
+	var body any = map[string]any{"status": report.Status}
+	if authorized {
+		body = report
+	}
+	c.JSON(http.StatusOK, models.NewSuccessResponse(body))
+}
+
+// unhealthyProblem is the problem details of a failed readiness check with
+// the per-check report added as the "report" member.
+type unhealthyProblem struct {
+	*models.Problem
+	Report *health.Report `json:"report"`
+}
+
+func (h *Handlers) healthDetailsAuthorized(c *gin.Context) bool {
+	expected := h.healthConfig.DetailsToken
+	if expected == "" {
+		return false
+	}
+	provided := c.GetHeader(HealthDetailsHeader)
+	return subtle.ConstantTimeCompare([]byte(provided), []byte(expected)) == 1
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jixlox0/studoto-backend/internal/config"
+	"github.com/jixlox0/studoto-backend/internal/health"
+	"github.com/jixlox0/studoto-backend/internal/lifecycle"
+	"github.com/jixlox0/studoto-backend/internal/middleware"
+)
+
+type failingChecker struct{}
+
+func (failingChecker) Name() string { return "database" }
+
+func (failingChecker) Check(ctx context.Context) error {
+	return stderrors.New("connection refused")
+}
+
+func TestReadinessCheckReportsProblem(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		ready      bool
+		token      string
+		wantReport bool
+	}{
+		{name: "draining", token: "secret"},
+		{name: "failing check", ready: true},
+		{name: "failing check with health token", ready: true, token: "secret", wantReport: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			readiness := lifecycle.NewReadiness()
+			readiness.SetReady(tt.ready)
+			prober := health.NewProber(time.Second, 0)
+			prober.Register(failingChecker{}, true)
+			h := &Handlers{readiness: readiness, prober: prober, healthConfig: config.HealthConfig{DetailsToken: "secret"}}
+			router := gin.New()
+			router.GET("/health/ready", h.ReadinessCheck)
+
+			req := httptest.NewRequest(http.MethodGet, "/health/ready", nil)
+			req.Header.Set(HealthDetailsHeader, tt.token)
+			recorder := httptest.NewRecorder()
+			router.ServeHTTP(recorder, req)
+
+			if recorder.Code != http.StatusServiceUnavailable {
+				t.Fatalf("status = %d, want %d", recorder.Code, http.StatusServiceUnavailable)
+			}
+			if got := recorder.Header().Get("Content-Type"); got != middleware.ProblemContentType {
+				t.Errorf("Content-Type = %q, want %q", got, middleware.ProblemContentType)
+			}
+			var body struct {
+				Code   string         `json:"code"`
+				Report *health.Report `json:"report"`
+			}
+			if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Code != "service_unavailable" {
+				t.Errorf("code = %q, want service_unavailable", body.Code)
+			}
+			if got := body.Report != nil && len(body.Report.Checks) == 1; got != tt.wantReport {
+				t.Errorf("report = %+v, want it included: %v", body.Report, tt.wantReport)
+			}
+		})
+	}
+}
@@ -0,0 +1,459 @@
+package api
+
+import (
+	"embed"
+	"encoding/json"
+	"io/fs"
+	"maps"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jixlox0/studoto-backend/internal/config"
+	"github.com/jixlox0/studoto-backend/internal/errors"
+	"github.com/jixlox0/studoto-backend/internal/middleware"
+	"github.com/jixlox0/studoto-backend/internal/models"
+	"github.com/jixlox0/studoto-backend/internal/openapi"
+	"github.com/jixlox0/studoto-backend/internal/pagination"
+	"github.com/jixlox0/studoto-backend/internal/service"
+)
+
+// Paths of the API description and its browsable docs
+const (
+	OpenAPIPath = "/openapi.json"
+	DocsPath    = "/docs"
+)
+
+// Security scheme names used in the document
+const (
+	bearerAuth = "bearerAuth"
+	apiKeyAuth = "apiKeyAuth"
+	cookieAuth = "cookieAuth"
+)
+
+// docsFiles holds the docs page and the Swagger UI assets it loads, which are
+// vendored with `make docs-assets`
+//
+//go:embed docs
+var docsFiles embed.FS
+
+// docsPolicy confines the docs page to the files above. It is served on the
+// API origin, where the CSRF cookie is readable, so no third-party script may
+// run on it.
+const docsPolicy = "default-src 'none'; script-src 'self'; style-src 'self' 'unsafe-inline'; " +
+	"img-src 'self' data:; connect-src 'self'; base-uri 'none'; form-action 'none'; frame-ancestors 'none'"
+
+// apiRoute documents one route registered in NewRouter. The table below is
+// kept by hand next to the router; TestOpenAPIDocumentsEveryRoute fails when
+// a route is added without an entry.
+type apiRoute struct {
+	method      string
+	path        string // as registered with gin and without VersionPrefix, e.g. /api/account/tokens/:id
+	operationID string
+	summary     string
+	tag         string
+	// auth is set for routes behind RequireAuth; scope is the access token
+	// scope they require, if any
+	auth  bool
+	scope string
+	// admin routes also require the admin role
+	admin   bool
+	query   any
+	request any
+	status  int
+	// response is the data of the success envelope; routes answering with
+	// another body list its media types in content instead. For paginated
+	// routes it is the type of one item.
+	response  any
+	paginated *pagination.Spec
+	content   []string
+	// errors are the statuses the handler itself reports, besides the ones
+	// added for authentication, rate limiting and idempotency
+	errors []int
+	// doubleEnvelope is set when the legacy alias wraps the success envelope
+	// in a second one, as it did before the route was versioned
+	doubleEnvelope bool
+}
+
+// securityActivityQuery documents the query of GetSecurityActivity, which
+// reads it without binding
+type securityActivityQuery struct {
+	Limit int `form:"limit" binding:"omitempty,min=1"`
+}
+
+type exportQuery struct {
+	Format string `form:"format" binding:"omitempty,oneof=json zip"`
+}
+
+type oauthCallbackQuery struct {
+	Code  string `form:"code" binding:"required"`
+	State string `form:"state"`
+}
+
+type oauthURLResponse struct {
+	URL string `json:"url"`
+}
+
+type healthStatus struct {
+	Status string `json:"status"`
+}
+
+func apiRoutes(cfg *config.Config) []apiRoute {
+	routes := []apiRoute{
+		// Health
+		{method: http.MethodGet, path: "/health", operationID: "health", summary: "Report readiness (kept for existing monitors)", tag: "health",
+			status: http.StatusOK, response: healthStatus{}, errors: []int{http.StatusServiceUnavailable}},
+		{method: http.MethodGet, path: "/health/live", operationID: "liveness", summary: "Report that the process is serving", tag: "health",
+			status: http.StatusOK, response: healthStatus{}},
+		{method: http.MethodGet, path: "/health/ready", operationID: "readiness", summary: "Report whether the service should receive traffic", tag: "health",
+			status: http.StatusOK, response: healthStatus{}, errors: []int{http.StatusServiceUnavailable}},
+
+		// Auth
+		{method: http.MethodPost, path: "/auth/signup", operationID: "signup", summary: "Create an account with email and password", tag: "auth",
+			request: models.CreateUserRequest{}, status: http.StatusCreated, response: models.AuthResponse{}, errors: []int{http.StatusConflict}},
+		{method: http.MethodPost, path: "/auth/signin", operationID: "signin", summary: "Sign in with email and password", tag: "auth",
+			request: models.LoginRequest{}, status: http.StatusOK, response: models.AuthResponse{}, errors: []int{http.StatusUnauthorized}},
+		{method: http.MethodPost, path: "/auth/signout", operationID: "signout", summary: "End the current session", tag: "auth",
+			auth: true, status: http.StatusNoContent},
+		{method: http.MethodGet, path: "/auth/oauth/:provider", operationID: "getOAuthURL", summary: "Get the authorization URL of an OAuth provider", tag: "auth",
+			status: http.StatusOK, response: oauthURLResponse{}, errors: []int{http.StatusBadRequest}},
+		{method: http.MethodGet, path: "/auth/callback/:provider", operationID: "oauthCallback", summary: "Complete an OAuth login", tag: "auth",
+			query: oauthCallbackQuery{}, status: http.StatusOK, response: models.AuthResponse{}, errors: []int{http.StatusConflict}, doubleEnvelope: true},
+
+		// Account
+		{method: http.MethodGet, path: "/api/account/profile", operationID: "getProfile", summary: "Get the current user", tag: "account",
+			auth: true, scope: models.ScopeProfileRead, status: http.StatusOK, response: models.User{}, errors: []int{http.StatusNotFound}},
+		{method: http.MethodPut, path: "/api/account/password", operationID: "changePassword", summary: "Change the password", tag: "account",
+			auth: true, scope: models.ScopeAccountWrite, request: models.ChangePasswordRequest{}, status: http.StatusNoContent, errors: []int{http.StatusNotFound}},
+		{method: http.MethodGet, path: "/api/account/security-activity", operationID: "getSecurityActivity", summary: "List recent security events of the current user", tag: "account",
+			auth: true, scope: models.ScopeProfileRead, query: securityActivityQuery{}, status: http.StatusOK, response: []models.AuditEvent{}},
+		{method: http.MethodDelete, path: "/api/account", operationID: "deleteAccount", summary: "Schedule the account for deletion", tag: "account",
+			auth: true, scope: models.ScopeAccountWrite, request: models.DeleteAccountRequest{}, status: http.StatusAccepted, response: models.AccountDeletionResponse{},
+			errors: []int{http.StatusForbidden, http.StatusNotFound}},
+		{method: http.MethodPost, path: "/api/account/deletion/cancel", operationID: "cancelAccountDeletion", summary: "Cancel a scheduled account deletion", tag: "account",
+			auth: true, scope: models.ScopeAccountWrite, status: http.StatusNoContent, errors: []int{http.StatusNotFound, http.StatusConflict}},
+		{method: http.MethodGet, path: "/api/account/export", operationID: "exportAccount", summary: "Download the data held about the current user", tag: "account",
+			auth: true, scope: models.ScopeProfileRead, query: exportQuery{}, status: http.StatusOK, content: []string{"application/json", "application/zip"},
+			errors: []int{http.StatusBadRequest, http.StatusNotFound}},
+
+		// Personal access tokens
+		{method: http.MethodGet, path: "/api/account/tokens", operationID: "listAccessTokens", summary: "List personal access tokens", tag: "tokens",
+			auth: true, scope: models.ScopeTokensRead, status: http.StatusOK, response: []models.AccessTokenResponse{}},
+		{method: http.MethodPost, path: "/api/account/tokens", operationID: "createAccessToken", summary: "Create a personal access token", tag: "tokens",
+			auth: true, scope: models.ScopeTokensWrite, request: models.CreateAccessTokenRequest{}, status: http.StatusCreated, response: models.CreatedAccessTokenResponse{}},
+		{method: http.MethodDelete, path: "/api/account/tokens/:id", operationID: "revokeAccessToken", summary: "Revoke a personal access token", tag: "tokens",
+			auth: true, scope: models.ScopeTokensWrite, status: http.StatusNoContent, errors: []int{http.StatusNotFound}},
+
+		// Admin
+		{method: http.MethodGet, path: "/api/admin/audit-events", operationID: "listAuditEvents", summary: "Search the audit log", tag: "admin",
+			auth: true, scope: models.ScopeAdmin, admin: true, query: models.AuditEventFilter{}, status: http.StatusOK, response: models.AuditEventPage{}},
+		{method: http.MethodGet, path: "/api/admin/users", operationID: "listUsers", summary: "List users", tag: "admin",
+			auth: true, scope: models.ScopeAdmin, admin: true, paginated: service.UserPagination, status: http.StatusOK, response: models.User{}},
+		{method: http.MethodPatch, path: "/api/admin/users/:id/role", operationID: "updateUserRole", summary: "Change the role of a user", tag: "admin",
+			auth: true, scope: models.ScopeAdmin, admin: true, request: models.UpdateUserRoleRequest{}, status: http.StatusOK, response: models.User{},
+			errors: []int{http.StatusNotFound}},
+
+		// Docs
+		{method: http.MethodGet, path: OpenAPIPath, operationID: "getOpenAPI", summary: "Get this API description", tag: "docs",
+			status: http.StatusOK, content: []string{"application/json"}},
+		{method: http.MethodGet, path: DocsPath, operationID: "getDocs", summary: "Browse this API description", tag: "docs",
+			status: http.StatusOK, content: []string{"text/html"}},
+		{method: http.MethodGet, path: DocsPath + "/:file", operationID: "getDocsAsset", summary: "Get a script or stylesheet of the docs page", tag: "docs",
+			status: http.StatusOK, content: []string{"text/javascript", "text/css"}, errors: []int{http.StatusNotFound}},
+	}
+	if cfg.Metrics.OnMainListener() {
+		routes = append(routes, apiRoute{method: http.MethodGet, path: cfg.Metrics.Path, operationID: "metrics", summary: "Scrape Prometheus metrics", tag: "metrics",
+			status: http.StatusOK, content: []string{"text/plain"}})
+	}
+	return routes
+}
+
+// NewOpenAPIDocument describes the routes of NewRouter for the given
+// configuration as an OpenAPI 3 document. Versioned routes are listed under
+// VersionPrefix, and again as deprecated operations when the legacy aliases
+// are enabled.
+func NewOpenAPIDocument(cfg *config.Config) *openapi.Document {
+	schemas := openapi.NewSchemas()
+	problem := schemas.For(models.Problem{})
+	envelope := schemas.For(models.SuccessResponse{})
+	paginatedEnvelope := schemas.For(models.PaginatedResponse{})
+
+	doc := &openapi.Document{
+		OpenAPI: openapi.Version,
+		Info: openapi.Info{
+			Title: "Studoto API",
+			Description: "Successful responses wrap their payload in a `{\"success\": true, \"data\": ...}` envelope. " +
+				"Errors are RFC 7807 problem details whose `code` is stable; their `detail` is translated according to Accept-Language.",
+			Version: "1.0.0",
+		},
+		Tags: []openapi.Tag{
+			{Name: "auth", Description: "Signup, signin and OAuth login"},
+			{Name: "account", Description: "The current user's account"},
+			{Name: "tokens", Description: "Personal access tokens"},
+			{Name: "admin", Description: "Administration; requires the admin role and scope"},
+			{Name: "health", Description: "Probes for orchestrators and load balancers"},
+			{Name: "docs", Description: "This API description"},
+		},
+		Paths: make(map[string]*openapi.PathItem),
+		Components: openapi.Components{
+			SecuritySchemes: map[string]*openapi.SecurityScheme{
+				bearerAuth: {Type: "http", Scheme: "bearer", Description: "Session token or personal access token"},
+				apiKeyAuth: {Type: "apiKey", In: "header", Name: "X-Auth-Key", Description: "Session token or personal access token"},
+				cookieAuth: {Type: "apiKey", In: "cookie", Name: cfg.Session.CookieName,
+					Description: "Session cookie set by signin when cookie sessions are enabled; unsafe methods also need the " + cfg.Session.CSRFHeaderName + " header"},
+			},
+		},
+	}
+	if cfg.Metrics.OnMainListener() {
+		doc.Tags = append(doc.Tags, openapi.Tag{Name: "metrics", Description: "Prometheus metrics"})
+	}
+
+	for _, route := range apiRoutes(cfg) {
+		path, params := openAPIPath(route.path)
+		op := &openapi.Operation{
+			OperationID: route.operationID,
+			Summary:     route.summary,
+			Tags:        []string{route.tag},
+			Parameters:  params,
+			Responses:   make(map[string]*openapi.Response),
+		}
+
+		// Routes in the /auth and /api groups are versioned and rate
+		// limited, and accept an Idempotency-Key on unsafe methods
+		limited := strings.HasPrefix(route.path, "/auth/") || strings.HasPrefix(route.path, "/api/")
+		mutating := route.method != http.MethodGet
+		if limited && mutating {
+			op.Parameters = append(op.Parameters, &openapi.Parameter{
+				Name:        middleware.IdempotencyKeyHeader,
+				In:          "header",
+				Description: "Makes the request safe to retry; retries with the same key get the first response",
+				Schema:      &openapi.Schema{Type: "string", MaxLength: intPtr(255)},
+			})
+		}
+		if route.query != nil {
+			op.Parameters = append(op.Parameters, schemas.QueryParameters(route.query)...)
+		}
+		if route.paginated != nil {
+			op.Parameters = append(op.Parameters, paginationParameters(route.paginated)...)
+		}
+		if route.request != nil {
+			op.RequestBody = &openapi.RequestBody{
+				Required: true,
+				Content:  map[string]*openapi.MediaType{"application/json": {Schema: schemas.For(route.request)}},
+			}
+		}
+
+		success := &openapi.Response{Description: http.StatusText(route.status)}
+		switch {
+		case route.paginated != nil:
+			success.Content = map[string]*openapi.MediaType{"application/json": {Schema: &openapi.Schema{AllOf: []*openapi.Schema{
+				paginatedEnvelope,
+				{Type: "object", Properties: map[string]*openapi.Schema{"data": {Type: "array", Items: schemas.For(route.response)}}},
+			}}}}
+		case route.response != nil:
+			success.Content = map[string]*openapi.MediaType{"application/json": {Schema: &openapi.Schema{AllOf: []*openapi.Schema{
+				envelope,
+				{Type: "object", Properties: map[string]*openapi.Schema{"data": schemas.For(route.response)}},
+			}}}}
+		case len(route.content) > 0:
+			success.Content = make(map[string]*openapi.MediaType)
+			for _, mediaType := range route.content {
+				success.Content[mediaType] = &openapi.MediaType{Schema: rawSchema(mediaType)}
+			}
+		}
+		op.Responses[strconv.Itoa(route.status)] = success
+
+		statuses := append([]int(nil), route.errors...)
+		if route.request != nil || route.query != nil || route.paginated != nil {
+			statuses = append(statuses, http.StatusBadRequest)
+		}
+		if route.auth {
+			statuses = append(statuses, http.StatusUnauthorized)
+			op.Security = []openapi.SecurityRequirement{{bearerAuth: {}}, {apiKeyAuth: {}}, {cookieAuth: {}}}
+		}
+		if route.scope != "" {
+			statuses = append(statuses, http.StatusForbidden)
+			op.Description = "Access tokens need the `" + route.scope + "` scope."
+			if route.admin {
+				op.Description += " The user must have the `" + models.RoleAdmin + "` role."
+			}
+		}
+		if limited {
+			statuses = append(statuses, http.StatusTooManyRequests)
+		}
+		if limited && mutating {
+			statuses = append(statuses, http.StatusConflict, http.StatusUnprocessableEntity)
+		}
+		for _, status := range statuses {
+			op.Responses[strconv.Itoa(status)] = problemResponse(status, problem)
+		}
+		op.Responses["default"] = &openapi.Response{
+			Description: "Unexpected error",
+			Content:     map[string]*openapi.MediaType{middleware.ProblemContentType: {Schema: problem}},
+		}
+
+		if !limited {
+			doc.AddOperation(route.method, path, op)
+			continue
+		}
+		doc.AddOperation(route.method, VersionPrefix+path, op)
+		if cfg.API.LegacyRoutes {
+			legacy := *op
+			legacy.OperationID += "Legacy"
+			legacy.Deprecated = true
+			legacy.Description = strings.TrimSpace("Deprecated alias of `" + route.method + " " + VersionPrefix + path + "`; " +
+				"responses carry Deprecation, Sunset and Link headers. " + op.Description)
+			if route.doubleEnvelope {
+				legacy.Description += " The success envelope is itself wrapped in one."
+				legacy.Responses = maps.Clone(op.Responses)
+				legacy.Responses[strconv.Itoa(route.status)] = &openapi.Response{
+					Description: success.Description,
+					Content: map[string]*openapi.MediaType{"application/json": {Schema: &openapi.Schema{AllOf: []*openapi.Schema{
+						envelope,
+						{Type: "object", Properties: map[string]*openapi.Schema{"data": success.Content["application/json"].Schema}},
+					}}}},
+				}
+			}
+			doc.AddOperation(route.method, path, &legacy)
+		}
+	}
+
+	doc.Components.Schemas = schemas.Components()
+	return doc
+}
+
+// paginationParameters describes the limit, cursor, sort and filter
+// parameters a pagination spec accepts.
+func paginationParameters(spec *pagination.Spec) []*openapi.Parameter {
+	var sortable []string
+	for _, field := range spec.Fields {
+		if field.Sortable {
+			sortable = append(sortable, "`"+field.Name+"`")
+		}
+	}
+	params := []*openapi.Parameter{
+		{Name: pagination.LimitParam, In: "query", Description: "Page size",
+			Schema: &openapi.Schema{Type: "integer", Minimum: floatPtr(1), Maximum: floatPtr(float64(spec.MaxLimit)), Default: spec.DefaultLimit}},
+		{Name: pagination.CursorParam, In: "query", Description: "Opaque cursor from the next or prev link of a previous page",
+			Schema: &openapi.Schema{Type: "string"}},
+		{Name: pagination.SortParam, In: "query",
+			Description: "Comma-separated fields, each prefixed with - for descending order; one of " + strings.Join(sortable, ", "),
+			Schema:      &openapi.Schema{Type: "string", Default: spec.DefaultSort}},
+	}
+
+	for _, field := range spec.Fields {
+		for _, op := range field.Operators {
+			name := pagination.FilterParam + "[" + field.Name + "]"
+			if op != pagination.Eq {
+				name += "[" + op + "]"
+			}
+			schema := filterSchema(field.Type)
+			description := "Filter on " + field.Name + " (" + op + ")"
+			if op == pagination.In {
+				schema = &openapi.Schema{Type: "string"}
+				description += "; values are separated by commas"
+			}
+			params = append(params, &openapi.Parameter{Name: name, In: "query", Description: description, Schema: schema})
+		}
+	}
+	return params
+}
+
+func filterSchema(t pagination.Type) *openapi.Schema {
+	switch t {
+	case pagination.Int:
+		return &openapi.Schema{Type: "integer", Format: "int64"}
+	case pagination.Time:
+		return &openapi.Schema{Type: "string", Format: "date-time"}
+	case pagination.Bool:
+		return &openapi.Schema{Type: "boolean"}
+	}
+	return &openapi.Schema{Type: "string"}
+}
+
+// openAPIPath converts a gin route path to an OpenAPI path template and
+// returns its path parameters.
+func openAPIPath(route string) (string, []*openapi.Parameter) {
+	var params []*openapi.Parameter
+	segments := strings.Split(route, "/")
+	for i, segment := range segments {
+		if name, ok := strings.CutPrefix(segment, ":"); ok {
+			segments[i] = "{" + name + "}"
+			params = append(params, &openapi.Parameter{Name: name, In: "path", Required: true, Schema: &openapi.Schema{Type: "string"}})
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+func problemResponse(status int, problem *openapi.Schema) *openapi.Response {
+	return &openapi.Response{
+		Description: http.StatusText(status),
+		Content:     map[string]*openapi.MediaType{middleware.ProblemContentType: {Schema: problem}},
+	}
+}
+
+// rawSchema describes a response body that is not enveloped
+func rawSchema(mediaType string) *openapi.Schema {
+	switch {
+	case mediaType == "application/json":
+		return &openapi.Schema{Type: "object"}
+	case strings.HasPrefix(mediaType, "text/"):
+		return &openapi.Schema{Type: "string"}
+	}
+	return &openapi.Schema{Type: "string", Format: "binary"}
+}
+
+func intPtr(n int) *int {
+	return &n
+}
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
+// openAPIHandler serves the document, encoded once up front.
+func openAPIHandler(doc *openapi.Document) (gin.HandlerFunc, error) {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	return func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+	}, nil
+}
+
+// docsHandler serves a Swagger UI page that renders the document.
+func docsHandler(c *gin.Context) {
+	serveDocsFile(c, "index.html")
+}
+
+// docsAssetHandler serves the scripts and stylesheets of the docs page.
+func docsAssetHandler(c *gin.Context) {
+	name := c.Param("file")
+	if name == "index.html" {
+		c.Redirect(http.StatusMovedPermanently, DocsPath)
+		return
+	}
+	serveDocsFile(c, name)
+}
+
+func serveDocsFile(c *gin.Context, name string) {
+	if !fs.ValidPath(name) {
+		middleware.AbortWithError(c, errors.ErrRouteNotFound)
+		return
+	}
+	data, err := fs.ReadFile(docsFiles, path.Join("docs", name))
+	if err != nil {
+		middleware.AbortWithError(c, errors.ErrRouteNotFound)
+		return
+	}
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	c.Header("Content-Security-Policy", docsPolicy)
+	c.Data(http.StatusOK, contentType, data)
+}
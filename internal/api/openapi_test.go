@@ -0,0 +1,159 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jixlox0/studoto-backend/internal/config"
+	"github.com/jixlox0/studoto-backend/internal/i18n"
+	"github.com/jixlox0/studoto-backend/internal/openapi"
+	"github.com/jixlox0/studoto-backend/internal/tracing"
+)
+
+func testConfig() *config.Config {
+	return &config.Config{
+		Server:  config.ServerConfig{RequestTimeoutSeconds: 30},
+		Session: config.SessionConfig{CookieName: "studoto_session", CSRFHeaderName: "X-CSRF-Token"},
+		API:     config.APIConfig{LegacyRoutes: true, LegacyDeprecatedAt: "2026-10-18", LegacySunset: "2027-04-30"},
+		Tracing: config.TracingConfig{Exporter: tracing.ExporterNone},
+	}
+}
+
+// TestOpenAPIDocumentsEveryRoute fails when a route registered in NewRouter
+// has no entry in apiRoutes, or an entry no longer matches a route.
+func TestOpenAPIDocumentsEveryRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := testConfig()
+	tracer, err := tracing.NewProvider(cfg.Tracing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundle, err := i18n.NewBundle()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Nothing is served, so the handlers and middleware need no dependencies
+	router, err := NewRouter(&Handlers{}, cfg, nil, nil, tracer, bundle, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	doc := NewOpenAPIDocument(cfg)
+	registered := make(map[string]bool)
+	for _, route := range router.Routes() {
+		path, _ := openAPIPath(route.Path)
+		registered[route.Method+" "+path] = true
+		if doc.Operation(route.Method, path) == nil {
+			t.Errorf("%s %s is not documented; add it to apiRoutes", route.Method, route.Path)
+		}
+	}
+	for path, item := range doc.Paths {
+		for method := range *item {
+			if !registered[strings.ToUpper(method)+" "+path] {
+				t.Errorf("%s %s is documented but not registered", strings.ToUpper(method), path)
+			}
+		}
+	}
+}
+
+func TestOpenAPIDocumentsMetricsWhenEnabled(t *testing.T) {
+	cfg := testConfig()
+	cfg.Metrics = config.MetricsConfig{Enabled: true, Path: "/metrics"}
+
+	if NewOpenAPIDocument(cfg).Operation(http.MethodGet, "/metrics") == nil {
+		t.Error("GET /metrics is not documented")
+	}
+}
+
+func TestOpenAPIHandlerServesDocument(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler, err := openAPIHandler(NewOpenAPIDocument(testConfig()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	router := gin.New()
+	router.GET(OpenAPIPath, handler)
+
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, OpenAPIPath, nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", recorder.Code, http.StatusOK)
+	}
+
+	var doc struct {
+		OpenAPI    string `json:"openapi"`
+		Components struct {
+			Schemas map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc.OpenAPI == "" {
+		t.Error("openapi version is missing")
+	}
+	for _, name := range []string{"CreateUserRequest", "LoginRequest", "SuccessResponse", "Problem"} {
+		if _, ok := doc.Components.Schemas[name]; !ok {
+			t.Errorf("schema %s is missing", name)
+		}
+	}
+}
+
+func TestDocsServesOnlyLocalFiles(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET(DocsPath, docsHandler)
+	router.GET(DocsPath+"/:file", docsAssetHandler)
+
+	tests := []struct {
+		path       string
+		wantStatus int
+		wantType   string
+	}{
+		{path: DocsPath, wantStatus: http.StatusOK, wantType: "text/html; charset=utf-8"},
+		{path: DocsPath + "/init.js", wantStatus: http.StatusOK, wantType: "text/javascript; charset=utf-8"},
+		{path: DocsPath + "/index.html", wantStatus: http.StatusMovedPermanently},
+		{path: DocsPath + "/missing.js", wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		recorder := httptest.NewRecorder()
+		router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if recorder.Code != tt.wantStatus {
+			t.Fatalf("%s: status = %d, want %d", tt.path, recorder.Code, tt.wantStatus)
+		}
+		if tt.wantStatus != http.StatusOK {
+			continue
+		}
+		if got := recorder.Header().Get("Content-Type"); got != tt.wantType {
+			t.Errorf("%s: Content-Type = %q, want %q", tt.path, got, tt.wantType)
+		}
+		if got := recorder.Header().Get("Content-Security-Policy"); got != docsPolicy {
+			t.Errorf("%s: Content-Security-Policy = %q, want %q", tt.path, got, docsPolicy)
+		}
+		if body := recorder.Body.String(); strings.Contains(body, "://") {
+			t.Errorf("%s refers to another origin:\n%s", tt.path, body)
+		}
+	}
+}
+
+func TestOpenAPIDocumentsOAuthCallbackEnvelopes(t *testing.T) {
+	doc := NewOpenAPIDocument(testConfig())
+	schema := func(path string) *openapi.Schema {
+		op := doc.Operation(http.MethodGet, path)
+		if op == nil {
+			t.Fatalf("GET %s is not documented", path)
+		}
+		return op.Responses["200"].Content["application/json"].Schema
+	}
+
+	current := schema(VersionPrefix + "/auth/callback/{provider}")
+	legacy := schema("/auth/callback/{provider}")
+	// The legacy body wraps the current one in a second envelope
+	if len(legacy.AllOf) != 2 || legacy.AllOf[1].Properties["data"] != current {
+		t.Errorf("legacy callback response = %+v, want the current response as its data", legacy)
+	}
+}
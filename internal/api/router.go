@@ -1,46 +1,152 @@
 package api
 
 import (
+	"fmt"
+	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/jixlox0/studoto-backend/internal/config"
+	"github.com/jixlox0/studoto-backend/internal/errors"
+	"github.com/jixlox0/studoto-backend/internal/i18n"
+	"github.com/jixlox0/studoto-backend/internal/metrics"
+	"github.com/jixlox0/studoto-backend/internal/middleware"
+	"github.com/jixlox0/studoto-backend/internal/models"
+	"github.com/jixlox0/studoto-backend/internal/tracing"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
-func NewRouter(handlers *Handlers, cfg *config.Config) *gin.Engine {
-	router := gin.Default()
+// VersionPrefix is the path prefix of the current API version
+const VersionPrefix = "/v1"
 
-	// Configure CORS
-	corsConfig := cors.Config{
-		AllowOrigins:     cfg.Server.CORS.AllowedOrigins,
-		AllowMethods:     cfg.Server.CORS.AllowedMethods,
-		AllowHeaders:     cfg.Server.CORS.AllowedHeaders,
-		ExposeHeaders:    cfg.Server.CORS.ExposedHeaders,
-		AllowCredentials: cfg.Server.CORS.AllowCredentials,
-		MaxAge:           time.Duration(cfg.Server.CORS.MaxAge) * time.Second,
+func NewRouter(handlers *Handlers, cfg *config.Config, logger *slog.Logger, m *metrics.Metrics, tracer *tracing.Provider, bundle *i18n.Bundle, rateLimiter *middleware.RateLimiter, idempotency *middleware.Idempotency) (*gin.Engine, error) {
+	router := gin.New()
+	router.HandleMethodNotAllowed = true
+	router.NoRoute(func(c *gin.Context) {
+		middleware.AbortWithError(c, errors.ErrRouteNotFound)
+	})
+	router.NoMethod(func(c *gin.Context) {
+		middleware.AbortWithError(c, errors.ErrMethodNotAllowed)
+	})
+	if err := middleware.ConfigureValidator(bundle); err != nil {
+		return nil, fmt.Errorf("failed to configure validation: %w", err)
 	}
+	// Client IPs key rate limits and audit events, so X-Forwarded-For is only
+	// believed when it comes from a configured proxy
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("invalid trusted proxies: %w", err)
+	}
+	router.Use(otelgin.Middleware(tracer.ServiceName,
+		otelgin.WithTracerProvider(tracer),
+		otelgin.WithGinFilter(func(c *gin.Context) bool {
+			// Probes and scrapes would drown out real traffic
+			route := c.FullPath()
+			return !strings.HasPrefix(route, "/health") && route != cfg.Metrics.Path
+		}),
+	))
+	router.Use(middleware.ErrorDetails(cfg.Server.DetailedErrors))
+	router.Use(middleware.RequestID(logger))
+	router.Use(middleware.Language(bundle))
+	router.Use(middleware.RequestLogger())
+	router.Use(middleware.Recovery())
+	router.Use(middleware.Timeout(time.Duration(cfg.Server.RequestTimeoutSeconds) * time.Second))
+	router.Use(middleware.ErrorHandler())
+	if cfg.Metrics.Enabled {
+		router.Use(middleware.Metrics(m))
+	}
+
+	// Configure CORS; without allowed origins only same-origin requests work
+	if len(cfg.Server.CORS.AllowedOrigins) > 0 {
+		corsConfig := cors.Config{
+			AllowOrigins:     cfg.Server.CORS.AllowedOrigins,
+			AllowMethods:     cfg.Server.CORS.AllowedMethods,
+			AllowHeaders:     cfg.Server.CORS.AllowedHeaders,
+			ExposeHeaders:    cfg.Server.CORS.ExposedHeaders,
+			AllowCredentials: cfg.Server.CORS.AllowCredentials,
+			MaxAge:           time.Duration(cfg.Server.CORS.MaxAge) * time.Second,
+		}
+		router.Use(cors.New(corsConfig))
+	}
+	router.Use(middleware.ClientInfo())
+
+	// Prometheus metrics, unless they have a listener of their own
+	if cfg.Metrics.OnMainListener() {
+		router.GET(cfg.Metrics.Path, gin.WrapH(m.Handler(cfg.Metrics.Token)))
+	}
+
+	// API description and docs
+	openAPI, err := openAPIHandler(NewOpenAPIDocument(cfg))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode the API description: %w", err)
+	}
+	router.GET(OpenAPIPath, openAPI)
+	router.GET(DocsPath, docsHandler)
+	router.GET(DocsPath+"/:file", docsAssetHandler)
 
-	router.Use(cors.New(corsConfig))
+	// Health checks; /health is kept for existing monitors and behaves like /health/ready
+	router.GET("/health", handlers.ReadinessCheck)
+	router.GET("/health/live", handlers.LivenessCheck)
+	router.GET("/health/ready", handlers.ReadinessCheck)
 
-	// Health check
-	router.GET("/health", handlers.HealthCheck)
+	// Versioned API. The unversioned paths are kept as deprecated aliases
+	// while deployed clients move over
+	registerRoutes(router.Group(VersionPrefix), handlers, rateLimiter, idempotency)
+	if cfg.API.LegacyRoutes {
+		deprecatedAt, sunset, err := cfg.API.LegacySchedule()
+		if err != nil {
+			return nil, err
+		}
+		legacy := router.Group("", middleware.Deprecation(m, deprecatedAt, sunset, VersionPrefix))
+		registerRoutes(legacy, handlers, rateLimiter, idempotency)
+	}
+
+	return router, nil
+}
 
+// registerRoutes registers the auth, account and admin routes under parent.
+func registerRoutes(parent *gin.RouterGroup, handlers *Handlers, rateLimiter *middleware.RateLimiter, idempotency *middleware.Idempotency) {
 	// Auth routes
-	auth := router.Group("/auth")
+	// Idempotency keys are scoped to the caller, so on signout they are
+	// handled once the caller is authenticated
+	auth := parent.Group("/auth")
+	auth.Use(rateLimiter.Handler())
 	{
-		auth.POST("/signup", handlers.Signup)
-		auth.POST("/signin", handlers.Signin)
+		auth.POST("/signup", idempotency.Handler(), handlers.Signup)
+		auth.POST("/signin", idempotency.Handler(), middleware.NoStoredResponse(), handlers.Signin)
+		auth.POST("/signout", handlers.authMiddleware.RequireAuth(), idempotency.Handler(), handlers.Signout)
 		auth.GET("/oauth/:provider", handlers.GetOAuthURL)
 		auth.GET("/callback/:provider", handlers.OAuthCallback)
 	}
 
 	// Protected routes
-	protected := router.Group("/api")
-	protected.Use(handlers.authMiddleware.RequireAuth())
+	protected := parent.Group("/api")
+	protected.Use(handlers.authMiddleware.RequireAuth(), rateLimiter.Handler(), idempotency.Handler())
 	{
-		protected.GET("/account/profile", handlers.GetProfile)
+		protected.GET("/account/profile", handlers.authMiddleware.RequireScope(models.ScopeProfileRead), handlers.GetProfile)
+
+		// Personal access tokens
+		protected.GET("/account/tokens", handlers.authMiddleware.RequireScope(models.ScopeTokensRead), handlers.ListAccessTokens)
+		protected.POST("/account/tokens", handlers.authMiddleware.RequireScope(models.ScopeTokensWrite), middleware.NoStoredResponse(), handlers.CreateAccessToken)
+		protected.DELETE("/account/tokens/:id", handlers.authMiddleware.RequireScope(models.ScopeTokensWrite), handlers.RevokeAccessToken)
+
+		// Account security
+		protected.PUT("/account/password", handlers.authMiddleware.RequireScope(models.ScopeAccountWrite), handlers.ChangePassword)
+		protected.GET("/account/security-activity", handlers.authMiddleware.RequireScope(models.ScopeProfileRead), handlers.GetSecurityActivity)
+
+		// Account deletion and data export
+		protected.DELETE("/account", handlers.authMiddleware.RequireScope(models.ScopeAccountWrite), handlers.DeleteAccount)
+		protected.POST("/account/deletion/cancel", handlers.authMiddleware.RequireScope(models.ScopeAccountWrite), handlers.CancelAccountDeletion)
+		protected.GET("/account/export", handlers.authMiddleware.RequireScope(models.ScopeProfileRead), handlers.ExportAccount)
 	}
 
-	return router
+	// Admin routes
+	admin := protected.Group("/admin")
+	admin.Use(handlers.authMiddleware.RequireScope(models.ScopeAdmin), handlers.authMiddleware.RequireRole(models.RoleAdmin))
+	{
+		admin.GET("/audit-events", handlers.ListAuditEvents)
+		admin.GET("/users", handlers.ListUsers)
+		admin.PATCH("/users/:id/role", handlers.UpdateUserRole)
+	}
 }
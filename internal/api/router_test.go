@@ -0,0 +1,102 @@
+package api
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jixlox0/studoto-backend/internal/config"
+	"github.com/jixlox0/studoto-backend/internal/i18n"
+	"github.com/jixlox0/studoto-backend/internal/metrics"
+	"github.com/jixlox0/studoto-backend/internal/middleware"
+	"github.com/jixlox0/studoto-backend/internal/ratelimit"
+	"github.com/jixlox0/studoto-backend/internal/tracing"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// newTestMetrics returns metrics backed by a database and Redis client that
+// are never connected to.
+func newTestMetrics(t *testing.T) *metrics.Metrics {
+	t.Helper()
+	db, err := gorm.Open(postgres.Open("host=127.0.0.1 port=1"), &gorm.Config{DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1"})
+	t.Cleanup(func() { client.Close() })
+	m, err := metrics.NewMetrics(db, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestLegacyAliasSharesRateLimitAndIsDeprecated(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := testConfig()
+	cfg.RateLimit = config.RateLimitConfig{
+		Enabled: true,
+		Policies: []config.RateLimitPolicy{
+			{Name: "signin", Method: http.MethodPost, Route: "/auth/signin", Limit: 2, Window: time.Minute,
+				KeyBy: middleware.RateLimitKeyIP, Algorithm: ratelimit.AlgorithmSlidingWindow},
+		},
+	}
+	m := newTestMetrics(t)
+	tracer, err := tracing.NewProvider(cfg.Tracing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bundle, err := i18n.NewBundle()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rateLimiter, err := middleware.NewRateLimiter(ratelimit.NewMemoryLimiter(), cfg.RateLimit, m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idempotency := middleware.NewIdempotency(nil, config.IdempotencyConfig{})
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	// Requests are rejected for their empty body before reaching a service
+	router, err := NewRouter(&Handlers{}, cfg, logger, m, tracer, bundle, rateLimiter, idempotency)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path          string
+		wantStatus    int
+		wantRemaining string
+		deprecated    bool
+	}{
+		{path: "/auth/signin", wantStatus: http.StatusBadRequest, wantRemaining: "1", deprecated: true},
+		{path: "/v1/auth/signin", wantStatus: http.StatusBadRequest, wantRemaining: "0"},
+		{path: "/auth/signin", wantStatus: http.StatusTooManyRequests, wantRemaining: "0", deprecated: true},
+		{path: "/v1/auth/signin", wantStatus: http.StatusTooManyRequests, wantRemaining: "0"},
+	}
+	for i, tt := range tests {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader("")))
+
+		if w.Code != tt.wantStatus {
+			t.Fatalf("request %d to %s: status = %d, want %d", i, tt.path, w.Code, tt.wantStatus)
+		}
+		if got := w.Header().Get(middleware.RateLimitRemainingHeader); got != tt.wantRemaining {
+			t.Errorf("request %d to %s: %s = %q, want %q", i, tt.path, middleware.RateLimitRemainingHeader, got, tt.wantRemaining)
+		}
+		for _, header := range []string{middleware.DeprecationHeader, middleware.SunsetHeader, "Link"} {
+			if got := w.Header().Get(header) != ""; got != tt.deprecated {
+				t.Errorf("request %d to %s: %s set = %v, want %v", i, tt.path, header, got, tt.deprecated)
+			}
+		}
+		if want := `</v1/auth/signin>; rel="successor-version"`; tt.deprecated && w.Header().Get("Link") != want {
+			t.Errorf("request %d to %s: Link = %q, want %q", i, tt.path, w.Header().Get("Link"), want)
+		}
+	}
+}
@@ -1,122 +1,506 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"os"
-	"strconv"
 	"strings"
+	"time"
+)
+
+// ConfigFileEnv names the optional YAML or TOML configuration file
+const ConfigFileEnv = "CONFIG_FILE"
+
+// DefaultJWTSecret is the placeholder secret used when none is configured.
+// It is public, so the server refuses to start with it in production.
+const DefaultJWTSecret = "your-secret-key-change-in-production"
+
+// Environments accepted in Config.Env
+const (
+	EnvDevelopment = "development"
+	EnvTest        = "test"
+	EnvStaging     = "staging"
+	EnvProduction  = "production"
 )
 
 type Config struct {
-	Database DatabaseConfig
-	Redis    RedisConfig
-	JWT      JWTConfig
-	OAuth    OAuthConfig
-	Server   ServerConfig
+	// Env is the deployment environment, one of the Env* constants.
+	Env         string            `yaml:"env" toml:"env"`
+	Database    DatabaseConfig    `yaml:"database" toml:"database"`
+	Redis       RedisConfig       `yaml:"redis" toml:"redis"`
+	JWT         JWTConfig         `yaml:"jwt" toml:"jwt"`
+	OAuth       OAuthConfig       `yaml:"oauth" toml:"oauth"`
+	Server      ServerConfig      `yaml:"server" toml:"server"`
+	API         APIConfig         `yaml:"api" toml:"api"`
+	Session     SessionConfig     `yaml:"session" toml:"session"`
+	Account     AccountConfig     `yaml:"account" toml:"account"`
+	Health      HealthConfig      `yaml:"health" toml:"health"`
+	Log         LogConfig         `yaml:"log" toml:"log"`
+	Metrics     MetricsConfig     `yaml:"metrics" toml:"metrics"`
+	Tracing     TracingConfig     `yaml:"tracing" toml:"tracing"`
+	RateLimit   RateLimitConfig   `yaml:"rate_limit" toml:"rate_limit"`
+	Idempotency IdempotencyConfig `yaml:"idempotency" toml:"idempotency"`
+}
+
+// normalizeEnv returns env in the form of the Env* constants.
+func normalizeEnv(env string) string {
+	return strings.ToLower(strings.TrimSpace(env))
 }
 
+// IsProduction reports whether the server runs in the production environment.
+func (c *Config) IsProduction() bool {
+	return c.Env == EnvProduction
+}
+
+// Fields tagged secret:"true" are masked by Redacted.
+
 type DatabaseConfig struct {
-	Host     string
-	Port     string
-	User     string
-	Password string
-	DBName   string
-	SSLMode  string
+	Host     string `yaml:"host" toml:"host"`
+	Port     string `yaml:"port" toml:"port"`
+	User     string `yaml:"user" toml:"user"`
+	Password string `yaml:"password" toml:"password" secret:"true"`
+	DBName   string `yaml:"name" toml:"name"`
+	SSLMode  string `yaml:"ssl_mode" toml:"ssl_mode"`
 }
 
 type JWTConfig struct {
-	SecretKey       string
-	ExpirationHours int
+	SecretKey       string `yaml:"secret" toml:"secret" secret:"true"`
+	ExpirationHours int    `yaml:"expiration_hours" toml:"expiration_hours"`
 }
 
 type OAuthConfig struct {
-	Google      GoogleOAuthConfig
-	GitHub      GitHubOAuthConfig
-	RedirectURL string
+	Google      GoogleOAuthConfig `yaml:"google" toml:"google"`
+	GitHub      GitHubOAuthConfig `yaml:"github" toml:"github"`
+	RedirectURL string            `yaml:"redirect_url" toml:"redirect_url"`
 }
 
 type GoogleOAuthConfig struct {
-	ClientID     string
-	ClientSecret string
+	ClientID     string `yaml:"client_id" toml:"client_id"`
+	ClientSecret string `yaml:"client_secret" toml:"client_secret" secret:"true"`
 }
 
 type GitHubOAuthConfig struct {
-	ClientID     string
-	ClientSecret string
+	ClientID     string `yaml:"client_id" toml:"client_id"`
+	ClientSecret string `yaml:"client_secret" toml:"client_secret" secret:"true"`
 }
 
 type RedisConfig struct {
-	Host     string
-	Port     string
-	Password string
-	DB       int
+	Host     string `yaml:"host" toml:"host"`
+	Port     string `yaml:"port" toml:"port"`
+	Password string `yaml:"password" toml:"password" secret:"true"`
+	DB       int    `yaml:"db" toml:"db"`
 }
 
 type ServerConfig struct {
-	Port string
-	CORS CORSConfig
+	Port string     `yaml:"port" toml:"port"`
+	CORS CORSConfig `yaml:"cors" toml:"cors"`
+	// Timeouts are in seconds. DrainDelaySeconds is how long the server keeps
+	// serving after reporting not-ready, giving load balancers time to stop
+	// routing to it; ShutdownTimeoutSeconds bounds the wait for in-flight
+	// requests and shutdown hooks afterwards.
+	ReadTimeoutSeconds       int `yaml:"read_timeout_seconds" toml:"read_timeout_seconds"`
+	ReadHeaderTimeoutSeconds int `yaml:"read_header_timeout_seconds" toml:"read_header_timeout_seconds"`
+	WriteTimeoutSeconds      int `yaml:"write_timeout_seconds" toml:"write_timeout_seconds"`
+	IdleTimeoutSeconds       int `yaml:"idle_timeout_seconds" toml:"idle_timeout_seconds"`
+	DrainDelaySeconds        int `yaml:"drain_delay_seconds" toml:"drain_delay_seconds"`
+	ShutdownTimeoutSeconds   int `yaml:"shutdown_timeout_seconds" toml:"shutdown_timeout_seconds"`
+	// RequestTimeoutSeconds bounds the context of every request; work still
+	// running when it expires is cancelled and answered with 503.
+	RequestTimeoutSeconds int `yaml:"request_timeout_seconds" toml:"request_timeout_seconds"`
+	// TrustedProxies lists the proxy addresses or CIDR ranges whose
+	// X-Forwarded-For header is believed when determining the client IP. With
+	// none, the connection's remote address is used.
+	TrustedProxies []string `yaml:"trusted_proxies" toml:"trusted_proxies"`
+	// DetailedErrors includes the underlying error in 500 responses and
+	// panics. It is on by default only in development and test.
+	DetailedErrors bool `yaml:"detailed_errors" toml:"detailed_errors"`
+}
+
+// APIConfig controls the unversioned aliases of the /v1 routes, which are
+// kept while deployed clients move over. Responses on them carry a
+// Deprecation header dated LegacyDeprecatedAt and, when it is set, a Sunset
+// header dated LegacySunset (both written as 2006-01-02). Turn LegacyRoutes
+// off once the sunset has passed.
+type APIConfig struct {
+	LegacyRoutes       bool   `yaml:"legacy_routes" toml:"legacy_routes"`
+	LegacyDeprecatedAt string `yaml:"legacy_deprecated_at" toml:"legacy_deprecated_at"`
+	LegacySunset       string `yaml:"legacy_sunset" toml:"legacy_sunset"`
+}
+
+// LegacySchedule returns the deprecation and sunset dates of the legacy
+// routes. sunset is zero when none is set.
+func (c APIConfig) LegacySchedule() (deprecatedAt, sunset time.Time, err error) {
+	deprecatedAt, err = time.Parse(time.DateOnly, c.LegacyDeprecatedAt)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("api.legacy_deprecated_at: %q is not a date (YYYY-MM-DD)", c.LegacyDeprecatedAt)
+	}
+	if c.LegacySunset == "" {
+		return deprecatedAt, time.Time{}, nil
+	}
+	sunset, err = time.Parse(time.DateOnly, c.LegacySunset)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("api.legacy_sunset: %q is not a date (YYYY-MM-DD)", c.LegacySunset)
+	}
+	return deprecatedAt, sunset, nil
+}
+
+// SessionConfig controls the optional cookie-based session mode, in which the
+// JWT is kept in an HttpOnly cookie and state-changing requests must echo a
+// double-submit CSRF token.
+type SessionConfig struct {
+	CookieMode     bool   `yaml:"cookie_mode" toml:"cookie_mode"`
+	CookieName     string `yaml:"cookie_name" toml:"cookie_name"`
+	CSRFCookieName string `yaml:"csrf_cookie_name" toml:"csrf_cookie_name"`
+	CSRFHeaderName string `yaml:"csrf_header" toml:"csrf_header"`
+	CookieDomain   string `yaml:"cookie_domain" toml:"cookie_domain"`
+	CookiePath     string `yaml:"cookie_path" toml:"cookie_path"`
+	CookieSecure   bool   `yaml:"cookie_secure" toml:"cookie_secure"`
+	CookieSameSite string `yaml:"cookie_samesite" toml:"cookie_samesite"`
+}
+
+// AccountConfig controls account deletion. Deleted accounts are kept for
+// DeletionGraceDays so the request can be cancelled, then purged by a
+// background job running every PurgeIntervalMinutes.
+type AccountConfig struct {
+	DeletionGraceDays    int `yaml:"deletion_grace_days" toml:"deletion_grace_days"`
+	PurgeIntervalMinutes int `yaml:"purge_interval_minutes" toml:"purge_interval_minutes"`
+}
+
+// HealthConfig controls the readiness probe. Each dependency check is bounded
+// by CheckTimeoutMs and results are reused for CacheTTLSeconds. The detailed
+// per-check report is only returned to callers presenting DetailsToken.
+type HealthConfig struct {
+	CheckTimeoutMs  int    `yaml:"check_timeout_ms" toml:"check_timeout_ms"`
+	CacheTTLSeconds int    `yaml:"cache_ttl_seconds" toml:"cache_ttl_seconds"`
+	DetailsToken    string `yaml:"details_token" toml:"details_token" secret:"true"`
+}
+
+// LogConfig controls structured logging. Level is one of debug, info, warn or
+// error and Format is json or text. SQL statements slower than
+// SlowQueryThresholdMs are logged at warn; bound parameters, and the error
+// messages of failed queries, are only included when SQLParams is set, as
+// they contain password hashes and personal data.
+type LogConfig struct {
+	Level                string `yaml:"level" toml:"level"`
+	Format               string `yaml:"format" toml:"format"`
+	SlowQueryThresholdMs int    `yaml:"slow_query_ms" toml:"slow_query_ms"`
+	SQLParams            bool   `yaml:"sql_params" toml:"sql_params"`
+}
+
+// MetricsConfig controls the Prometheus endpoint, served at Path. With Addr
+// (host:port) set it gets a listener of its own, which can be kept off the
+// public network; otherwise it is served on the main listener. Token, when
+// set, must be sent as "Authorization: Bearer <token>" to scrape either way.
+type MetricsConfig struct {
+	Enabled bool   `yaml:"enabled" toml:"enabled"`
+	Path    string `yaml:"path" toml:"path"`
+	Addr    string `yaml:"addr" toml:"addr"`
+	Token   string `yaml:"token" toml:"token" secret:"true"`
+}
+
+// OnMainListener reports whether metrics are served by the API router.
+func (c MetricsConfig) OnMainListener() bool {
+	return c.Enabled && c.Addr == ""
+}
+
+// TracingConfig controls OpenTelemetry tracing. Exporter is "none" (the
+// default, which installs a no-op tracer) or "otlp", which sends spans over
+// OTLP/HTTP to OTLPEndpoint (host:port). SampleRatio is the fraction of new
+// traces recorded; requests that arrive with a sampled parent are always kept.
+type TracingConfig struct {
+	Exporter     string  `yaml:"exporter" toml:"exporter"`
+	ServiceName  string  `yaml:"service_name" toml:"service_name"`
+	OTLPEndpoint string  `yaml:"otlp_endpoint" toml:"otlp_endpoint"`
+	OTLPInsecure bool    `yaml:"otlp_insecure" toml:"otlp_insecure"`
+	SampleRatio  float64 `yaml:"sample_ratio" toml:"sample_ratio"`
+}
+
+// RateLimitConfig controls request rate limiting. Counters are kept in Redis,
+// with each call bounded by RedisTimeoutMs; while Redis is failing every
+// instance counts in memory instead.
+type RateLimitConfig struct {
+	Enabled        bool              `yaml:"enabled" toml:"enabled"`
+	RedisTimeoutMs int               `yaml:"redis_timeout_ms" toml:"redis_timeout_ms"`
+	Policies       []RateLimitPolicy `yaml:"policies" toml:"policies"`
+}
+
+// RateLimitPolicy limits one route, identified by its method and gin route
+// pattern (e.g. "GET /auth/callback/:provider"). KeyBy selects what is
+// counted: "ip", "user" (the authenticated user, or the IP on public routes)
+// or "api_key" (the presented credential, or the IP when there is none).
+// Algorithm is "sliding_window" or "token_bucket"; Burst is the bucket size
+// and defaults to Limit.
+//
+// In files and the environment a policy is written as
+// "METHOD ROUTE LIMIT/WINDOW [name=...] [key=...] [algorithm=...] [burst=N]".
+type RateLimitPolicy struct {
+	Name      string
+	Method    string
+	Route     string
+	Limit     int
+	Window    time.Duration
+	KeyBy     string
+	Algorithm string
+	Burst     int
+}
+
+// IdempotencyConfig controls Idempotency-Key handling. Responses are kept for
+// TTLHours; a request still being processed holds its key for at most
+// LockTimeoutSeconds, which should exceed the request timeout.
+type IdempotencyConfig struct {
+	Enabled            bool `yaml:"enabled" toml:"enabled"`
+	TTLHours           int  `yaml:"ttl_hours" toml:"ttl_hours"`
+	LockTimeoutSeconds int  `yaml:"lock_timeout_seconds" toml:"lock_timeout_seconds"`
 }
 
 type CORSConfig struct {
-	AllowedOrigins   []string
-	AllowedMethods   []string
-	AllowedHeaders   []string
-	ExposedHeaders   []string
-	AllowCredentials bool
-	MaxAge           int
+	AllowedOrigins   []string `yaml:"allowed_origins" toml:"allowed_origins"`
+	AllowedMethods   []string `yaml:"allowed_methods" toml:"allowed_methods"`
+	AllowedHeaders   []string `yaml:"allowed_headers" toml:"allowed_headers"`
+	ExposedHeaders   []string `yaml:"exposed_headers" toml:"exposed_headers"`
+	AllowCredentials bool     `yaml:"allow_credentials" toml:"allow_credentials"`
+	MaxAge           int      `yaml:"max_age" toml:"max_age"`
 }
 
+// defaultRateLimitPolicies throttle the unauthenticated endpoints that
+// attackers can hammer: password guessing, account creation and OAuth code
+// replay
+const defaultRateLimitPolicies = "POST /auth/signin 10/1m name=signin; " +
+	"POST /auth/signup 5/1h name=signup; " +
+	"GET /auth/callback/:provider 20/1m name=oauth_callback algorithm=token_bucket burst=5"
+
+// Load builds the configuration from, in increasing order of precedence, the
+// built-in defaults, the defaults of the environment's profile (see
+// applyProfile), the YAML or TOML file named by CONFIG_FILE, and environment
+// variables. The environment is taken from APP_ENV, or else the file's env
+// key. Every variable can also be read from a file named by
+// the same variable with a _FILE suffix (e.g. JWT_SECRET_FILE), for Docker
+// and Kubernetes secrets. The result is validated, and all problems found are
+// reported together.
 func Load() (*Config, error) {
-	expirationHours, _ := strconv.Atoi(getEnv("JWT_EXPIRATION_HOURS", "24"))
+	var (
+		errs []error
+		file *configFile
+	)
+	if path := os.Getenv(ConfigFileEnv); path != "" {
+		var err error
+		if file, err = readConfigFile(path); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// The profile must be known before the file is applied over it
+	profile := EnvDevelopment
+	if file != nil {
+		var header struct {
+			Env string `yaml:"env" toml:"env"`
+		}
+		if err := file.decode(&header, false); err == nil && header.Env != "" {
+			profile = header.Env
+		}
+	}
+	env := &envSource{}
+	env.string("APP_ENV", &profile)
+
+	cfg := defaults()
+	applyProfile(cfg, normalizeEnv(profile))
+
+	if file != nil {
+		if err := file.decode(cfg, true); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	env.apply(cfg)
+	errs = append(errs, env.errs...)
+	// The file and APP_ENV set Env verbatim; every check compares it with
+	// the Env* constants, so "Production" must not slip past them
+	cfg.Env = normalizeEnv(cfg.Env)
+
+	if len(errs) == 0 {
+		if err := cfg.Validate(); err != nil {
+			return nil, err
+		}
+		return cfg, nil
+	}
+	return nil, errors.Join(errs...)
+}
+
+// defaults returns the settings shared by all environments; applyProfile
+// fills in the environment-specific ones.
+func defaults() *Config {
+	policies, err := parseRateLimitPolicies(defaultRateLimitPolicies)
+	if err != nil {
+		panic(fmt.Sprintf("invalid default rate limit policies: %v", err))
+	}
 
 	return &Config{
+		Env: EnvDevelopment,
 		Database: DatabaseConfig{
-			Host:     getEnv("DB_HOST", "localhost"),
-			Port:     getEnv("DB_PORT", "5432"),
-			User:     getEnv("DB_USER", "postgres"),
-			Password: getEnv("DB_PASSWORD", "postgres"),
-			DBName:   getEnv("DB_NAME", "studoto"),
-			SSLMode:  getEnv("DB_SSLMODE", "disable"),
+			Host:     "localhost",
+			Port:     "5432",
+			User:     "postgres",
+			Password: "postgres",
+			DBName:   "studoto",
+			SSLMode:  "disable",
 		},
 		Redis: RedisConfig{
-			Host:     getEnv("REDIS_HOST", "localhost"),
-			Port:     getEnv("REDIS_PORT", "6379"),
-			Password: getEnv("REDIS_PASSWORD", ""),
-			DB:       parseInt(getEnv("REDIS_DB", "0"), 0),
+			Host: "localhost",
+			Port: "6379",
 		},
 		JWT: JWTConfig{
-			SecretKey:       getEnv("JWT_SECRET", "your-secret-key-change-in-production"),
-			ExpirationHours: expirationHours,
+			SecretKey:       DefaultJWTSecret,
+			ExpirationHours: 24,
 		},
 		OAuth: OAuthConfig{
-			Google: GoogleOAuthConfig{
-				ClientID:     getEnv("GOOGLE_CLIENT_ID", ""),
-				ClientSecret: getEnv("GOOGLE_CLIENT_SECRET", ""),
-			},
-			GitHub: GitHubOAuthConfig{
-				ClientID:     getEnv("GITHUB_CLIENT_ID", ""),
-				ClientSecret: getEnv("GITHUB_CLIENT_SECRET", ""),
-			},
-			RedirectURL: getEnv("OAUTH_REDIRECT_URL", "http://localhost:8080/auth/callback"),
+			RedirectURL: "http://localhost:8080/v1/auth/callback",
 		},
 		Server: ServerConfig{
-			Port: getEnv("PORT", "8080"),
+			Port:                     "8080",
+			ReadTimeoutSeconds:       15,
+			ReadHeaderTimeoutSeconds: 5,
+			WriteTimeoutSeconds:      30,
+			IdleTimeoutSeconds:       120,
+			DrainDelaySeconds:        5,
+			ShutdownTimeoutSeconds:   30,
+			RequestTimeoutSeconds:    10,
 			CORS: CORSConfig{
-				AllowedOrigins:   parseStringSlice(getEnv("CORS_ALLOWED_ORIGINS", "*")),
-				AllowedMethods:   parseStringSlice(getEnv("CORS_ALLOWED_METHODS", "GET,POST,PUT,PATCH,DELETE,OPTIONS")),
-				AllowedHeaders:   parseStringSlice(getEnv("CORS_ALLOWED_HEADERS", "Origin,Content-Type,Accept,Authorization,X-Auth-Key,x-auth-token,X-Auth-Token")),
-				ExposedHeaders:   parseStringSlice(getEnv("CORS_EXPOSED_HEADERS", "Content-Length")),
-				AllowCredentials: getEnv("CORS_ALLOW_CREDENTIALS", "true") == "true",
-				MaxAge:           parseInt(getEnv("CORS_MAX_AGE", "86400"), 86400),
+				AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+				AllowedHeaders: []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Auth-Key", "x-auth-token", "X-Auth-Token", "X-CSRF-Token", "X-Request-ID", "Idempotency-Key"},
+				ExposedHeaders: []string{"Content-Length", "X-Request-ID", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy", "Retry-After", "Idempotent-Replayed", "Deprecation", "Sunset", "Link"},
+				MaxAge:         86400,
 			},
 		},
-	}, nil
+		API: APIConfig{
+			LegacyRoutes:       true,
+			LegacyDeprecatedAt: "2026-10-18",
+			LegacySunset:       "2027-04-30",
+		},
+		Session: SessionConfig{
+			CookieName:     "studoto_session",
+			CSRFCookieName: "studoto_csrf",
+			CSRFHeaderName: "X-CSRF-Token",
+			CookiePath:     "/",
+			CookieSameSite: "lax",
+		},
+		Account: AccountConfig{
+			DeletionGraceDays:    30,
+			PurgeIntervalMinutes: 60,
+		},
+		Health: HealthConfig{
+			CheckTimeoutMs:  2000,
+			CacheTTLSeconds: 5,
+		},
+		Log: LogConfig{
+			SlowQueryThresholdMs: 200,
+		},
+		Metrics: MetricsConfig{
+			Enabled: true,
+			Path:    "/metrics",
+		},
+		Tracing: TracingConfig{
+			Exporter:     "none",
+			ServiceName:  "studoto-backend",
+			OTLPEndpoint: "localhost:4318",
+			SampleRatio:  1,
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:        true,
+			RedisTimeoutMs: 200,
+			Policies:       policies,
+		},
+		Idempotency: IdempotencyConfig{
+			Enabled:            true,
+			TTLHours:           24,
+			LockTimeoutSeconds: 30,
+		},
+	}
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
-	}
-	return defaultValue
+// apply overrides cfg with the environment variables that are set.
+func (e *envSource) apply(cfg *Config) {
+	e.string("APP_ENV", &cfg.Env)
+
+	e.string("DB_HOST", &cfg.Database.Host)
+	e.string("DB_PORT", &cfg.Database.Port)
+	e.string("DB_USER", &cfg.Database.User)
+	e.string("DB_PASSWORD", &cfg.Database.Password)
+	e.string("DB_NAME", &cfg.Database.DBName)
+	e.string("DB_SSLMODE", &cfg.Database.SSLMode)
+
+	e.string("REDIS_HOST", &cfg.Redis.Host)
+	e.string("REDIS_PORT", &cfg.Redis.Port)
+	e.string("REDIS_PASSWORD", &cfg.Redis.Password)
+	e.int("REDIS_DB", &cfg.Redis.DB)
+
+	e.string("JWT_SECRET", &cfg.JWT.SecretKey)
+	e.int("JWT_EXPIRATION_HOURS", &cfg.JWT.ExpirationHours)
+
+	e.string("GOOGLE_CLIENT_ID", &cfg.OAuth.Google.ClientID)
+	e.string("GOOGLE_CLIENT_SECRET", &cfg.OAuth.Google.ClientSecret)
+	e.string("GITHUB_CLIENT_ID", &cfg.OAuth.GitHub.ClientID)
+	e.string("GITHUB_CLIENT_SECRET", &cfg.OAuth.GitHub.ClientSecret)
+	e.string("OAUTH_REDIRECT_URL", &cfg.OAuth.RedirectURL)
+
+	e.string("PORT", &cfg.Server.Port)
+	e.int("SERVER_READ_TIMEOUT_SECONDS", &cfg.Server.ReadTimeoutSeconds)
+	e.int("SERVER_READ_HEADER_TIMEOUT_SECONDS", &cfg.Server.ReadHeaderTimeoutSeconds)
+	e.int("SERVER_WRITE_TIMEOUT_SECONDS", &cfg.Server.WriteTimeoutSeconds)
+	e.int("SERVER_IDLE_TIMEOUT_SECONDS", &cfg.Server.IdleTimeoutSeconds)
+	e.int("SERVER_DRAIN_DELAY_SECONDS", &cfg.Server.DrainDelaySeconds)
+	e.int("SERVER_SHUTDOWN_TIMEOUT_SECONDS", &cfg.Server.ShutdownTimeoutSeconds)
+	e.int("SERVER_REQUEST_TIMEOUT_SECONDS", &cfg.Server.RequestTimeoutSeconds)
+	e.strings("SERVER_TRUSTED_PROXIES", &cfg.Server.TrustedProxies)
+	e.bool("SERVER_DETAILED_ERRORS", &cfg.Server.DetailedErrors)
+	e.strings("CORS_ALLOWED_ORIGINS", &cfg.Server.CORS.AllowedOrigins)
+	e.strings("CORS_ALLOWED_METHODS", &cfg.Server.CORS.AllowedMethods)
+	e.strings("CORS_ALLOWED_HEADERS", &cfg.Server.CORS.AllowedHeaders)
+	e.strings("CORS_EXPOSED_HEADERS", &cfg.Server.CORS.ExposedHeaders)
+	e.bool("CORS_ALLOW_CREDENTIALS", &cfg.Server.CORS.AllowCredentials)
+	e.int("CORS_MAX_AGE", &cfg.Server.CORS.MaxAge)
+
+	e.bool("SESSION_COOKIE_MODE", &cfg.Session.CookieMode)
+	e.bool("API_LEGACY_ROUTES", &cfg.API.LegacyRoutes)
+	e.string("API_LEGACY_DEPRECATED_AT", &cfg.API.LegacyDeprecatedAt)
+	e.string("API_LEGACY_SUNSET", &cfg.API.LegacySunset)
+
+	e.string("SESSION_COOKIE_NAME", &cfg.Session.CookieName)
+	e.string("SESSION_CSRF_COOKIE_NAME", &cfg.Session.CSRFCookieName)
+	e.string("SESSION_CSRF_HEADER", &cfg.Session.CSRFHeaderName)
+	e.string("SESSION_COOKIE_DOMAIN", &cfg.Session.CookieDomain)
+	e.string("SESSION_COOKIE_PATH", &cfg.Session.CookiePath)
+	e.bool("SESSION_COOKIE_SECURE", &cfg.Session.CookieSecure)
+	e.string("SESSION_COOKIE_SAMESITE", &cfg.Session.CookieSameSite)
+
+	e.int("ACCOUNT_DELETION_GRACE_DAYS", &cfg.Account.DeletionGraceDays)
+	e.int("ACCOUNT_PURGE_INTERVAL_MINUTES", &cfg.Account.PurgeIntervalMinutes)
+
+	e.int("HEALTH_CHECK_TIMEOUT_MS", &cfg.Health.CheckTimeoutMs)
+	e.int("HEALTH_CACHE_TTL_SECONDS", &cfg.Health.CacheTTLSeconds)
+	e.string("HEALTH_DETAILS_TOKEN", &cfg.Health.DetailsToken)
+
+	e.string("LOG_LEVEL", &cfg.Log.Level)
+	e.string("LOG_FORMAT", &cfg.Log.Format)
+	e.int("LOG_SLOW_QUERY_MS", &cfg.Log.SlowQueryThresholdMs)
+	e.bool("LOG_SQL_PARAMS", &cfg.Log.SQLParams)
+
+	e.bool("METRICS_ENABLED", &cfg.Metrics.Enabled)
+	e.string("METRICS_PATH", &cfg.Metrics.Path)
+	e.string("METRICS_ADDR", &cfg.Metrics.Addr)
+	e.string("METRICS_TOKEN", &cfg.Metrics.Token)
+
+	e.string("TRACING_EXPORTER", &cfg.Tracing.Exporter)
+	e.string("TRACING_SERVICE_NAME", &cfg.Tracing.ServiceName)
+	e.string("TRACING_OTLP_ENDPOINT", &cfg.Tracing.OTLPEndpoint)
+	e.bool("TRACING_OTLP_INSECURE", &cfg.Tracing.OTLPInsecure)
+	e.float("TRACING_SAMPLE_RATIO", &cfg.Tracing.SampleRatio)
+
+	e.bool("RATE_LIMIT_ENABLED", &cfg.RateLimit.Enabled)
+	e.int("RATE_LIMIT_REDIS_TIMEOUT_MS", &cfg.RateLimit.RedisTimeoutMs)
+	e.policies("RATE_LIMIT_POLICIES", &cfg.RateLimit.Policies)
+
+	e.bool("IDEMPOTENCY_ENABLED", &cfg.Idempotency.Enabled)
+	e.int("IDEMPOTENCY_TTL_HOURS", &cfg.Idempotency.TTLHours)
+	e.int("IDEMPOTENCY_LOCK_TIMEOUT_SECONDS", &cfg.Idempotency.LockTimeoutSeconds)
 }
 
 func parseStringSlice(value string) []string {
@@ -133,13 +517,3 @@ func parseStringSlice(value string) []string {
 	}
 	return result
 }
-
-func parseInt(value string, defaultValue int) int {
-	if value == "" {
-		return defaultValue
-	}
-	if parsed, err := strconv.Atoi(value); err == nil {
-		return parsed
-	}
-	return defaultValue
-}
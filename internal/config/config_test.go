@@ -0,0 +1,236 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testSecret = "a-test-secret-that-is-long-enough-for-production"
+
+func TestLoadNormalizesEnv(t *testing.T) {
+	for _, env := range []string{"Production", " PRODUCTION\n", "production"} {
+		t.Run(env, func(t *testing.T) {
+			t.Setenv("APP_ENV", env)
+
+			// The production checks apply whatever the spelling
+			_, err := Load()
+			if err == nil || !strings.Contains(err.Error(), "jwt.secret: the default secret must not be used in production") {
+				t.Fatalf("Load() error = %v, want the default secret rejected", err)
+			}
+
+			t.Setenv("JWT_SECRET", testSecret)
+			cfg, err := Load()
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if cfg.Env != EnvProduction || !cfg.IsProduction() {
+				t.Errorf("Env = %q, IsProduction() = %v, want production", cfg.Env, cfg.IsProduction())
+			}
+		})
+	}
+}
+
+func TestProductionRefusesPublicMetrics(t *testing.T) {
+	t.Setenv("APP_ENV", EnvProduction)
+	t.Setenv("JWT_SECRET", testSecret)
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Metrics.OnMainListener() {
+		t.Fatalf("production serves metrics on the main listener by default (addr %q)", cfg.Metrics.Addr)
+	}
+
+	cfg.Metrics.Addr = ""
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "metrics:") {
+		t.Fatalf("Validate() error = %v, want public metrics refused", err)
+	}
+	cfg.Metrics.Token = "scrape-token"
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() with a metrics token error = %v", err)
+	}
+}
+
+// writeConfigFile writes content to a file named name in a fresh directory
+// and returns its path.
+func writeConfigFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	tests := []struct {
+		name       string
+		file       string
+		fileName   string
+		env        map[string]string
+		wantLevel  string
+		wantFormat string
+	}{
+		{name: "defaults and development profile", wantLevel: "debug", wantFormat: "text"},
+		{name: "file over profile", fileName: "config.yaml", file: "log:\n  level: warn\n",
+			wantLevel: "warn", wantFormat: "text"},
+		{name: "env over file", fileName: "config.yaml", file: "log:\n  level: warn\n",
+			env: map[string]string{"LOG_LEVEL": "error"}, wantLevel: "error", wantFormat: "text"},
+		{name: "profile from the file's env", fileName: "config.yaml", file: "env: staging\n",
+			wantLevel: "info", wantFormat: "json"},
+		{name: "file over the profile it selects", fileName: "config.toml", file: "env = \"staging\"\n[log]\nformat = \"text\"\n",
+			wantLevel: "info", wantFormat: "text"},
+		{name: "APP_ENV over the file's env", fileName: "config.yaml", file: "env: staging\n",
+			env: map[string]string{"APP_ENV": "development"}, wantLevel: "debug", wantFormat: "text"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.file != "" {
+				t.Setenv(ConfigFileEnv, writeConfigFile(t, tt.fileName, tt.file))
+			}
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			cfg, err := Load()
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if cfg.Log.Level != tt.wantLevel || cfg.Log.Format != tt.wantFormat {
+				t.Errorf("log = %s/%s, want %s/%s", cfg.Log.Level, cfg.Log.Format, tt.wantLevel, tt.wantFormat)
+			}
+		})
+	}
+}
+
+func TestLoadSecretFiles(t *testing.T) {
+	secretFile := writeConfigFile(t, "jwt_secret", testSecret+"\n")
+
+	tests := []struct {
+		name    string
+		env     map[string]string
+		wantErr string
+	}{
+		{name: "read from file", env: map[string]string{"JWT_SECRET_FILE": secretFile}},
+		{name: "both set", env: map[string]string{"JWT_SECRET_FILE": secretFile, "JWT_SECRET": "other"},
+			wantErr: "JWT_SECRET and JWT_SECRET_FILE are both set"},
+		{name: "missing file", env: map[string]string{"JWT_SECRET_FILE": filepath.Join(t.TempDir(), "missing")},
+			wantErr: "JWT_SECRET_FILE:"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			cfg, err := Load()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Load() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			// The trailing newline of the file is not part of the secret
+			if cfg.JWT.SecretKey != testSecret {
+				t.Errorf("JWT.SecretKey = %q, want %q", cfg.JWT.SecretKey, testSecret)
+			}
+		})
+	}
+}
+
+func TestLoadRejectsUnknownKeys(t *testing.T) {
+	tests := []struct {
+		fileName string
+		file     string
+	}{
+		{"config.yaml", "log:\n  levle: warn\n"},
+		{"config.toml", "[log]\nlevle = \"warn\"\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fileName, func(t *testing.T) {
+			t.Setenv(ConfigFileEnv, writeConfigFile(t, tt.fileName, tt.file))
+			if _, err := Load(); err == nil || !strings.Contains(err.Error(), "levle") {
+				t.Fatalf("Load() error = %v, want the unknown key reported", err)
+			}
+		})
+	}
+}
+
+func TestLoadReportsEveryProblem(t *testing.T) {
+	t.Run("environment", func(t *testing.T) {
+		t.Setenv("REDIS_DB", "zero")
+		t.Setenv("JWT_EXPIRATION_HOURS", "a day")
+
+		_, err := Load()
+		for _, want := range []string{`REDIS_DB: "zero" is not an integer`, `JWT_EXPIRATION_HOURS: "a day" is not an integer`} {
+			if err == nil || !strings.Contains(err.Error(), want) {
+				t.Errorf("Load() error = %v, want it to include %q", err, want)
+			}
+		}
+	})
+
+	t.Run("validation", func(t *testing.T) {
+		cfg := defaults()
+		applyProfile(cfg, EnvDevelopment)
+		cfg.Database.Port = "postgres"
+		cfg.Redis.Host = ""
+		cfg.Log.Level = "loud"
+
+		err := cfg.Validate()
+		for _, want := range []string{
+			`database.port: "postgres" is not a valid port`,
+			"redis.host: required",
+			`log.level: "loud" is not one of`,
+		} {
+			if err == nil || !strings.Contains(err.Error(), want) {
+				t.Errorf("Validate() error = %v, want it to include %q", err, want)
+			}
+		}
+	})
+}
+
+func TestRedacted(t *testing.T) {
+	cfg := defaults()
+	cfg.JWT.SecretKey = testSecret
+	cfg.Database.Password = "db-password"
+	cfg.OAuth.GitHub.ClientSecret = "github-secret"
+	cfg.Metrics.Token = ""
+
+	redacted := cfg.Redacted()
+	for name, got := range map[string]string{
+		"jwt.secret":                 redacted.JWT.SecretKey,
+		"database.password":          redacted.Database.Password,
+		"oauth.github.client_secret": redacted.OAuth.GitHub.ClientSecret,
+	} {
+		if got != redactedValue {
+			t.Errorf("%s = %q, want it redacted", name, got)
+		}
+	}
+	if redacted.Metrics.Token != "" {
+		t.Errorf("metrics.token = %q, want an unset secret left empty", redacted.Metrics.Token)
+	}
+	if redacted.Database.User != cfg.Database.User {
+		t.Errorf("database.user = %q, want %q", redacted.Database.User, cfg.Database.User)
+	}
+	if cfg.JWT.SecretKey != testSecret {
+		t.Error("Redacted() modified the original configuration")
+	}
+
+	var dump strings.Builder
+	if err := cfg.Dump(&dump); err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{testSecret, "db-password", "github-secret"} {
+		if strings.Contains(dump.String(), secret) {
+			t.Errorf("Dump() output contains %q", secret)
+		}
+	}
+}
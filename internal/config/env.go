@@ -0,0 +1,107 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// secretFileSuffix marks a variable holding the path of a file with the
+// actual value, as mounted by Docker and Kubernetes secrets
+const secretFileSuffix = "_FILE"
+
+// envSource reads environment variables into the configuration, collecting
+// parse errors instead of stopping at the first one.
+type envSource struct {
+	errs []error
+}
+
+// lookup returns the value of key, or the contents of the file named by
+// key_FILE. Empty variables count as unset, so that "KEY=" in an env file
+// keeps the default.
+func (e *envSource) lookup(key string) (string, bool) {
+	value := os.Getenv(key)
+	path := os.Getenv(key + secretFileSuffix)
+
+	switch {
+	case value != "" && path != "":
+		e.errs = append(e.errs, fmt.Errorf("%s and %s%s are both set", key, key, secretFileSuffix))
+		return "", false
+	case path != "":
+		data, err := os.ReadFile(path)
+		if err != nil {
+			e.errs = append(e.errs, fmt.Errorf("%s%s: %w", key, secretFileSuffix, err))
+			return "", false
+		}
+		// Secret files usually end with a newline that is not part of the value
+		return strings.TrimRight(string(data), "\r\n"), true
+	case value != "":
+		return value, true
+	}
+	return "", false
+}
+
+func (e *envSource) string(key string, dst *string) {
+	if value, ok := e.lookup(key); ok {
+		*dst = value
+	}
+}
+
+func (e *envSource) strings(key string, dst *[]string) {
+	if value, ok := e.lookup(key); ok {
+		*dst = parseStringSlice(value)
+	}
+}
+
+func (e *envSource) int(key string, dst *int) {
+	value, ok := e.lookup(key)
+	if !ok {
+		return
+	}
+	parsed, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s: %q is not an integer", key, value))
+		return
+	}
+	*dst = parsed
+}
+
+func (e *envSource) float(key string, dst *float64) {
+	value, ok := e.lookup(key)
+	if !ok {
+		return
+	}
+	parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s: %q is not a number", key, value))
+		return
+	}
+	*dst = parsed
+}
+
+func (e *envSource) bool(key string, dst *bool) {
+	value, ok := e.lookup(key)
+	if !ok {
+		return
+	}
+	parsed, err := strconv.ParseBool(strings.TrimSpace(value))
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s: %q is not a boolean", key, value))
+		return
+	}
+	*dst = parsed
+}
+
+func (e *envSource) policies(key string, dst *[]RateLimitPolicy) {
+	value, ok := e.lookup(key)
+	if !ok {
+		return
+	}
+	policies, err := parseRateLimitPolicies(value)
+	if err != nil {
+		e.errs = append(e.errs, fmt.Errorf("%s: %w", key, err))
+		return
+	}
+	*dst = policies
+}
@@ -0,0 +1,65 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// configFile is a YAML or TOML configuration file, with the format chosen by
+// extension.
+type configFile struct {
+	path   string
+	format string
+	data   []byte
+}
+
+func readConfigFile(path string) (*configFile, error) {
+	format := strings.ToLower(filepath.Ext(path))
+	switch format {
+	case ".yaml", ".yml", ".toml":
+	default:
+		return nil, fmt.Errorf("unsupported config file extension %q: use .yaml, .yml or .toml", format)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	return &configFile{path: path, format: format, data: data}, nil
+}
+
+// decode decodes the file over dst, so that settings missing from the file
+// keep their current values. In strict mode unknown keys are rejected to catch
+// typos.
+func (f *configFile) decode(dst any, strict bool) error {
+	if f.format == ".toml" {
+		dec := toml.NewDecoder(bytes.NewReader(f.data))
+		if strict {
+			dec.DisallowUnknownFields()
+		}
+		if err := dec.Decode(dst); err != nil {
+			var strictErr *toml.StrictMissingError
+			if errors.As(err, &strictErr) {
+				return fmt.Errorf("invalid config file %s: unknown keys:\n%s", f.path, strictErr.String())
+			}
+			return fmt.Errorf("invalid config file %s: %w", f.path, err)
+		}
+		return nil
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(f.data))
+	dec.KnownFields(strict)
+	// An empty file decodes to io.EOF, which is not an error here
+	if err := dec.Decode(dst); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("invalid config file %s: %w", f.path, err)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package config
+
+import "strings"
+
+// Gin modes returned by GinMode, matching gin.DebugMode, gin.TestMode and
+// gin.ReleaseMode
+const (
+	ginDebugMode   = "debug"
+	ginTestMode    = "test"
+	ginReleaseMode = "release"
+)
+
+// applyProfile sets the defaults of an environment. They sit between the
+// built-in defaults and the config file, so either can still override them:
+// development favours convenience (debug logs, readable text output, error
+// details in responses, cookies usable over plain HTTP, metrics on the main
+// port), while staging and production default to the locked-down settings,
+// serve metrics on a port of their own and expect CORS origins to be
+// configured explicitly.
+func applyProfile(cfg *Config, env string) {
+	cfg.Env = env
+
+	switch env {
+	case EnvDevelopment, EnvTest:
+		cfg.Server.CORS.AllowedOrigins = []string{"*"}
+		cfg.Server.CORS.AllowCredentials = false
+		cfg.Server.DetailedErrors = true
+		cfg.Session.CookieSecure = false
+		cfg.Log.Format = "text"
+		cfg.Log.Level = "debug"
+		if env == EnvTest {
+			cfg.Log.Level = "warn"
+		}
+	default:
+		cfg.Server.CORS.AllowedOrigins = []string{}
+		cfg.Server.CORS.AllowCredentials = true
+		cfg.Server.DetailedErrors = false
+		cfg.Session.CookieSecure = true
+		cfg.Log.Format = "json"
+		cfg.Log.Level = "info"
+		cfg.Metrics.Addr = ":9090"
+	}
+}
+
+// GinMode returns the gin mode for the environment.
+func (c *Config) GinMode() string {
+	switch c.Env {
+	case EnvDevelopment:
+		return ginDebugMode
+	case EnvTest:
+		return ginTestMode
+	default:
+		return ginReleaseMode
+	}
+}
+
+// validateProfile rejects combinations that are unsafe in any environment,
+// and settings that are only acceptable outside production.
+func (c *Config) validateProfile(v *validator) {
+	for _, origin := range c.Server.CORS.AllowedOrigins {
+		v.check(origin == "*" || strings.HasPrefix(origin, "http://") || strings.HasPrefix(origin, "https://"),
+			"server.cors.allowed_origins: %q must be * or start with http:// or https://", origin)
+		// Browsers refuse credentialed responses to a wildcard origin, and
+		// reflecting arbitrary origins instead would let any site act as the user
+		v.check(origin != "*" || !c.Server.CORS.AllowCredentials,
+			"server.cors: allow_credentials cannot be combined with the * origin")
+	}
+
+	if !c.IsProduction() {
+		return
+	}
+	for _, origin := range c.Server.CORS.AllowedOrigins {
+		v.check(origin != "*", "server.cors.allowed_origins: * is not allowed in production")
+		v.check(!strings.HasPrefix(origin, "http://"), "server.cors.allowed_origins: %q must use https in production", origin)
+	}
+	v.check(!c.Server.DetailedErrors, "server.detailed_errors: must be false in production")
+	v.check(!c.Session.CookieMode || c.Session.CookieSecure, "session.cookie_secure: must be true in production")
+	v.check(!c.Log.SQLParams, "log.sql_params: must be false in production")
+	v.check(!c.Metrics.OnMainListener() || c.Metrics.Token != "",
+		"metrics: must be served on metrics.addr or require metrics.token in production")
+	if c.OAuth.Google.ClientID != "" || c.OAuth.GitHub.ClientID != "" {
+		v.check(strings.HasPrefix(c.OAuth.RedirectURL, "https://"), "oauth.redirect_url: must use https in production")
+	}
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseRateLimitPolicies parses semicolon-separated policies, as used by
+// RATE_LIMIT_POLICIES.
+func parseRateLimitPolicies(value string) ([]RateLimitPolicy, error) {
+	policies := []RateLimitPolicy{}
+	for _, spec := range strings.Split(value, ";") {
+		if strings.TrimSpace(spec) == "" {
+			continue
+		}
+		var policy RateLimitPolicy
+		if err := policy.UnmarshalText([]byte(spec)); err != nil {
+			return nil, err
+		}
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
+
+// UnmarshalText parses "METHOD ROUTE LIMIT/WINDOW [name=...] [key=...]
+// [algorithm=...] [burst=N]", for example "POST /auth/signin 10/1m key=ip".
+func (p *RateLimitPolicy) UnmarshalText(text []byte) error {
+	spec := strings.TrimSpace(string(text))
+	fields := strings.Fields(spec)
+	if len(fields) < 3 {
+		return fmt.Errorf("invalid rate limit policy %q: expected METHOD ROUTE LIMIT/WINDOW", spec)
+	}
+
+	policy := RateLimitPolicy{
+		Method:    strings.ToUpper(fields[0]),
+		Route:     fields[1],
+		KeyBy:     "ip",
+		Algorithm: "sliding_window",
+	}
+	policy.Name = policy.Method + " " + policy.Route
+
+	limit, window, found := strings.Cut(fields[2], "/")
+	if !found {
+		return fmt.Errorf("invalid rate limit policy %q: expected LIMIT/WINDOW, got %q", policy.Name, fields[2])
+	}
+	var err error
+	if policy.Limit, err = strconv.Atoi(limit); err != nil {
+		return fmt.Errorf("invalid rate limit policy %q: bad limit %q", policy.Name, limit)
+	}
+	if policy.Window, err = time.ParseDuration(window); err != nil {
+		return fmt.Errorf("invalid rate limit policy %q: bad window %q", policy.Name, window)
+	}
+
+	for _, option := range fields[3:] {
+		name, value, _ := strings.Cut(option, "=")
+		switch name {
+		case "name":
+			policy.Name = value
+		case "key":
+			policy.KeyBy = value
+		case "algorithm":
+			policy.Algorithm = value
+		case "burst":
+			if policy.Burst, err = strconv.Atoi(value); err != nil {
+				return fmt.Errorf("invalid rate limit policy %q: bad burst %q", policy.Name, value)
+			}
+		default:
+			return fmt.Errorf("invalid rate limit policy %q: unknown option %q", policy.Name, option)
+		}
+	}
+
+	*p = policy
+	return nil
+}
+
+// MarshalText writes the policy in the form accepted by UnmarshalText.
+func (p RateLimitPolicy) MarshalText() ([]byte, error) {
+	spec := fmt.Sprintf("%s %s %d/%s key=%s algorithm=%s", p.Method, p.Route, p.Limit, p.Window, p.KeyBy, p.Algorithm)
+	// The default name contains a space and cannot be written as an option
+	if p.Name != p.Method+" "+p.Route {
+		spec += " name=" + p.Name
+	}
+	if p.Burst > 0 {
+		spec += " burst=" + strconv.Itoa(p.Burst)
+	}
+	return []byte(spec), nil
+}
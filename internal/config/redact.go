@@ -0,0 +1,46 @@
+package config
+
+import (
+	"io"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+// redactedValue replaces secrets in Redacted
+const redactedValue = "[REDACTED]"
+
+// Redacted returns a copy of the configuration with every field tagged
+// secret:"true" masked. Empty secrets stay empty, which shows that they are
+// unset.
+func (c *Config) Redacted() *Config {
+	redacted := *c
+	redact(reflect.ValueOf(&redacted).Elem())
+	return &redacted
+}
+
+func redact(v reflect.Value) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := v.Field(i)
+		switch {
+		case field.Kind() == reflect.Struct:
+			redact(field)
+		case field.Kind() == reflect.String && t.Field(i).Tag.Get("secret") == "true":
+			if field.String() != "" {
+				field.SetString(redactedValue)
+			}
+		}
+	}
+}
+
+// Dump writes the redacted configuration as YAML, in the format accepted by
+// CONFIG_FILE.
+func (c *Config) Dump(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(c.Redacted()); err != nil {
+		return err
+	}
+	return enc.Close()
+}
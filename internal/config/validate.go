@@ -0,0 +1,152 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// minProductionSecretLength is the shortest JWT secret accepted in production;
+// HS256 keys shorter than the 256-bit hash output weaken the signature
+const minProductionSecretLength = 32
+
+// validator collects every problem with a configuration so that they can be
+// fixed in one go rather than one restart at a time.
+type validator struct {
+	errs []error
+}
+
+func (v *validator) check(ok bool, format string, args ...any) {
+	if !ok {
+		v.errs = append(v.errs, fmt.Errorf(format, args...))
+	}
+}
+
+func (v *validator) oneOf(field, value string, allowed ...string) {
+	v.check(slices.Contains(allowed, strings.ToLower(value)),
+		"%s: %q is not one of %s", field, value, strings.Join(allowed, ", "))
+}
+
+func (v *validator) port(field, value string) {
+	port, err := strconv.Atoi(value)
+	v.check(err == nil && port > 0 && port < 65536, "%s: %q is not a valid port", field, value)
+}
+
+func (v *validator) nonNegative(field string, value int) {
+	v.check(value >= 0, "%s: must not be negative", field)
+}
+
+func (v *validator) positive(field string, value int) {
+	v.check(value > 0, "%s: must be positive", field)
+}
+
+// Validate reports every invalid or unsafe setting, joined into one error.
+func (c *Config) Validate() error {
+	v := &validator{}
+
+	v.oneOf("env", c.Env, EnvDevelopment, EnvTest, EnvStaging, EnvProduction)
+	c.validateProfile(v)
+
+	v.check(c.Database.Host != "", "database.host: required")
+	v.port("database.port", c.Database.Port)
+	v.check(c.Database.DBName != "", "database.name: required")
+	v.oneOf("database.ssl_mode", c.Database.SSLMode, "disable", "allow", "prefer", "require", "verify-ca", "verify-full")
+
+	v.check(c.Redis.Host != "", "redis.host: required")
+	v.port("redis.port", c.Redis.Port)
+	v.nonNegative("redis.db", c.Redis.DB)
+
+	v.check(c.JWT.SecretKey != "", "jwt.secret: required")
+	v.positive("jwt.expiration_hours", c.JWT.ExpirationHours)
+	if c.IsProduction() {
+		v.check(c.JWT.SecretKey != DefaultJWTSecret, "jwt.secret: the default secret must not be used in production")
+		v.check(len(c.JWT.SecretKey) >= minProductionSecretLength,
+			"jwt.secret: must be at least %d characters in production", minProductionSecretLength)
+	}
+
+	v.check((c.OAuth.Google.ClientID == "") == (c.OAuth.Google.ClientSecret == ""),
+		"oauth.google: client_id and client_secret must be set together")
+	v.check((c.OAuth.GitHub.ClientID == "") == (c.OAuth.GitHub.ClientSecret == ""),
+		"oauth.github: client_id and client_secret must be set together")
+	redirectURL, err := url.Parse(c.OAuth.RedirectURL)
+	v.check(err == nil && redirectURL.IsAbs(), "oauth.redirect_url: %q is not an absolute URL", c.OAuth.RedirectURL)
+
+	v.port("server.port", c.Server.Port)
+	v.nonNegative("server.read_timeout_seconds", c.Server.ReadTimeoutSeconds)
+	v.nonNegative("server.read_header_timeout_seconds", c.Server.ReadHeaderTimeoutSeconds)
+	v.nonNegative("server.write_timeout_seconds", c.Server.WriteTimeoutSeconds)
+	v.nonNegative("server.idle_timeout_seconds", c.Server.IdleTimeoutSeconds)
+	v.nonNegative("server.drain_delay_seconds", c.Server.DrainDelaySeconds)
+	v.nonNegative("server.shutdown_timeout_seconds", c.Server.ShutdownTimeoutSeconds)
+	v.nonNegative("server.request_timeout_seconds", c.Server.RequestTimeoutSeconds)
+	for _, proxy := range c.Server.TrustedProxies {
+		_, _, cidrErr := net.ParseCIDR(proxy)
+		v.check(cidrErr == nil || net.ParseIP(proxy) != nil,
+			"server.trusted_proxies: %q is not an IP address or CIDR range", proxy)
+	}
+	v.nonNegative("server.cors.max_age", c.Server.CORS.MaxAge)
+
+	deprecatedAt, sunset, err := c.API.LegacySchedule()
+	v.check(err == nil, "%v", err)
+	v.check(err != nil || sunset.IsZero() || sunset.After(deprecatedAt),
+		"api.legacy_sunset: must be after api.legacy_deprecated_at")
+
+	v.check(c.Session.CookieName != "", "session.cookie_name: required")
+	v.check(c.Session.CSRFCookieName != "", "session.csrf_cookie_name: required")
+	v.check(c.Session.CSRFHeaderName != "", "session.csrf_header: required")
+	v.oneOf("session.cookie_samesite", c.Session.CookieSameSite, "lax", "strict", "none")
+	// Browsers drop SameSite=None cookies that are not Secure
+	v.check(!strings.EqualFold(c.Session.CookieSameSite, "none") || c.Session.CookieSecure,
+		"session.cookie_secure: must be true when cookie_samesite is none")
+
+	v.nonNegative("account.deletion_grace_days", c.Account.DeletionGraceDays)
+	v.positive("account.purge_interval_minutes", c.Account.PurgeIntervalMinutes)
+
+	v.positive("health.check_timeout_ms", c.Health.CheckTimeoutMs)
+	v.nonNegative("health.cache_ttl_seconds", c.Health.CacheTTLSeconds)
+
+	v.oneOf("log.level", c.Log.Level, "debug", "info", "warn", "warning", "error")
+	v.oneOf("log.format", c.Log.Format, "json", "text")
+	v.nonNegative("log.slow_query_ms", c.Log.SlowQueryThresholdMs)
+
+	v.check(strings.HasPrefix(c.Metrics.Path, "/"), "metrics.path: %q must start with /", c.Metrics.Path)
+	if c.Metrics.Addr != "" {
+		_, port, err := net.SplitHostPort(c.Metrics.Addr)
+		v.check(err == nil, "metrics.addr: %q is not host:port", c.Metrics.Addr)
+		if err == nil {
+			v.port("metrics.addr", port)
+			v.check(port != c.Server.Port, "metrics.addr: must not use the server port %s", c.Server.Port)
+		}
+	}
+
+	v.oneOf("tracing.exporter", c.Tracing.Exporter, "none", "otlp")
+	v.check(c.Tracing.ServiceName != "", "tracing.service_name: required")
+	v.check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio: must be between 0 and 1")
+
+	v.positive("rate_limit.redis_timeout_ms", c.RateLimit.RedisTimeoutMs)
+	for _, p := range c.RateLimit.Policies {
+		field := fmt.Sprintf("rate_limit.policies[%s]", p.Name)
+		v.positive(field+".limit", p.Limit)
+		v.check(p.Window > 0, "%s.window: must be positive", field)
+		v.nonNegative(field+".burst", p.Burst)
+		v.oneOf(field+".key", p.KeyBy, "ip", "user", "api_key")
+		v.oneOf(field+".algorithm", p.Algorithm, "sliding_window", "token_bucket")
+	}
+
+	v.positive("idempotency.ttl_hours", c.Idempotency.TTLHours)
+	v.positive("idempotency.lock_timeout_seconds", c.Idempotency.LockTimeoutSeconds)
+	// A lock that expires while the request is still running lets a retry
+	// execute it a second time
+	v.check(!c.Idempotency.Enabled || c.Server.RequestTimeoutSeconds == 0 ||
+		c.Idempotency.LockTimeoutSeconds > c.Server.RequestTimeoutSeconds,
+		"idempotency.lock_timeout_seconds: must exceed server.request_timeout_seconds")
+
+	if len(v.errs) > 0 {
+		return fmt.Errorf("invalid configuration:\n%w", errors.Join(v.errs...))
+	}
+	return nil
+}
@@ -1,23 +1,27 @@
 package database
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
-	"github.com/go-gormigrate/gormigrate/v2"
 	"github.com/jixlox0/studoto-backend/internal/config"
-	"github.com/jixlox0/studoto-backend/internal/database/migrations"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
+	gormlogger "gorm.io/gorm/logger"
 )
 
-func NewConnection(cfg config.DatabaseConfig) (*gorm.DB, error) {
-	dsn := fmt.Sprintf(
+// DSN returns the connection string for cfg.
+func DSN(cfg config.DatabaseConfig) string {
+	return fmt.Sprintf(
 		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
 		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.DBName, cfg.SSLMode,
 	)
+}
+
+func NewConnection(cfg config.DatabaseConfig, logger *slog.Logger, gormLogger gormlogger.Interface) (*gorm.DB, error) {
+	dsn := DSN(cfg)
 
 	var db *gorm.DB
 	var err error
@@ -27,14 +31,19 @@ func NewConnection(cfg config.DatabaseConfig) (*gorm.DB, error) {
 	// Retry connection with exponential backoff
 	for i := 0; i < maxRetries; i++ {
 		db, err = gorm.Open(postgres.Open(dsn), &gorm.Config{
-			Logger: logger.Default.LogMode(logger.Info),
+			Logger: gormLogger,
 		})
 		if err == nil {
 			break
 		}
 
 		if i < maxRetries-1 {
-			log.Printf("Database connection attempt %d/%d failed, retrying in %v...", i+1, maxRetries, retryDelay)
+			logger.Warn("Database connection attempt failed, retrying",
+				"attempt", i+1,
+				"max_attempts", maxRetries,
+				"retry_in", retryDelay.String(),
+				"error", err,
+			)
 			time.Sleep(retryDelay)
 			retryDelay *= 2 // Exponential backoff
 		}
@@ -60,29 +69,33 @@ func NewConnection(cfg config.DatabaseConfig) (*gorm.DB, error) {
 	sqlDB.SetMaxOpenConns(100)
 	sqlDB.SetConnMaxLifetime(time.Hour)
 
-	log.Println("Successfully connected to database")
+	logger.Info("Successfully connected to database")
 	return db, nil
 }
 
-func RunMigrations(db *gorm.DB) error {
-	// Convert our migrations to gormigrate format
-	migrationList := migrations.GetMigrations()
-	gormigrateMigrations := make([]*gormigrate.Migration, len(migrationList))
-
-	for i, mig := range migrationList {
-		gormigrateMigrations[i] = &gormigrate.Migration{
-			ID:       mig.ID,
-			Migrate:  mig.Migrate,
-			Rollback: mig.Rollback,
-		}
+// RunMigrations applies every pending migration.
+func RunMigrations(ctx context.Context, db *gorm.DB) error {
+	if err := NewMigrator(db).Up(ctx); err != nil {
+		return fmt.Errorf("could not migrate: %w", err)
 	}
 
-	m := gormigrate.New(db, gormigrate.DefaultOptions, gormigrateMigrations)
+	slog.Info("Database migrations completed successfully")
+	return nil
+}
 
-	if err := m.Migrate(); err != nil {
-		return fmt.Errorf("could not migrate: %w", err)
+// PendingMigrations returns the IDs of known migrations that have not yet
+// been applied, in the order they would run.
+func PendingMigrations(ctx context.Context, db *gorm.DB) ([]string, error) {
+	statuses, err := NewMigrator(db).Status(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	log.Println("Database migrations completed successfully")
-	return nil
+	var pending []string
+	for _, status := range statuses {
+		if !status.Applied {
+			pending = append(pending, status.ID)
+		}
+	}
+	return pending, nil
 }
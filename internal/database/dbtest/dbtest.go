@@ -0,0 +1,215 @@
+// Package dbtest provides a Postgres database to Go tests. Every test works in
+// its own transaction, rolled back when the test ends, so tests can share one
+// database, run in any order and leave nothing behind.
+//
+//	func TestSomething(t *testing.T) {
+//		tx := dbtest.Tx(t)
+//		dbtest.LoadFixtures(t, tx, os.DirFS("testdata"), "users.yaml")
+//		repo := repository.NewUserRepository(tx)
+//		...
+//	}
+package dbtest
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jixlox0/studoto-backend/internal/config"
+	"github.com/jixlox0/studoto-backend/internal/database"
+	"github.com/jixlox0/studoto-backend/internal/seed"
+	"gopkg.in/yaml.v3"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// RequiredEnv makes tests fail instead of skip when no database is reachable,
+// for CI where a missing database is a broken setup.
+const RequiredEnv = "DBTEST_REQUIRED"
+
+// connectTimeout bounds the first connection, so a missing database skips
+// tests quickly instead of waiting out the server's connection retries
+const connectTimeout = 3 * time.Second
+
+var (
+	openOnce sync.Once
+	openDB   *gorm.DB
+	openEnv  string
+	openErr  error
+)
+
+// Open returns the shared test database, connecting and applying migrations
+// on first use. It uses the same configuration as the server (DB_HOST and so
+// on, or CONFIG_FILE) and refuses any configuration but development or test,
+// since migrations are applied to it. When no database is reachable the test
+// is skipped, unless DBTEST_REQUIRED is set.
+func Open(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	openOnce.Do(func() {
+		openDB, openEnv, openErr = open()
+	})
+	if openErr != nil {
+		if required, _ := strconv.ParseBool(os.Getenv(RequiredEnv)); required {
+			t.Fatalf("test database unavailable: %v", openErr)
+		}
+		t.Skipf("test database unavailable: %v", openErr)
+	}
+	return openDB
+}
+
+func open() (*gorm.DB, string, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, "", err
+	}
+	// Tests may write anything, so they get the same guard as seeding
+	if err := seed.Guard(cfg.Env, false); err != nil {
+		return nil, "", fmt.Errorf("refusing to run tests against a %s configuration: %w", cfg.Env, err)
+	}
+
+	db, err := gorm.Open(postgres.Open(database.DSN(cfg.Database)), &gorm.Config{
+		Logger:               gormlogger.Discard,
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), connectTimeout)
+	defer cancel()
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, "", err
+	}
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return nil, "", err
+	}
+
+	if err := database.RunMigrations(context.Background(), db); err != nil {
+		return nil, "", err
+	}
+	return db, cfg.Env, nil
+}
+
+// Tx begins a transaction on the shared test database and rolls it back when
+// the test and its subtests have finished. Pass the returned handle wherever
+// a *gorm.DB is expected, e.g. to repository constructors.
+func Tx(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	tx := Open(t).Begin()
+	if tx.Error != nil {
+		t.Fatalf("failed to begin test transaction: %v", tx.Error)
+	}
+	t.Cleanup(func() {
+		tx.Rollback()
+	})
+	return tx
+}
+
+// Env returns the environment of the test database's configuration, for code
+// that checks it, such as seed.Registry.Run.
+func Env(t testing.TB) string {
+	t.Helper()
+
+	Open(t)
+	return openEnv
+}
+
+// Seed runs the named seed sets, or all of them, with the default options.
+func Seed(t testing.TB, db *gorm.DB, names ...string) {
+	t.Helper()
+
+	registry := seed.NewDefaultRegistry(seed.DefaultOptions())
+	if err := registry.Run(context.Background(), db, Env(t), names...); err != nil {
+		t.Fatalf("failed to seed: %v", err)
+	}
+}
+
+// LoadFixtures inserts the rows in the fixture files matching patterns in
+// fsys. A fixture file is YAML mapping table names to lists of rows, inserted
+// in the order they appear so that referenced rows can come first:
+//
+//	users:
+//	  - uuid: usr-alice
+//	    email: alice@example.com
+//	    name: Alice
+//	audit_events:
+//	  - uuid: aud-1
+//	    actor_id: usr-alice
+//	    action: auth.signup
+//
+// created_at and updated_at default to the current time when the table has
+// them and the row does not set them.
+func LoadFixtures(t testing.TB, db *gorm.DB, fsys fs.FS, patterns ...string) {
+	t.Helper()
+
+	for _, pattern := range patterns {
+		paths, err := fs.Glob(fsys, pattern)
+		if err != nil {
+			t.Fatalf("invalid fixture pattern %q: %v", pattern, err)
+		}
+		if len(paths) == 0 {
+			t.Fatalf("no fixture files match %q", pattern)
+		}
+		for _, path := range paths {
+			if err := loadFile(db, fsys, path); err != nil {
+				t.Fatalf("failed to load fixtures from %s: %v", path, err)
+			}
+		}
+	}
+}
+
+func loadFile(db *gorm.DB, fsys fs.FS, path string) error {
+	data, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return err
+	}
+
+	// Decoding into a node keeps the tables in file order
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	if len(doc.Content) == 0 {
+		return nil
+	}
+	tables := doc.Content[0]
+	if tables.Kind != yaml.MappingNode {
+		return fmt.Errorf("expected a mapping of table names to rows")
+	}
+
+	now := time.Now()
+	for i := 0; i+1 < len(tables.Content); i += 2 {
+		table := tables.Content[i].Value
+		var rows []map[string]any
+		if err := tables.Content[i+1].Decode(&rows); err != nil {
+			return fmt.Errorf("%s: %w", table, err)
+		}
+
+		var timestamps []string
+		for _, column := range []string{"created_at", "updated_at"} {
+			if db.Migrator().HasColumn(table, column) {
+				timestamps = append(timestamps, column)
+			}
+		}
+		for n, row := range rows {
+			for _, column := range timestamps {
+				if _, ok := row[column]; !ok {
+					row[column] = now
+				}
+			}
+			if err := db.Table(table).Create(row).Error; err != nil {
+				return fmt.Errorf("%s row %d: %w", table, n+1, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package dbtest
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jixlox0/studoto-backend/internal/models"
+)
+
+func TestLoadFixtures(t *testing.T) {
+	tx := Tx(t)
+	before := time.Now()
+	// The token references the user, so this fails unless tables are
+	// inserted in file order
+	LoadFixtures(t, tx, os.DirFS("testdata"), "tokens.yaml")
+
+	var token models.AccessToken
+	if err := tx.Preload("User").Where("uuid = ?", "tok-fixture-1").First(&token).Error; err != nil {
+		t.Fatalf("fixture token not loaded: %v", err)
+	}
+	if token.User.UUID != "usr-fixture-owner" {
+		t.Errorf("token belongs to %q, want usr-fixture-owner", token.User.UUID)
+	}
+	for name, ts := range map[string]time.Time{
+		"user created_at":  token.User.CreatedAt,
+		"user updated_at":  token.User.UpdatedAt,
+		"token created_at": token.CreatedAt,
+		"token updated_at": token.UpdatedAt,
+	} {
+		if ts.Before(before.Add(-time.Second)) {
+			t.Errorf("%s = %v, want it filled in with the load time", name, ts)
+		}
+	}
+}
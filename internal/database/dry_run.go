@@ -0,0 +1,41 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// statementPrinter is a GORM logger that writes every statement that changes
+// the schema or data, for dry runs. Reads, including the introspection
+// queries behind HasTable and HasColumn, are left out.
+type statementPrinter struct {
+	w io.Writer
+}
+
+func newStatementPrinter(w io.Writer) *statementPrinter {
+	return &statementPrinter{w: w}
+}
+
+func (p *statementPrinter) LogMode(gormlogger.LogLevel) gormlogger.Interface {
+	return p
+}
+
+func (p *statementPrinter) Info(context.Context, string, ...interface{}) {}
+
+func (p *statementPrinter) Warn(context.Context, string, ...interface{}) {}
+
+func (p *statementPrinter) Error(context.Context, string, ...interface{}) {}
+
+func (p *statementPrinter) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	sql, _ := fc()
+	sql = strings.TrimSpace(sql)
+	if sql == "" || strings.HasPrefix(strings.ToUpper(sql), "SELECT") {
+		return
+	}
+	fmt.Fprintf(p.w, "%s;\n\n", strings.TrimRight(sql, "; \n"))
+}
@@ -0,0 +1,19 @@
+package migrations
+
+import (
+	"github.com/jixlox0/studoto-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+func init() {
+	register(&Migration{
+		ID:   "20261018213006",
+		Name: "create_schools_and_courses",
+		Migrate: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&models.School{}, &models.Course{})
+		},
+		Rollback: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&models.Course{}, &models.School{})
+		},
+	})
+}
@@ -1,16 +1,29 @@
 package migrations
 
 import (
+	"sort"
+
 	"github.com/jixlox0/studoto-backend/internal/models"
 	"github.com/jixlox0/studoto-backend/pkg/uuid"
 	"gorm.io/gorm"
 )
 
-// GetMigrations returns all database migrations
+// registered holds the migrations that live in their own files, as
+// scaffolded by `migrate create` or loaded from the embedded SQL files.
+var registered []*Migration
+
+// register adds a migration defined in its own file. It is called from the
+// init function of that file.
+func register(m *Migration) {
+	registered = append(registered, m)
+}
+
+// GetMigrations returns all database migrations, ordered by ID
 func GetMigrations() []*Migration {
-	return []*Migration{
+	all := []*Migration{
 		{
-			ID: "20240101000001",
+			ID:   "20240101000001",
+			Name: "create_users",
 			Migrate: func(tx *gorm.DB) error {
 				return tx.AutoMigrate(&models.User{})
 			},
@@ -19,7 +32,8 @@ func GetMigrations() []*Migration {
 			},
 		},
 		{
-			ID: "20240101000002",
+			ID:   "20240101000002",
+			Name: "add_user_uuid",
 			Migrate: func(tx *gorm.DB) error {
 				// Add UUID column if it doesn't exist
 				if !tx.Migrator().HasColumn(&models.User{}, "uuid") {
@@ -47,13 +61,85 @@ func GetMigrations() []*Migration {
 				return nil
 			},
 		},
-		// Add more migrations here as needed
+		{
+			ID:   "20240101000003",
+			Name: "create_access_tokens",
+			Migrate: func(tx *gorm.DB) error {
+				return tx.AutoMigrate(&models.AccessToken{})
+			},
+			Rollback: func(tx *gorm.DB) error {
+				return tx.Migrator().DropTable(&models.AccessToken{})
+			},
+		},
+		{
+			ID:   "20240101000004",
+			Name: "add_user_role",
+			Migrate: func(tx *gorm.DB) error {
+				if !tx.Migrator().HasColumn(&models.User{}, "role") {
+					return tx.Migrator().AddColumn(&models.User{}, "role")
+				}
+				return nil
+			},
+			Rollback: func(tx *gorm.DB) error {
+				if tx.Migrator().HasColumn(&models.User{}, "role") {
+					return tx.Migrator().DropColumn(&models.User{}, "role")
+				}
+				return nil
+			},
+		},
+		{
+			ID:   "20240101000005",
+			Name: "create_audit_events",
+			Migrate: func(tx *gorm.DB) error {
+				return tx.AutoMigrate(&models.AuditEvent{})
+			},
+			Rollback: func(tx *gorm.DB) error {
+				return tx.Migrator().DropTable(&models.AuditEvent{})
+			},
+		},
+		{
+			ID:   "20240101000006",
+			Name: "add_user_deletion_schedule",
+			Migrate: func(tx *gorm.DB) error {
+				for _, column := range []string{"deletion_requested_at", "deletion_scheduled_at"} {
+					if !tx.Migrator().HasColumn(&models.User{}, column) {
+						if err := tx.Migrator().AddColumn(&models.User{}, column); err != nil {
+							return err
+						}
+					}
+				}
+				if !tx.Migrator().HasIndex(&models.User{}, "deletion_scheduled_at") {
+					return tx.Migrator().CreateIndex(&models.User{}, "deletion_scheduled_at")
+				}
+				return nil
+			},
+			Rollback: func(tx *gorm.DB) error {
+				for _, column := range []string{"deletion_requested_at", "deletion_scheduled_at"} {
+					if tx.Migrator().HasColumn(&models.User{}, column) {
+						if err := tx.Migrator().DropColumn(&models.User{}, column); err != nil {
+							return err
+						}
+					}
+				}
+				return nil
+			},
+		},
 	}
+
+	all = append(all, registered...)
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].ID < all[j].ID
+	})
+	return all
 }
 
 // Migration represents a database migration
 type Migration struct {
-	ID       string
+	ID   string
+	Name string
+	// Checksum identifies the content of SQL migrations, so that editing one
+	// after it has been applied is detected. Go migrations have none.
+	Checksum string
 	Migrate  func(*gorm.DB) error
 	Rollback func(*gorm.DB) error
 }
@@ -0,0 +1,98 @@
+package migrations
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// SQL migrations are pairs of files named <id>_<name>.up.sql and
+// <id>_<name>.down.sql. The down file is optional; without it the migration
+// cannot be rolled back.
+const (
+	sqlUpSuffix   = ".up.sql"
+	sqlDownSuffix = ".down.sql"
+)
+
+//go:embed sql/*.sql
+var sqlFiles embed.FS
+
+func init() {
+	migrations, err := loadSQLMigrations(sqlFiles, "sql")
+	if err != nil {
+		// The files are embedded at build time, so this is a bug in the build
+		panic(err)
+	}
+	for _, m := range migrations {
+		register(m)
+	}
+}
+
+// loadSQLMigrations reads the SQL migrations in dir. Each file runs as a
+// single Exec, so it may hold several statements.
+func loadSQLMigrations(fsys fs.FS, dir string) ([]*Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	ups := make(map[string]string)
+	downs := make(map[string]string)
+	var order []string
+	for _, entry := range entries {
+		fileName := entry.Name()
+		data, err := fs.ReadFile(fsys, path.Join(dir, fileName))
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case strings.HasSuffix(fileName, sqlUpSuffix):
+			base := strings.TrimSuffix(fileName, sqlUpSuffix)
+			ups[base] = string(data)
+			order = append(order, base)
+		case strings.HasSuffix(fileName, sqlDownSuffix):
+			downs[strings.TrimSuffix(fileName, sqlDownSuffix)] = string(data)
+		default:
+			return nil, fmt.Errorf("sql migration %s: name must end in %s or %s", fileName, sqlUpSuffix, sqlDownSuffix)
+		}
+	}
+	for base := range downs {
+		if _, ok := ups[base]; !ok {
+			return nil, fmt.Errorf("sql migration %s%s has no matching %s file", base, sqlDownSuffix, sqlUpSuffix)
+		}
+	}
+
+	migrations := make([]*Migration, 0, len(order))
+	for _, base := range order {
+		id, name, ok := strings.Cut(base, "_")
+		if !ok || id == "" || name == "" {
+			return nil, fmt.Errorf("sql migration %s: name must be <id>_<name>", base)
+		}
+		migrations = append(migrations, newSQLMigration(id, name, ups[base], downs[base]))
+	}
+	return migrations, nil
+}
+
+func newSQLMigration(id, name, up, down string) *Migration {
+	sum := sha256.Sum256([]byte(up))
+	m := &Migration{
+		ID:       id,
+		Name:     name,
+		Checksum: hex.EncodeToString(sum[:]),
+		Migrate: func(tx *gorm.DB) error {
+			return tx.Exec(up).Error
+		},
+	}
+	if down != "" {
+		m.Rollback = func(tx *gorm.DB) error {
+			return tx.Exec(down).Error
+		}
+	}
+	return m
+}
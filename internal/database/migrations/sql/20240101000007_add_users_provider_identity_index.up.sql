@@ -0,0 +1,4 @@
+-- OAuth logins look users up by provider and provider ID together
+CREATE INDEX IF NOT EXISTS idx_users_provider_identity
+    ON users (provider, provider_id)
+    WHERE provider_id <> '' AND deleted_at IS NULL;
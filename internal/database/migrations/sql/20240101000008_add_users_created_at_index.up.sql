@@ -0,0 +1,5 @@
+-- The admin user list pages through users by creation time, with the ID
+-- breaking ties
+CREATE INDEX IF NOT EXISTS idx_users_created_at_id
+    ON users (created_at, id)
+    WHERE deleted_at IS NULL;
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_users_provider_identity;
+CREATE INDEX idx_users_provider_identity
+    ON users (provider, provider_id)
+    WHERE provider_id <> '' AND deleted_at IS NULL;
@@ -0,0 +1,6 @@
+-- An OAuth identity belongs to one account, so concurrent first logins with
+-- it cannot both create one; the loser finds the winner's row instead
+DROP INDEX IF EXISTS idx_users_provider_identity;
+CREATE UNIQUE INDEX idx_users_provider_identity
+    ON users (provider, provider_id)
+    WHERE provider_id <> '' AND deleted_at IS NULL;
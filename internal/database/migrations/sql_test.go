@@ -0,0 +1,76 @@
+package migrations
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoadSQLMigrations(t *testing.T) {
+	fsys := fstest.MapFS{
+		"sql/0002_add_index.up.sql":      {Data: []byte("CREATE INDEX i ON t (c);")},
+		"sql/0001_create_table.up.sql":   {Data: []byte("CREATE TABLE t (c int);")},
+		"sql/0001_create_table.down.sql": {Data: []byte("DROP TABLE t;")},
+	}
+
+	migrations, err := loadSQLMigrations(fsys, "sql")
+	if err != nil {
+		t.Fatalf("loadSQLMigrations() error = %v", err)
+	}
+	if len(migrations) != 2 {
+		t.Fatalf("loaded %d migrations, want 2", len(migrations))
+	}
+
+	first, second := migrations[0], migrations[1]
+	if first.ID != "0001" || first.Name != "create_table" || second.ID != "0002" || second.Name != "add_index" {
+		t.Errorf("migrations = %s_%s, %s_%s; want 0001_create_table, 0002_add_index",
+			first.ID, first.Name, second.ID, second.Name)
+	}
+	sum := sha256.Sum256([]byte("CREATE TABLE t (c int);"))
+	if first.Checksum != hex.EncodeToString(sum[:]) {
+		t.Errorf("checksum = %s, want the SHA-256 of the up file", first.Checksum)
+	}
+	if first.Rollback == nil {
+		t.Error("migration with a down file cannot be rolled back")
+	}
+	if second.Rollback != nil {
+		t.Error("migration without a down file can be rolled back")
+	}
+}
+
+func TestLoadSQLMigrationsRejectsBadFiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   []string
+		wantErr string
+	}{
+		{name: "orphan down file", files: []string{"0001_create_table.up.sql", "0002_add_index.down.sql"},
+			wantErr: "0002_add_index.down.sql has no matching .up.sql file"},
+		{name: "unknown suffix", files: []string{"0001_create_table.sql"},
+			wantErr: "name must end in .up.sql or .down.sql"},
+		{name: "missing name", files: []string{"0001.up.sql"},
+			wantErr: "name must be <id>_<name>"},
+		{name: "missing id", files: []string{"_create_table.up.sql"},
+			wantErr: "name must be <id>_<name>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := fstest.MapFS{}
+			for _, file := range tt.files {
+				fsys["sql/"+file] = &fstest.MapFile{Data: []byte("SELECT 1;")}
+			}
+			if _, err := loadSQLMigrations(fsys, "sql"); err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("loadSQLMigrations() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEmbeddedSQLMigrations(t *testing.T) {
+	if _, err := loadSQLMigrations(sqlFiles, "sql"); err != nil {
+		t.Fatalf("embedded migrations: %v", err)
+	}
+}
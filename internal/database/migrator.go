@@ -0,0 +1,403 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-gormigrate/gormigrate/v2"
+	"github.com/jixlox0/studoto-backend/internal/database/migrations"
+	"gorm.io/gorm"
+)
+
+// Columns added to the gormigrate table, which only stores IDs. applied_at is
+// filled by a database default; checksum is recorded after a run.
+const (
+	appliedAtColumn = "applied_at"
+	checksumColumn  = "checksum"
+)
+
+// migrationLockKey identifies the Postgres advisory lock held while
+// migrations run, so that replicas starting together migrate one at a time.
+// Any value works as long as every instance uses the same one.
+const migrationLockKey int64 = 0x73747564_6d696772
+
+// ErrMigrationModified is returned when an applied SQL migration no longer
+// matches the checksum recorded when it ran.
+var ErrMigrationModified = errors.New("applied migration has been modified")
+
+// MigrationStatus describes one migration known to the code or recorded in
+// the database.
+type MigrationStatus struct {
+	ID      string
+	Name    string
+	Applied bool
+	// AppliedAt is nil for pending migrations and for those applied before
+	// the time was recorded
+	AppliedAt *time.Time
+	// Missing is set for migrations recorded as applied that no longer
+	// exist in the code
+	Missing bool
+	// Modified is set for applied SQL migrations whose file has changed
+	// since they ran
+	Modified bool
+}
+
+// Migrator applies and rolls back the migrations in migrations.GetMigrations.
+// Changes run under an advisory lock and are refused while an applied
+// migration has been modified.
+type Migrator interface {
+	// Up applies every pending migration.
+	Up(ctx context.Context) error
+	// Down rolls back the last n applied migrations and returns their IDs.
+	Down(ctx context.Context, n int) ([]string, error)
+	// To migrates forward or rolls back until id is the last applied
+	// migration.
+	To(ctx context.Context, id string) error
+	// Redo rolls back the last applied migration, applies it again and
+	// returns its ID. The migration may have been modified.
+	Redo(ctx context.Context) (string, error)
+	// Status lists every migration in order, followed by any applied
+	// migrations missing from the code.
+	Status(ctx context.Context) ([]MigrationStatus, error)
+}
+
+type migrator struct {
+	db         *gorm.DB
+	migrations []*migrations.Migration
+	// dryRun receives the statements that would run; nil runs them for real
+	dryRun io.Writer
+}
+
+func NewMigrator(db *gorm.DB) Migrator {
+	return &migrator{
+		db:         db,
+		migrations: migrations.GetMigrations(),
+	}
+}
+
+// NewDryRunMigrator returns a Migrator that writes the SQL each change would
+// execute to w instead of applying it. Changes run inside a transaction that
+// is always rolled back, so Go migrations that branch on the current schema
+// or data print what they would really do.
+func NewDryRunMigrator(db *gorm.DB, w io.Writer) Migrator {
+	return &migrator{
+		db:         db,
+		migrations: migrations.GetMigrations(),
+		dryRun:     w,
+	}
+}
+
+// migrationRecord is a row of the gormigrate table.
+type migrationRecord struct {
+	ID        string
+	AppliedAt *time.Time
+	Checksum  string
+}
+
+func (m *migrator) Up(ctx context.Context) error {
+	return m.run(ctx, func(db *gorm.DB, records map[string]migrationRecord) error {
+		if err := m.checkModified(records, ""); err != nil {
+			return err
+		}
+		return m.gormigrate(db).Migrate()
+	})
+}
+
+func (m *migrator) Down(ctx context.Context, n int) ([]string, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("number of migrations to roll back must be positive, got %d", n)
+	}
+
+	var rolledBack []string
+	err := m.run(ctx, func(db *gorm.DB, records map[string]migrationRecord) error {
+		if err := m.checkModified(records, ""); err != nil {
+			return err
+		}
+		applied := m.applied(records)
+		if n > len(applied) {
+			return fmt.Errorf("cannot roll back %d migration(s): only %d applied", n, len(applied))
+		}
+
+		g := m.gormigrate(db)
+		for i := len(applied) - 1; i >= len(applied)-n; i-- {
+			if err := g.RollbackLast(); err != nil {
+				return fmt.Errorf("failed to roll back %s: %w", applied[i], err)
+			}
+			rolledBack = append(rolledBack, applied[i])
+		}
+		return nil
+	})
+	return rolledBack, err
+}
+
+func (m *migrator) To(ctx context.Context, id string) error {
+	return m.run(ctx, func(db *gorm.DB, records map[string]migrationRecord) error {
+		if err := m.checkModified(records, ""); err != nil {
+			return err
+		}
+		g := m.gormigrate(db)
+		if _, ok := records[id]; ok {
+			return g.RollbackTo(id)
+		}
+		return g.MigrateTo(id)
+	})
+}
+
+func (m *migrator) Redo(ctx context.Context) (string, error) {
+	var last string
+	err := m.run(ctx, func(db *gorm.DB, records map[string]migrationRecord) error {
+		applied := m.applied(records)
+		if len(applied) == 0 {
+			return errors.New("no applied migration to redo")
+		}
+		last = applied[len(applied)-1]
+		// Redoing is how an edit to the latest migration is applied, so only
+		// the others have to be unchanged
+		if err := m.checkModified(records, last); err != nil {
+			return err
+		}
+
+		g := m.gormigrate(db)
+		if err := g.RollbackLast(); err != nil {
+			return fmt.Errorf("failed to roll back %s: %w", last, err)
+		}
+		if err := g.MigrateTo(last); err != nil {
+			return fmt.Errorf("failed to re-apply %s: %w", last, err)
+		}
+		return nil
+	})
+	return last, err
+}
+
+func (m *migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	records, err := readRecords(m.db.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return m.statuses(records), nil
+}
+
+// statuses merges the migrations known to the code with the applied records.
+// It consumes records.
+func (m *migrator) statuses(records map[string]migrationRecord) []MigrationStatus {
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		status := MigrationStatus{ID: mig.ID, Name: mig.Name}
+		if record, ok := records[mig.ID]; ok {
+			status.Applied = true
+			status.AppliedAt = record.AppliedAt
+			status.Modified = modified(mig, record)
+			delete(records, mig.ID)
+		}
+		statuses = append(statuses, status)
+	}
+	for _, record := range records {
+		statuses = append(statuses, MigrationStatus{
+			ID:        record.ID,
+			Applied:   true,
+			AppliedAt: record.AppliedAt,
+			Missing:   true,
+		})
+	}
+	missing := statuses[len(m.migrations):]
+	sort.Slice(missing, func(i, j int) bool {
+		return missing[i].ID < missing[j].ID
+	})
+	return statuses
+}
+
+// run holds the migration lock while fn changes the schema, then records the
+// checksums of the migrations it applied. In dry-run mode everything,
+// including the bookkeeping, happens in a transaction that is rolled back.
+func (m *migrator) run(ctx context.Context, fn func(db *gorm.DB, records map[string]migrationRecord) error) error {
+	unlock, err := m.lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	db := m.db.WithContext(ctx)
+	if m.dryRun != nil {
+		tx := db.Session(&gorm.Session{Logger: newStatementPrinter(m.dryRun)}).Begin()
+		if tx.Error != nil {
+			return tx.Error
+		}
+		defer tx.Rollback()
+		db = tx
+	}
+
+	if err := prepareTable(db); err != nil {
+		return fmt.Errorf("failed to prepare migrations table: %w", err)
+	}
+	records, err := readRecords(db)
+	if err != nil {
+		return err
+	}
+	if err := fn(db, records); err != nil {
+		return err
+	}
+	return m.recordChecksums(db)
+}
+
+// lock takes the migration advisory lock on a dedicated connection, since
+// session locks belong to the connection that took them. The returned
+// function releases it.
+func (m *migrator) lock(ctx context.Context) (func(), error) {
+	sqlDB, err := m.db.DB()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", migrationLockKey).Scan(&locked); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	if !locked {
+		slog.Info("Waiting for another instance to finish migrating")
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockKey); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+	}
+
+	return func() {
+		// Closing the connection ends the session, which releases the lock
+		// even if the unlock fails
+		if _, err := conn.ExecContext(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", migrationLockKey); err != nil {
+			slog.Warn("Failed to release migration lock", "error", err)
+		}
+		conn.Close()
+	}, nil
+}
+
+func (m *migrator) gormigrate(db *gorm.DB) *gormigrate.Gormigrate {
+	list := make([]*gormigrate.Migration, len(m.migrations))
+	for i, mig := range m.migrations {
+		list[i] = &gormigrate.Migration{
+			ID:       mig.ID,
+			Migrate:  mig.Migrate,
+			Rollback: mig.Rollback,
+		}
+	}
+	return gormigrate.New(db, gormigrate.DefaultOptions, list)
+}
+
+// applied returns the IDs of the applied migrations known to the code, in the
+// order they were defined.
+func (m *migrator) applied(records map[string]migrationRecord) []string {
+	var applied []string
+	for _, mig := range m.migrations {
+		if _, ok := records[mig.ID]; ok {
+			applied = append(applied, mig.ID)
+		}
+	}
+	return applied
+}
+
+// checkModified fails if any applied migration other than except has changed
+// since it ran.
+func (m *migrator) checkModified(records map[string]migrationRecord, except string) error {
+	var ids []string
+	for _, mig := range m.migrations {
+		if record, ok := records[mig.ID]; ok && mig.ID != except && modified(mig, record) {
+			ids = append(ids, mig.ID)
+		}
+	}
+	if len(ids) > 0 {
+		return fmt.Errorf("%w: %s; restore the original file and add a new migration instead",
+			ErrMigrationModified, strings.Join(ids, ", "))
+	}
+	return nil
+}
+
+// recordChecksums stores the checksum of every applied SQL migration that has
+// none yet: those applied by this run, and those applied before checksums
+// were recorded, which take the current file as their baseline.
+func (m *migrator) recordChecksums(db *gorm.DB) error {
+	records, err := readRecords(db)
+	if err != nil {
+		return err
+	}
+	opts := gormigrate.DefaultOptions
+	for _, mig := range m.migrations {
+		record, ok := records[mig.ID]
+		if !ok || mig.Checksum == "" || record.Checksum != "" {
+			continue
+		}
+		err := db.Table(opts.TableName).
+			Where(opts.IDColumnName+" = ?", mig.ID).
+			Update(checksumColumn, mig.Checksum).Error
+		if err != nil {
+			return fmt.Errorf("failed to record checksum of %s: %w", mig.ID, err)
+		}
+	}
+	return nil
+}
+
+func modified(mig *migrations.Migration, record migrationRecord) bool {
+	return mig.Checksum != "" && record.Checksum != "" && mig.Checksum != record.Checksum
+}
+
+// prepareTable creates the migrations table, or adds the columns missing from
+// one created by an earlier release. Rows that predate applied_at keep a NULL
+// time rather than the time of the upgrade.
+func prepareTable(db *gorm.DB) error {
+	opts := gormigrate.DefaultOptions
+
+	if !db.Migrator().HasTable(opts.TableName) {
+		return db.Exec(fmt.Sprintf(
+			"CREATE TABLE %s (%s VARCHAR(%d) PRIMARY KEY, %s TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP, %s VARCHAR(64))",
+			opts.TableName, opts.IDColumnName, opts.IDColumnSize, appliedAtColumn, checksumColumn,
+		)).Error
+	}
+	if !db.Migrator().HasColumn(opts.TableName, appliedAtColumn) {
+		if err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s TIMESTAMPTZ", opts.TableName, appliedAtColumn)).Error; err != nil {
+			return err
+		}
+		if err := db.Exec(fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET DEFAULT CURRENT_TIMESTAMP", opts.TableName, appliedAtColumn)).Error; err != nil {
+			return err
+		}
+	}
+	if !db.Migrator().HasColumn(opts.TableName, checksumColumn) {
+		return db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s VARCHAR(64)", opts.TableName, checksumColumn)).Error
+	}
+	return nil
+}
+
+// readRecords reads the migrations table without modifying the schema, so
+// that status works against a database that has never been migrated.
+func readRecords(db *gorm.DB) (map[string]migrationRecord, error) {
+	opts := gormigrate.DefaultOptions
+
+	records := make(map[string]migrationRecord)
+	if !db.Migrator().HasTable(opts.TableName) {
+		return records, nil
+	}
+
+	columns := []string{opts.IDColumnName + " AS id"}
+	if db.Migrator().HasColumn(opts.TableName, appliedAtColumn) {
+		columns = append(columns, appliedAtColumn)
+	}
+	if db.Migrator().HasColumn(opts.TableName, checksumColumn) {
+		columns = append(columns, fmt.Sprintf("COALESCE(%s, '') AS %s", checksumColumn, checksumColumn))
+	}
+	var rows []migrationRecord
+	if err := db.Table(opts.TableName).Select(strings.Join(columns, ", ")).Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	for _, row := range rows {
+		records[row.ID] = row
+	}
+	return records, nil
+}
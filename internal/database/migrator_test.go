@@ -0,0 +1,87 @@
+package database
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jixlox0/studoto-backend/internal/database/migrations"
+)
+
+func testMigrator() *migrator {
+	return &migrator{migrations: []*migrations.Migration{
+		{ID: "0001", Name: "create_users"},
+		{ID: "0002", Name: "add_index", Checksum: "aaa"},
+		{ID: "0003", Name: "add_column", Checksum: "bbb"},
+	}}
+}
+
+func TestModified(t *testing.T) {
+	tests := []struct {
+		name     string
+		checksum string
+		recorded string
+		want     bool
+	}{
+		{"unchanged", "aaa", "aaa", false},
+		{"changed", "aaa", "bbb", true},
+		{"Go migration", "", "aaa", false},
+		{"applied before checksums were recorded", "aaa", "", false},
+	}
+	for _, tt := range tests {
+		got := modified(&migrations.Migration{Checksum: tt.checksum}, migrationRecord{Checksum: tt.recorded})
+		if got != tt.want {
+			t.Errorf("%s: modified() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCheckModified(t *testing.T) {
+	m := testMigrator()
+	records := map[string]migrationRecord{
+		"0001": {ID: "0001"},
+		"0002": {ID: "0002", Checksum: "changed"},
+		"0003": {ID: "0003", Checksum: "changed"},
+	}
+
+	err := m.checkModified(records, "")
+	if !errors.Is(err, ErrMigrationModified) || !strings.Contains(err.Error(), "0002, 0003") {
+		t.Fatalf("checkModified() error = %v, want 0002 and 0003 reported", err)
+	}
+	// Redo may re-apply an edited last migration
+	err = m.checkModified(records, "0003")
+	if !errors.Is(err, ErrMigrationModified) || strings.Contains(err.Error(), "0003") {
+		t.Fatalf("checkModified(except 0003) error = %v, want only 0002 reported", err)
+	}
+	delete(records, "0002")
+	if err := m.checkModified(records, "0003"); err != nil {
+		t.Fatalf("checkModified() error = %v", err)
+	}
+}
+
+func TestStatuses(t *testing.T) {
+	appliedAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	records := map[string]migrationRecord{
+		"0001": {ID: "0001", AppliedAt: &appliedAt},
+		"0002": {ID: "0002", Checksum: "changed"},
+		"0010": {ID: "0010"},
+		"0000": {ID: "0000"},
+		"0005": {ID: "0005"},
+	}
+
+	got := testMigrator().statuses(records)
+	want := []MigrationStatus{
+		{ID: "0001", Name: "create_users", Applied: true, AppliedAt: &appliedAt},
+		{ID: "0002", Name: "add_index", Applied: true, Modified: true},
+		{ID: "0003", Name: "add_column"},
+		// Missing migrations follow, ordered by ID
+		{ID: "0000", Applied: true, Missing: true},
+		{ID: "0005", Applied: true, Missing: true},
+		{ID: "0010", Applied: true, Missing: true},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("statuses() =\n%+v\nwant\n%+v", got, want)
+	}
+}
@@ -0,0 +1,85 @@
+// Package email renders the emails sent to users. Subjects and bodies live in
+// the i18n catalogs under "email.<template>.subject" and
+// "email.<template>.body", so an email is written in the recipient's language
+// with the same fallback chain as API messages. Rendering is separate from
+// delivery: a mailer sends the returned Message.
+package email
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jixlox0/studoto-backend/internal/i18n"
+)
+
+// Template names an email. The comment of each lists the values it takes.
+type Template string
+
+const (
+	// AccountDeletionScheduled confirms a deletion request: name, and date
+	// the account will be purged.
+	AccountDeletionScheduled Template = "account_deletion_scheduled"
+	// AccountDeletionCancelled confirms a cancelled deletion: name.
+	AccountDeletionCancelled Template = "account_deletion_cancelled"
+	// PasswordChanged warns about a password change: name, and date of the
+	// change.
+	PasswordChanged Template = "password_changed"
+)
+
+// Templates lists every template, each of which must be in the default
+// catalog.
+var Templates = []Template{AccountDeletionScheduled, AccountDeletionCancelled, PasswordChanged}
+
+// placeholder matches a "{name}" in a template
+var placeholder = regexp.MustCompile(`\{([a-z_]+)\}`)
+
+// Message is a rendered email.
+type Message struct {
+	// Locale is the language the email is written in, for the
+	// Content-Language header.
+	Locale  string
+	Subject string
+	Body    string
+}
+
+// Render writes the email t in the localizer's language with every "{name}"
+// replaced by data[name]. Dates should be formatted with the localizer's
+// Date. It fails if the template is unknown or a value is missing; values are
+// inserted as they are, even if they look like placeholders themselves.
+func Render(l *i18n.Localizer, t Template, data map[string]string) (*Message, error) {
+	subject, err := render(l, t, "subject", data)
+	if err != nil {
+		return nil, err
+	}
+	body, err := render(l, t, "body", data)
+	if err != nil {
+		return nil, err
+	}
+	return &Message{
+		Locale:  l.Locale(),
+		Subject: subject,
+		Body:    strings.TrimSpace(body) + "\n",
+	}, nil
+}
+
+// render returns part of the email t, checking the template itself rather than
+// the result for placeholders that data has no value for.
+func render(l *i18n.Localizer, t Template, part string, data map[string]string) (string, error) {
+	key := "email." + string(t) + "." + part
+	template, ok := l.Message(key, nil)
+	if !ok {
+		return "", fmt.Errorf("email template %q has no %s", t, part)
+	}
+	var missing []string
+	for _, match := range placeholder.FindAllStringSubmatch(template, -1) {
+		if _, ok := data[match[1]]; !ok {
+			missing = append(missing, match[0])
+		}
+	}
+	if len(missing) > 0 {
+		return "", fmt.Errorf("email template %q: no value for %s", t, strings.Join(missing, ", "))
+	}
+	message, _ := l.Message(key, data)
+	return message, nil
+}
@@ -0,0 +1,66 @@
+package email
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jixlox0/studoto-backend/internal/i18n"
+)
+
+func TestRenderEveryTemplate(t *testing.T) {
+	b, err := i18n.NewBundle()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := map[string]string{"name": "Ada", "date": "17 novembre 2026"}
+
+	for _, tmpl := range Templates {
+		en, err := Render(b.Localizer("en"), tmpl, data)
+		if err != nil {
+			t.Fatalf("Render(en, %s) error = %v", tmpl, err)
+		}
+		fr, err := Render(b.Localizer(b.Match("fr-CA")), tmpl, data)
+		if err != nil {
+			t.Fatalf("Render(fr, %s) error = %v", tmpl, err)
+		}
+
+		if fr.Locale != "fr" || fr.Subject == en.Subject || fr.Body == en.Body {
+			t.Errorf("%s: French email = %+v, want a translation of %+v", tmpl, fr, en)
+		}
+		for _, m := range []*Message{en, fr} {
+			if !strings.Contains(m.Body, "Ada") {
+				t.Errorf("%s (%s): body %q does not greet the user", tmpl, m.Locale, m.Body)
+			}
+		}
+	}
+}
+
+func TestRenderReportsMissingValues(t *testing.T) {
+	b, err := i18n.NewBundle()
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := b.Localizer("en")
+
+	if _, err := Render(l, AccountDeletionScheduled, map[string]string{"name": "Ada"}); err == nil || !strings.Contains(err.Error(), "{date}") {
+		t.Errorf("Render() without date error = %v, want {date} reported", err)
+	}
+	if _, err := Render(l, Template("welcome"), nil); err == nil {
+		t.Error("Render() of an unknown template succeeded")
+	}
+}
+
+func TestRenderKeepsPlaceholdersInValues(t *testing.T) {
+	b, err := i18n.NewBundle()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := Render(b.Localizer("en"), PasswordChanged, map[string]string{"name": "Ada {x} {date}", "date": "October 18, 2026"})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if !strings.Contains(m.Body, "Ada {x} {date}") {
+		t.Errorf("body %q does not contain the name as given", m.Body)
+	}
+}
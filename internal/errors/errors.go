@@ -2,21 +2,102 @@ package errors
 
 import "errors"
 
+// Error is an error reported to API clients. Code identifies it in responses
+// and never changes once published, so clients can act on it; Message is the
+// default, English, description.
+type Error struct {
+	Code    string
+	Message string
+}
+
+// New returns an Error with the given code and message.
+func New(code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Code returns the code of the first Error in err's chain, or "" if there is
+// none.
+func Code(err error) string {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr.Code
+	}
+	return ""
+}
+
 // User-related errors
 var (
-	ErrUserNotFound       = errors.New("User not found")
-	ErrUserAlreadyExists  = errors.New("User already exists")
-	ErrInvalidPassword    = errors.New("Invalid password")
-	ErrInvalidProvider    = errors.New("Invalid provider")
-	ErrInvalidCredentials = errors.New("Invalid credentials")
-	ErrInvalidToken       = errors.New("Invalid token")
-	ErrUnauthorized       = errors.New("Unauthorized")
-	ErrBadRequest         = errors.New("Bad request")
-	ErrInternalError      = errors.New("Internal error")
-	ErrValidationError    = errors.New("Validation error")
-	ErrXAuthKeyRequired   = errors.New("X-Auth-Key required")
-	ErrXAuthKeyEmpty      = errors.New("X-Auth-Key empty")
-	ErrCodeRequired       = errors.New("Code required")
-	ErrNotAuthenticated   = errors.New("Not authenticated")
-	ErrInvalidUserIDType  = errors.New("Invalid user ID type")
+	ErrUserNotFound       = New("user_not_found", "User not found")
+	ErrUserAlreadyExists  = New("user_already_exists", "User already exists")
+	ErrInvalidPassword    = New("invalid_password", "Invalid password")
+	ErrInvalidProvider    = New("invalid_provider", "Invalid provider")
+	ErrInvalidCredentials = New("invalid_credentials", "Invalid credentials")
+	ErrInvalidToken       = New("invalid_token", "Invalid token")
+	ErrUnauthorized       = New("unauthorized", "Unauthorized")
+	ErrForbidden          = New("forbidden", "Forbidden")
+	ErrBadRequest         = New("bad_request", "Bad request")
+	ErrInternalError      = New("internal_error", "Internal error")
+	ErrServiceUnavailable = New("service_unavailable", "Service unavailable")
+	ErrRequestTimeout     = New("request_timeout", "Request timed out")
+	ErrRequestCanceled    = New("request_canceled", "Request canceled")
+	ErrTooManyRequests    = New("too_many_requests", "Too many requests")
+	ErrValidationError    = New("validation_failed", "Validation error")
+	ErrXAuthKeyRequired   = New("x_auth_key_required", "X-Auth-Key required")
+	ErrXAuthKeyEmpty      = New("x_auth_key_empty", "X-Auth-Key empty")
+	ErrCodeRequired       = New("code_required", "Code required")
+	ErrNotAuthenticated   = New("not_authenticated", "Not authenticated")
+	ErrAuthRequired       = New("authentication_required", "Authentication required")
+	ErrInvalidCSRFToken   = New("invalid_csrf_token", "Invalid CSRF token")
+	ErrOAuthExchange      = New("oauth_exchange_failed", "OAuth code exchange failed")
+)
+
+// Request errors, reported before a request reaches a handler or when its
+// body cannot be read
+var (
+	ErrMalformedBody    = New("malformed_body", "Malformed request body")
+	ErrBodyTooLarge     = New("body_too_large", "Request body too large")
+	ErrRouteNotFound    = New("route_not_found", "Route not found")
+	ErrMethodNotAllowed = New("method_not_allowed", "Method not allowed")
+)
+
+// Generic errors, reported when a database error reaches a handler without
+// being mapped to one of the errors above
+var (
+	ErrResourceNotFound = New("resource_not_found", "Resource not found")
+	ErrConflict         = New("conflict", "Conflict with an existing resource")
+)
+
+// Idempotency errors
+var (
+	ErrInvalidIdempotencyKey = New("invalid_idempotency_key", "Invalid Idempotency-Key")
+	ErrIdempotencyKeyInUse   = New("idempotency_key_in_use", "A request with this Idempotency-Key is still being processed")
+	ErrIdempotencyKeyReused  = New("idempotency_key_reused", "Idempotency-Key was already used for a different request")
+)
+
+// Access token errors
+var (
+	ErrAccessTokenNotFound = New("access_token_not_found", "Access token not found")
+	ErrAccessTokenExpired  = New("access_token_expired", "Access token expired")
+	ErrAccessTokenRevoked  = New("access_token_revoked", "Access token revoked")
+	ErrInvalidScope        = New("invalid_scope", "Invalid scope")
+	ErrInsufficientScope   = New("insufficient_scope", "Insufficient scope")
+)
+
+// Account lifecycle errors
+var (
+	ErrReauthenticationFailed = New("reauthentication_failed", "Re-authentication failed")
+	ErrNoDeletionPending      = New("no_deletion_pending", "No account deletion pending")
+	ErrInvalidExportFormat    = New("invalid_export_format", "Invalid export format")
+)
+
+// Pagination errors
+var (
+	ErrInvalidLimit  = New("invalid_limit", "Invalid page size")
+	ErrInvalidCursor = New("invalid_cursor", "Invalid or expired cursor")
+	ErrInvalidSort   = New("invalid_sort", "Unsupported sort field")
+	ErrInvalidFilter = New("invalid_filter", "Unsupported or invalid filter")
 )
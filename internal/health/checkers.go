@@ -0,0 +1,113 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jixlox0/studoto-backend/internal/config"
+	"github.com/jixlox0/studoto-backend/internal/database"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// NewDefaultProber returns a prober checking every dependency the API needs
+// to serve traffic. Redis and the OAuth settings are checked but not critical:
+// every instance shares the same Redis, so taking instances out of rotation
+// when it is down would only turn a degraded service (rate limits in memory,
+// no idempotent replay, session tokens refused) into a complete outage, and
+// password sign-in keeps working without OAuth.
+func NewDefaultProber(db *gorm.DB, redisClient *redis.Client, oauthCfg config.OAuthConfig, cfg config.HealthConfig) *Prober {
+	prober := NewProber(
+		time.Duration(cfg.CheckTimeoutMs)*time.Millisecond,
+		time.Duration(cfg.CacheTTLSeconds)*time.Second,
+	)
+	prober.Register(&databaseChecker{db: db}, true)
+	prober.Register(&redisChecker{client: redisClient}, false)
+	prober.Register(&migrationsChecker{db: db}, true)
+	prober.Register(&oauthConfigChecker{cfg: oauthCfg}, false)
+	return prober
+}
+
+type databaseChecker struct {
+	db *gorm.DB
+}
+
+func (c *databaseChecker) Name() string {
+	return "database"
+}
+
+func (c *databaseChecker) Check(ctx context.Context) error {
+	sqlDB, err := c.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+type redisChecker struct {
+	client *redis.Client
+}
+
+func (c *redisChecker) Name() string {
+	return "redis"
+}
+
+func (c *redisChecker) Check(ctx context.Context) error {
+	return c.client.Ping(ctx).Err()
+}
+
+// migrationsChecker fails while the schema is behind the code, e.g. during a
+// rolling deploy before the new release has migrated.
+type migrationsChecker struct {
+	db *gorm.DB
+}
+
+func (c *migrationsChecker) Name() string {
+	return "migrations"
+}
+
+func (c *migrationsChecker) Check(ctx context.Context) error {
+	pending, err := database.PendingMigrations(ctx, c.db)
+	if err != nil {
+		return err
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%d pending migration(s): %s", len(pending), strings.Join(pending, ", "))
+	}
+	return nil
+}
+
+// oauthConfigChecker catches half-configured providers, which would only
+// surface as failed logins.
+type oauthConfigChecker struct {
+	cfg config.OAuthConfig
+}
+
+func (c *oauthConfigChecker) Name() string {
+	return "oauth_config"
+}
+
+func (c *oauthConfigChecker) Check(ctx context.Context) error {
+	var problems []string
+	if (c.cfg.Google.ClientID == "") != (c.cfg.Google.ClientSecret == "") {
+		problems = append(problems, "google client ID and secret must be set together")
+	}
+	if (c.cfg.GitHub.ClientID == "") != (c.cfg.GitHub.ClientSecret == "") {
+		problems = append(problems, "github client ID and secret must be set together")
+	}
+
+	providersEnabled := c.cfg.Google.ClientID != "" || c.cfg.GitHub.ClientID != ""
+	if providersEnabled {
+		if u, err := url.Parse(c.cfg.RedirectURL); err != nil || !u.IsAbs() {
+			problems = append(problems, "redirect URL must be an absolute URL")
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return nil
+}
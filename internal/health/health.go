@@ -0,0 +1,145 @@
+// Package health runs dependency checks for the readiness probe and
+// aggregates them into a report.
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Report and check statuses
+const (
+	StatusOK          = "ok"
+	StatusDegraded    = "degraded"
+	StatusUnavailable = "unavailable"
+)
+
+// Checker verifies that one dependency is usable.
+type Checker interface {
+	Name() string
+	Check(ctx context.Context) error
+}
+
+// CheckResult is the outcome of a single checker.
+type CheckResult struct {
+	Name       string    `json:"name"`
+	Status     string    `json:"status"`
+	Critical   bool      `json:"critical"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs int64     `json:"duration_ms"`
+	CheckedAt  time.Time `json:"checked_at"`
+}
+
+// Report is the aggregated result of all checkers. A failing critical check
+// makes the report unavailable; a failing non-critical one only degrades it.
+type Report struct {
+	Status    string        `json:"status"`
+	Checks    []CheckResult `json:"checks"`
+	CheckedAt time.Time     `json:"checked_at"`
+}
+
+// Healthy reports whether every critical check passed.
+func (r *Report) Healthy() bool {
+	return r.Status != StatusUnavailable
+}
+
+type registration struct {
+	checker  Checker
+	critical bool
+}
+
+// Prober runs the registered checkers concurrently, each bounded by its own
+// timeout, and caches the report so frequent probes do not hammer the
+// dependencies.
+type Prober struct {
+	timeout  time.Duration
+	cacheTTL time.Duration
+
+	mu       sync.Mutex
+	checkers []registration
+	cached   *Report
+}
+
+func NewProber(timeout, cacheTTL time.Duration) *Prober {
+	return &Prober{
+		timeout:  timeout,
+		cacheTTL: cacheTTL,
+	}
+}
+
+// Register adds a checker. Critical checkers take the service out of
+// rotation when they fail.
+func (p *Prober) Register(checker Checker, critical bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.checkers = append(p.checkers, registration{checker: checker, critical: critical})
+	p.cached = nil
+}
+
+// Check returns the cached report if it is still fresh and otherwise runs
+// every checker. Concurrent callers wait for a single run.
+func (p *Prober) Check(ctx context.Context) *Report {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.cached != nil && time.Since(p.cached.CheckedAt) < p.cacheTTL {
+		return p.cached
+	}
+
+	report := p.run(ctx)
+	p.cached = report
+	return report
+}
+
+func (p *Prober) run(ctx context.Context) *Report {
+	results := make([]CheckResult, len(p.checkers))
+
+	var wg sync.WaitGroup
+	for i, reg := range p.checkers {
+		wg.Add(1)
+		go func(i int, reg registration) {
+			defer wg.Done()
+			results[i] = p.runOne(ctx, reg)
+		}(i, reg)
+	}
+	wg.Wait()
+
+	report := &Report{
+		Status:    StatusOK,
+		Checks:    results,
+		CheckedAt: time.Now(),
+	}
+	for _, result := range results {
+		if result.Status == StatusOK {
+			continue
+		}
+		if result.Critical {
+			report.Status = StatusUnavailable
+		} else if report.Status == StatusOK {
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+func (p *Prober) runOne(ctx context.Context, reg registration) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := reg.checker.Check(ctx)
+
+	result := CheckResult{
+		Name:       reg.checker.Name(),
+		Status:     StatusOK,
+		Critical:   reg.critical,
+		DurationMs: time.Since(start).Milliseconds(),
+		CheckedAt:  start,
+	}
+	if err != nil {
+		result.Status = StatusUnavailable
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -0,0 +1,267 @@
+// Package i18n translates the messages the API shows to people: error
+// details, keyed by the stable codes in internal/errors, validation errors
+// and the emails rendered by internal/email. Catalogs are YAML files in
+// locales/, embedded in the binary.
+package i18n
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-playground/locales"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/fr"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	enTranslations "github.com/go-playground/validator/v10/translations/en"
+	frTranslations "github.com/go-playground/validator/v10/translations/fr"
+	apperrors "github.com/jixlox0/studoto-backend/internal/errors"
+	"golang.org/x/text/language"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultLocale is used when the client accepts none of the bundled locales,
+// and ends every fallback chain. Its catalog must contain every key.
+const DefaultLocale = "en"
+
+//go:embed locales/*.yaml
+var catalogFS embed.FS
+
+// validatorLocales are the locales the validator has translations for. A
+// catalog without an entry here still works; its validation errors use the
+// generic validation.invalid message.
+var validatorLocales = map[string]struct {
+	translator locales.Translator
+	register   func(*validator.Validate, ut.Translator) error
+}{
+	"en": {en.New(), enTranslations.RegisterDefaultTranslations},
+	"fr": {fr.New(), frTranslations.RegisterDefaultTranslations},
+}
+
+// Bundle holds the catalogs of every bundled locale.
+type Bundle struct {
+	locales     []string
+	catalogs    map[string]map[string]string
+	matcher     language.Matcher
+	translators map[string]ut.Translator
+}
+
+// NewBundle loads the embedded catalogs. It fails if the default catalog is
+// missing or another catalog has keys the default one lacks, which are
+// usually typos.
+func NewBundle() (*Bundle, error) {
+	return loadBundle(catalogFS, "locales")
+}
+
+func loadBundle(fsys fs.FS, dir string) (*Bundle, error) {
+	paths, err := fs.Glob(fsys, path.Join(dir, "*.yaml"))
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Bundle{
+		catalogs:    make(map[string]map[string]string),
+		translators: make(map[string]ut.Translator),
+	}
+	for _, p := range paths {
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return nil, err
+		}
+		var catalog map[string]string
+		if err := yaml.Unmarshal(data, &catalog); err != nil {
+			return nil, fmt.Errorf("%s: %w", p, err)
+		}
+		locale := strings.TrimSuffix(path.Base(p), ".yaml")
+		if _, err := language.Parse(locale); err != nil {
+			return nil, fmt.Errorf("%s: invalid locale: %w", p, err)
+		}
+		b.catalogs[locale] = catalog
+	}
+
+	defaults, ok := b.catalogs[DefaultLocale]
+	if !ok {
+		return nil, fmt.Errorf("no catalog for the default locale %q", DefaultLocale)
+	}
+	for locale, catalog := range b.catalogs {
+		for key := range catalog {
+			if _, ok := defaults[key]; !ok {
+				return nil, fmt.Errorf("%s catalog: key %q is not in the %s catalog", locale, key, DefaultLocale)
+			}
+		}
+	}
+
+	// The default locale comes first, so the matcher falls back to it
+	b.locales = append(b.locales, DefaultLocale)
+	for locale := range b.catalogs {
+		if locale != DefaultLocale {
+			b.locales = append(b.locales, locale)
+		}
+	}
+	sort.Strings(b.locales[1:])
+	tags := make([]language.Tag, len(b.locales))
+	for i, locale := range b.locales {
+		tags[i] = language.Make(locale)
+	}
+	b.matcher = language.NewMatcher(tags)
+
+	universal := ut.New(validatorLocales[DefaultLocale].translator)
+	for _, locale := range b.locales {
+		if vl, ok := validatorLocales[locale]; ok {
+			if err := universal.AddTranslator(vl.translator, true); err != nil {
+				return nil, err
+			}
+			b.translators[locale], _ = universal.GetTranslator(vl.translator.Locale())
+		}
+	}
+	return b, nil
+}
+
+// Locales returns the bundled locales, the default one first.
+func (b *Bundle) Locales() []string {
+	return append([]string(nil), b.locales...)
+}
+
+// Match returns the bundled locale that best fits an Accept-Language header,
+// or the default locale when none does. Regional variants match their
+// language, so "fr-CA" gets "fr".
+func (b *Bundle) Match(acceptLanguage string) string {
+	tags, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(tags) == 0 {
+		return DefaultLocale
+	}
+	_, index, confidence := b.matcher.Match(tags...)
+	if confidence == language.No {
+		return DefaultLocale
+	}
+	return b.locales[index]
+}
+
+// RegisterValidator adds the validation error translations of the bundled
+// locales to v.
+func (b *Bundle) RegisterValidator(v *validator.Validate) error {
+	for locale, trans := range b.translators {
+		if err := validatorLocales[locale].register(v, trans); err != nil {
+			return fmt.Errorf("%s validation translations: %w", locale, err)
+		}
+	}
+	return nil
+}
+
+// Localizer returns a Localizer for locale, which should be one returned by
+// Match.
+func (b *Bundle) Localizer(locale string) *Localizer {
+	// Parent locales are tried before the default, so a future "pt-BR"
+	// catalog only needs the keys that differ from "pt"
+	var chain []string
+	for tag := language.Make(locale); !tag.IsRoot(); tag = tag.Parent() {
+		if _, ok := b.catalogs[tag.String()]; ok && tag.String() != DefaultLocale {
+			chain = append(chain, tag.String())
+		}
+	}
+	chain = append(chain, DefaultLocale)
+	return &Localizer{bundle: b, locale: locale, chain: chain}
+}
+
+// Localizer translates messages into one locale, falling back to its parent
+// locales and then to the default locale for keys it has no translation for.
+type Localizer struct {
+	bundle *Bundle
+	locale string
+	chain  []string
+}
+
+// Locale returns the locale messages are translated into.
+func (l *Localizer) Locale() string {
+	return l.locale
+}
+
+// Message returns the message for key with every "{name}" replaced by
+// args[name], and false if no catalog in the chain has the key.
+func (l *Localizer) Message(key string, args map[string]string) (string, bool) {
+	for _, locale := range l.chain {
+		if message, ok := l.bundle.catalogs[locale][key]; ok {
+			if len(args) > 0 {
+				pairs := make([]string, 0, 2*len(args))
+				for name, value := range args {
+					pairs = append(pairs, "{"+name+"}", value)
+				}
+				message = strings.NewReplacer(pairs...).Replace(message)
+			}
+			return message, true
+		}
+	}
+	return "", false
+}
+
+// Date formats t as a long date in the locale, e.g. "January 2, 2026" or
+// "2 janvier 2026".
+func (l *Localizer) Date(t time.Time) string {
+	for _, locale := range l.chain {
+		if vl, ok := validatorLocales[locale]; ok {
+			return vl.translator.FmtDateLong(t)
+		}
+	}
+	return t.Format(time.DateOnly)
+}
+
+// Error returns the translated message for err, or its own English message
+// when no catalog has its code.
+func (l *Localizer) Error(err *apperrors.Error) string {
+	if message, ok := l.Message(err.Code, nil); ok {
+		return message
+	}
+	return err.Message
+}
+
+// FieldError returns the translated message for a failed validation rule.
+// Rules the validator has no translation for get the generic
+// validation.invalid message rather than the validator's own English text.
+func (l *Localizer) FieldError(fe validator.FieldError) string {
+	for _, locale := range l.chain {
+		trans, ok := l.bundle.translators[locale]
+		if !ok {
+			continue
+		}
+		if message := fe.Translate(trans); message != fe.Error() {
+			return message
+		}
+		break
+	}
+	message, _ := l.Message("validation.invalid", map[string]string{"field": fe.Field()})
+	return message
+}
+
+type localizerKey struct{}
+
+// WithLocalizer returns a copy of ctx carrying l.
+func WithLocalizer(ctx context.Context, l *Localizer) context.Context {
+	return context.WithValue(ctx, localizerKey{}, l)
+}
+
+// FromContext returns the Localizer carried by ctx, or one for the default
+// locale if there is none.
+func FromContext(ctx context.Context) *Localizer {
+	if l, ok := ctx.Value(localizerKey{}).(*Localizer); ok {
+		return l
+	}
+	return defaultLocalizer()
+}
+
+// defaultLocalizer serves requests that did not pass through the language
+// negotiation, e.g. in tests. The catalogs are embedded, so failing to load
+// them is a bug.
+var defaultLocalizer = sync.OnceValue(func() *Localizer {
+	b, err := NewBundle()
+	if err != nil {
+		panic(err)
+	}
+	return b.Localizer(DefaultLocale)
+})
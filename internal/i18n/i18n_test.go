@@ -0,0 +1,111 @@
+package i18n
+
+import (
+	"io/fs"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func readCatalog(t *testing.T, locale string) map[string]string {
+	t.Helper()
+	data, err := fs.ReadFile(catalogFS, "locales/"+locale+".yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var catalog map[string]string
+	if err := yaml.Unmarshal(data, &catalog); err != nil {
+		t.Fatal(err)
+	}
+	return catalog
+}
+
+func TestCatalogKeysAreInDefault(t *testing.T) {
+	defaults := readCatalog(t, DefaultLocale)
+	paths, err := fs.Glob(catalogFS, "locales/*.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range paths {
+		locale := strings.TrimSuffix(strings.TrimPrefix(p, "locales/"), ".yaml")
+		for key := range readCatalog(t, locale) {
+			if _, ok := defaults[key]; !ok {
+				t.Errorf("%s.yaml: key %q is not in %s.yaml", locale, key, DefaultLocale)
+			}
+		}
+	}
+}
+
+func TestLoadBundleRejectsUnknownKeys(t *testing.T) {
+	fsys := fstest.MapFS{
+		"locales/en.yaml": {Data: []byte("user_not_found: User not found\n")},
+		"locales/fr.yaml": {Data: []byte("user_not_fuond: Utilisateur introuvable\n")},
+	}
+	if _, err := loadBundle(fsys, "locales"); err == nil || !strings.Contains(err.Error(), "user_not_fuond") {
+		t.Fatalf("loadBundle() error = %v, want the unknown key reported", err)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	b, err := NewBundle()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		acceptLanguage string
+		want           string
+	}{
+		{"fr", "fr"},
+		{"fr-CA", "fr"},
+		{"de-DE, fr;q=0.8, en;q=0.5", "fr"},
+		{"en-GB", "en"},
+		{"de", DefaultLocale},
+		{"", DefaultLocale},
+		{"not a language;;", DefaultLocale},
+	}
+	for _, tt := range tests {
+		if got := b.Match(tt.acceptLanguage); got != tt.want {
+			t.Errorf("Match(%q) = %q, want %q", tt.acceptLanguage, got, tt.want)
+		}
+	}
+}
+
+func TestLocalizerFallsBackToDefault(t *testing.T) {
+	fsys := fstest.MapFS{
+		"locales/en.yaml": {Data: []byte("greeting: Hello {name}\nfarewell: Goodbye\n")},
+		"locales/fr.yaml": {Data: []byte("greeting: Bonjour {name}\n")},
+	}
+	b, err := loadBundle(fsys, "locales")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := b.Localizer(b.Match("fr-CA"))
+
+	if got, _ := l.Message("greeting", map[string]string{"name": "Ada"}); got != "Bonjour Ada" {
+		t.Errorf("Message(greeting) = %q, want the French message", got)
+	}
+	if got, _ := l.Message("farewell", nil); got != "Goodbye" {
+		t.Errorf("Message(farewell) = %q, want the English fallback", got)
+	}
+	if _, ok := l.Message("missing", nil); ok {
+		t.Error("Message(missing) found a message")
+	}
+}
+
+func TestDate(t *testing.T) {
+	b, err := NewBundle()
+	if err != nil {
+		t.Fatal(err)
+	}
+	date := time.Date(2026, time.November, 17, 12, 0, 0, 0, time.UTC)
+
+	for locale, want := range map[string]string{"en": "November 17, 2026", "fr": "17 novembre 2026"} {
+		if got := b.Localizer(locale).Date(date); got != want {
+			t.Errorf("%s: Date() = %q, want %q", locale, got, want)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+// Package idempotency stores the outcome of requests made with an
+// Idempotency-Key so that retries can be answered without repeating the work.
+package idempotency
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrLockLost is returned by Complete and Release when the lock expired and
+// the key was taken by another request in the meantime.
+var ErrLockLost = errors.New("idempotency lock lost")
+
+// Record is what is stored under a key: a lock while the first request is
+// being processed, then its response.
+type Record struct {
+	// Fingerprint identifies the request the key was first used with.
+	Fingerprint string      `json:"fingerprint"`
+	Completed   bool        `json:"completed"`
+	Status      int         `json:"status,omitempty"`
+	Header      http.Header `json:"header,omitempty"`
+	Body        []byte      `json:"body,omitempty"`
+	// Subject is stored instead of the response of a route whose responses
+	// carry credentials. It identifies what the first request created, such
+	// as the new account, and retries get fresh credentials for it.
+	Subject string `json:"subject,omitempty"`
+	// LockToken identifies the request holding the lock, so that a request
+	// whose lock expired cannot overwrite its successor's record.
+	LockToken string `json:"lock_token,omitempty"`
+}
+
+// Lock is held by the request that first used a key.
+type Lock struct {
+	Key   string
+	Token string
+}
+
+type Store interface {
+	// Acquire locks key for a new request with the given fingerprint. When
+	// the key is already in use it returns the existing record and a nil lock.
+	Acquire(ctx context.Context, key, fingerprint string, ttl time.Duration) (*Lock, *Record, error)
+	// Complete replaces the lock with the final response, kept for ttl.
+	Complete(ctx context.Context, lock *Lock, record *Record, ttl time.Duration) error
+	// Release drops the lock without storing a response, so that the request
+	// can be retried.
+	Release(ctx context.Context, lock *Lock) error
+	// Keys returns the keys in use within scope, without the scope.
+	Keys(ctx context.Context, scope string) ([]string, error)
+	// DeleteScope drops every lock and record within scope.
+	DeleteScope(ctx context.Context, scope string) error
+}
+
+// UserScope returns the scope of the keys sent by a user, which keeps them
+// apart from other callers' keys; keys are stored as scope + ":" + key.
+func UserScope(userID string) string {
+	return "user:" + userID
+}
+
+// compareAndSetScript replaces (or, with an empty value, deletes) the record
+// at KEYS[1] only while it is still locked by ARGV[1]
+var compareAndSetScript = redis.NewScript(`
+local current = redis.call('GET', KEYS[1])
+if not current then
+	return 0
+end
+local record = cjson.decode(current)
+if record.completed or record.lock_token ~= ARGV[1] then
+	return 0
+end
+if ARGV[2] == '' then
+	redis.call('DEL', KEYS[1])
+else
+	redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+end
+return 1
+`)
+
+type redisStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisStore creates a store keeping records in Redis.
+func NewRedisStore(client *redis.Client) Store {
+	return &redisStore{
+		client: client,
+		prefix: "idempotency:",
+	}
+}
+
+func (s *redisStore) Acquire(ctx context.Context, key, fingerprint string, ttl time.Duration) (*Lock, *Record, error) {
+	token, err := newLockToken()
+	if err != nil {
+		return nil, nil, err
+	}
+	value, err := json.Marshal(&Record{Fingerprint: fingerprint, LockToken: token})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode idempotency record: %w", err)
+	}
+
+	// The existing record can expire between SET NX and GET, in which case
+	// the key is free again and the lock is retried once
+	for attempt := 0; attempt < 2; attempt++ {
+		acquired, err := s.client.SetNX(ctx, s.prefix+key, value, ttl).Result()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to lock idempotency key: %w", err)
+		}
+		if acquired {
+			return &Lock{Key: key, Token: token}, nil, nil
+		}
+
+		existing, err := s.client.Get(ctx, s.prefix+key).Bytes()
+		if err == redis.Nil {
+			continue
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get idempotency record: %w", err)
+		}
+
+		var record Record
+		if err := json.Unmarshal(existing, &record); err != nil {
+			return nil, nil, fmt.Errorf("failed to decode idempotency record: %w", err)
+		}
+		return nil, &record, nil
+	}
+	return nil, nil, fmt.Errorf("failed to lock idempotency key: key churned during lookup")
+}
+
+func (s *redisStore) Complete(ctx context.Context, lock *Lock, record *Record, ttl time.Duration) error {
+	record.Completed = true
+	record.LockToken = ""
+	value, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode idempotency record: %w", err)
+	}
+	return s.compareAndSet(ctx, lock, string(value), ttl)
+}
+
+func (s *redisStore) Release(ctx context.Context, lock *Lock) error {
+	return s.compareAndSet(ctx, lock, "", 0)
+}
+
+func (s *redisStore) compareAndSet(ctx context.Context, lock *Lock, value string, ttl time.Duration) error {
+	swapped, err := compareAndSetScript.Run(ctx, s.client, []string{s.prefix + lock.Key},
+		lock.Token, value, ttl.Milliseconds()).Int()
+	if err != nil {
+		return fmt.Errorf("failed to update idempotency record: %w", err)
+	}
+	if swapped == 0 {
+		return ErrLockLost
+	}
+	return nil
+}
+
+func (s *redisStore) Keys(ctx context.Context, scope string) ([]string, error) {
+	var keys []string
+	err := s.scan(ctx, scope, func(key string) error {
+		keys = append(keys, strings.TrimPrefix(key, s.prefix+scope+":"))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(keys)
+	return keys, nil
+}
+
+func (s *redisStore) DeleteScope(ctx context.Context, scope string) error {
+	return s.scan(ctx, scope, func(key string) error {
+		if err := s.client.Del(ctx, key).Err(); err != nil {
+			return fmt.Errorf("failed to delete idempotency record: %w", err)
+		}
+		return nil
+	})
+}
+
+// scan calls fn with the Redis key of every record within scope. Scopes are
+// built from public IDs, which contain no glob characters.
+func (s *redisStore) scan(ctx context.Context, scope string, fn func(key string) error) error {
+	iter := s.client.Scan(ctx, 0, s.prefix+scope+":*", 100).Iterator()
+	for iter.Next(ctx) {
+		if err := fn(iter.Val()); err != nil {
+			return err
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return fmt.Errorf("failed to list idempotency records: %w", err)
+	}
+	return nil
+}
+
+func newLockToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate lock token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
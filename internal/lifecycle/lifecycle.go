@@ -0,0 +1,74 @@
+// Package lifecycle coordinates process startup and shutdown: a readiness
+// flag that tells load balancers whether to send traffic, and an ordered
+// list of hooks that release resources on shutdown.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+)
+
+// Readiness reports whether the process should receive traffic. It starts
+// out not ready, is set once startup completes and is cleared again before
+// the server begins draining.
+type Readiness struct {
+	ready atomic.Bool
+}
+
+func NewReadiness() *Readiness {
+	return &Readiness{}
+}
+
+func (r *Readiness) SetReady(ready bool) {
+	r.ready.Store(ready)
+}
+
+func (r *Readiness) Ready() bool {
+	return r.ready.Load()
+}
+
+type hook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// Manager runs shutdown hooks in the order they were registered, so
+// dependents should be registered before what they depend on (e.g. workers
+// before the database they use).
+type Manager struct {
+	mu    sync.Mutex
+	hooks []hook
+}
+
+func NewManager() *Manager {
+	return &Manager{}
+}
+
+// OnShutdown registers fn to run during Shutdown.
+func (m *Manager) OnShutdown(name string, fn func(ctx context.Context) error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = append(m.hooks, hook{name: name, fn: fn})
+}
+
+// Shutdown runs every hook in registration order. A failing hook does not
+// prevent later ones from running; all failures are returned together.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	hooks := m.hooks
+	m.hooks = nil
+	m.mu.Unlock()
+
+	var errs []error
+	for _, h := range hooks {
+		slog.Info("Shutting down", "component", h.name)
+		if err := h.fn(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", h.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,136 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// GormLogger adapts GORM's logger to slog. Queries are logged through the
+// request-scoped logger so they carry the request ID; failed queries are
+// logged at error, queries slower than the threshold at warn and everything
+// else at debug. Bound parameters, and the values Postgres quotes in its
+// errors, are left out of the logs unless explicitly enabled, since they
+// include password hashes and personal data.
+type GormLogger struct {
+	logger        *slog.Logger
+	slowThreshold time.Duration
+	logParams     bool
+	silent        bool
+}
+
+func NewGormLogger(logger *slog.Logger, slowThreshold time.Duration, logParams bool) *GormLogger {
+	return &GormLogger{
+		logger:        logger,
+		slowThreshold: slowThreshold,
+		logParams:     logParams,
+	}
+}
+
+// LogMode only distinguishes silent from not silent; the slog level decides
+// what is written.
+func (l *GormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	clone := *l
+	clone.silent = level == gormlogger.Silent
+	return &clone
+}
+
+func (l *GormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	l.log(ctx, slog.LevelInfo, msg, args...)
+}
+
+func (l *GormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	l.log(ctx, slog.LevelWarn, msg, args...)
+}
+
+func (l *GormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	l.log(ctx, slog.LevelError, msg, args...)
+}
+
+func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if l.silent {
+		return
+	}
+
+	logger := l.scoped(ctx)
+	elapsed := time.Since(begin)
+
+	var level slog.Level
+	var msg string
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		level, msg = slog.LevelError, "Query failed"
+	case l.slowThreshold > 0 && elapsed > l.slowThreshold:
+		level, msg = slog.LevelWarn, "Slow query"
+	default:
+		level, msg = slog.LevelDebug, "Query"
+	}
+
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+
+	sql, rows := fc()
+	attrs := []slog.Attr{
+		slog.String("sql", sql),
+		slog.Int64("rows", rows),
+		slog.Float64("duration_ms", float64(elapsed.Microseconds())/1000),
+	}
+	if err != nil && level == slog.LevelError {
+		attrs = append(attrs, l.errorAttrs(err)...)
+	}
+	logger.LogAttrs(ctx, level, msg, attrs...)
+}
+
+// errorAttrs describes a failed query. Postgres puts the offending values in
+// its messages (e.g. "Key (email)=(...) already exists"), so unless
+// parameters are logged only the error code and constraint are kept.
+func (l *GormLogger) errorAttrs(err error) []slog.Attr {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return []slog.Attr{slog.String("error", err.Error())}
+	}
+
+	attrs := []slog.Attr{slog.String("error_code", pgErr.Code)}
+	if pgErr.ConstraintName != "" {
+		attrs = append(attrs, slog.String("constraint", pgErr.ConstraintName))
+	}
+	if l.logParams {
+		attrs = append(attrs, slog.String("error", err.Error()))
+		if pgErr.Detail != "" {
+			attrs = append(attrs, slog.String("error_detail", pgErr.Detail))
+		}
+	}
+	return attrs
+}
+
+// ParamsFilter is called by GORM before the SQL is rendered for logging.
+// Dropping the parameters leaves the placeholders in the logged statement.
+func (l *GormLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	if l.logParams {
+		return sql, params
+	}
+	return sql, nil
+}
+
+func (l *GormLogger) log(ctx context.Context, level slog.Level, msg string, args ...interface{}) {
+	if l.silent {
+		return
+	}
+	l.scoped(ctx).Log(ctx, level, fmt.Sprintf(msg, args...))
+}
+
+func (l *GormLogger) scoped(ctx context.Context) *slog.Logger {
+	if ctx != nil {
+		if logger, ok := ctx.Value(contextKey{}).(*slog.Logger); ok {
+			return logger
+		}
+	}
+	return l.logger
+}
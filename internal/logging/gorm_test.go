@@ -0,0 +1,52 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestGormLoggerHidesErrorValues(t *testing.T) {
+	pgErr := &pgconn.PgError{
+		Severity:       "ERROR",
+		Code:           "23505",
+		Message:        `duplicate key value violates unique constraint "idx_users_email"`,
+		Detail:         "Key (email)=(ada@example.com) already exists.",
+		ConstraintName: "idx_users_email",
+	}
+	err := fmt.Errorf("create user: %w", pgErr)
+	query := func() (string, int64) { return "INSERT INTO users (email) VALUES ($1)", 0 }
+
+	tests := []struct {
+		name       string
+		logParams  bool
+		wantDetail bool
+	}{
+		{name: "without parameters", logParams: false},
+		{name: "with parameters", logParams: true, wantDetail: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			l := NewGormLogger(slog.New(slog.NewJSONHandler(&buf, nil)), time.Second, tt.logParams)
+
+			l.Trace(context.Background(), time.Now(), query, err)
+
+			out := buf.String()
+			for _, want := range []string{`"error_code":"23505"`, `"constraint":"idx_users_email"`} {
+				if !strings.Contains(out, want) {
+					t.Errorf("log %s does not contain %s", out, want)
+				}
+			}
+			if got := strings.Contains(out, "ada@example.com"); got != tt.wantDetail {
+				t.Errorf("log %s contains the email: %v, want %v", out, got, tt.wantDetail)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+// Package metrics exposes Prometheus metrics for HTTP traffic, the database
+// and Redis connection pools, and authentication events.
+package metrics
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+const namespace = "studoto"
+
+// Metrics owns the Prometheus registry and the application's collectors.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests     *prometheus.CounterVec
+	httpDuration     *prometheus.HistogramVec
+	httpInFlight     prometheus.Gauge
+	httpDeprecated   *prometheus.CounterVec
+	signups          *prometheus.CounterVec
+	signinFailures   *prometheus.CounterVec
+	oauthLogins      *prometheus.CounterVec
+	tokenCacheLookup *prometheus.CounterVec
+	rateLimited      *prometheus.CounterVec
+}
+
+// NewMetrics registers the HTTP and authentication metrics together with
+// collectors for the Go runtime, the process, and the database and Redis
+// connection pools.
+func NewMetrics(db *gorm.DB, redisClient *redis.Client) (*Metrics, error) {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "HTTP requests handled, by method, route and status code.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "HTTP request latency, by method and route.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		httpInFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_in_flight",
+			Help:      "HTTP requests currently being served.",
+		}),
+		httpDeprecated: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "deprecated_requests_total",
+			Help:      "Requests to deprecated routes, by method and route.",
+		}, []string{"method", "route"}),
+		signups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "auth",
+			Name:      "signups_total",
+			Help:      "Accounts created, by method (password or OAuth provider).",
+		}, []string{"method"}),
+		signinFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "auth",
+			Name:      "signin_failures_total",
+			Help:      "Failed password sign-ins, by reason.",
+		}, []string{"reason"}),
+		oauthLogins: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "auth",
+			Name:      "oauth_logins_total",
+			Help:      "Successful OAuth logins, by provider.",
+		}, []string{"provider"}),
+		tokenCacheLookup: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "auth",
+			Name:      "token_cache_lookups_total",
+			Help:      "JWT cache lookups during token validation, by result (hit or miss).",
+		}, []string{"result"}),
+		rateLimited: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "ratelimit",
+			Name:      "rejections_total",
+			Help:      "Requests rejected by a rate limit policy, by policy.",
+		}, []string{"policy"}),
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database instance: %w", err)
+	}
+
+	cs := []prometheus.Collector{
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		collectors.NewDBStatsCollector(sqlDB, db.Name()),
+		newRedisPoolCollector(redisClient),
+		m.httpRequests,
+		m.httpDuration,
+		m.httpInFlight,
+		m.httpDeprecated,
+		m.signups,
+		m.signinFailures,
+		m.oauthLogins,
+		m.tokenCacheLookup,
+		m.rateLimited,
+	}
+	for _, c := range cs {
+		if err := m.registry.Register(c); err != nil {
+			return nil, fmt.Errorf("failed to register metrics collector: %w", err)
+		}
+	}
+
+	return m, nil
+}
+
+// Handler serves the registry in the Prometheus exposition format. When token
+// is set, scrapes must send it as "Authorization: Bearer <token>".
+func (m *Metrics) Handler(token string) http.Handler {
+	handler := promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+	if token == "" {
+		return handler
+	}
+	expected := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="metrics"`)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// RequestStarted and RequestFinished track one HTTP request. route is the
+// matched route pattern, never the raw path, to keep label cardinality bounded.
+func (m *Metrics) RequestStarted() {
+	m.httpInFlight.Inc()
+}
+
+func (m *Metrics) RequestFinished(method, route string, status int, elapsed time.Duration) {
+	m.httpInFlight.Dec()
+	m.httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	m.httpDuration.WithLabelValues(method, route).Observe(elapsed.Seconds())
+}
+
+// DeprecatedRouteUsed counts a request to a deprecated route, so that the
+// clients still calling it can be chased before it is removed.
+func (m *Metrics) DeprecatedRouteUsed(method, route string) {
+	m.httpDeprecated.WithLabelValues(method, route).Inc()
+}
+
+// SignupCompleted counts a new account; method is "password" or the OAuth provider.
+func (m *Metrics) SignupCompleted(method string) {
+	m.signups.WithLabelValues(method).Inc()
+}
+
+func (m *Metrics) SigninFailed(reason string) {
+	m.signinFailures.WithLabelValues(reason).Inc()
+}
+
+func (m *Metrics) OAuthLogin(provider string) {
+	m.oauthLogins.WithLabelValues(provider).Inc()
+}
+
+func (m *Metrics) RateLimited(policy string) {
+	m.rateLimited.WithLabelValues(policy).Inc()
+}
+
+// ObserveTokenCache implements auth.CacheObserver.
+func (m *Metrics) ObserveTokenCache(hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	m.tokenCacheLookup.WithLabelValues(result).Inc()
+}
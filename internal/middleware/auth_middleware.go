@@ -1,66 +1,229 @@
 package middleware
 
 import (
+	stderrors "errors"
+	"fmt"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jixlox0/studoto-backend/internal/errors"
+	"github.com/jixlox0/studoto-backend/internal/models"
+	"github.com/jixlox0/studoto-backend/internal/service"
 	"github.com/jixlox0/studoto-backend/pkg/auth"
 )
 
+// Authentication methods recorded in Principal.Method
+const (
+	AuthMethodJWT         = "jwt"
+	AuthMethodAccessToken = "access_token"
+)
+
 type AuthMiddleware struct {
-	jwtAuth *auth.JWTAuth
+	jwtAuth            *auth.JWTAuth
+	accessTokenService service.AccessTokenService
+	userService        service.UserService
+	sessions           *SessionCookies
 }
 
-func NewAuthMiddleware(jwtAuth *auth.JWTAuth) *AuthMiddleware {
-	return &AuthMiddleware{jwtAuth: jwtAuth}
+func NewAuthMiddleware(jwtAuth *auth.JWTAuth, accessTokenService service.AccessTokenService, userService service.UserService, sessions *SessionCookies) *AuthMiddleware {
+	return &AuthMiddleware{
+		jwtAuth:            jwtAuth,
+		accessTokenService: accessTokenService,
+		userService:        userService,
+		sessions:           sessions,
+	}
 }
 
 func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		// Check for authentication token in headers
-		// Support both X-Auth-Key and x-auth-token headers
-		authKey := c.GetHeader("X-Auth-Key")
-		if authKey == "" {
-			authKey = c.GetHeader("x-auth-token")
-		}
-		if authKey == "" {
-			authKey = c.GetHeader("X-Auth-Token")
-		}
-		
-		if authKey == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": errors.ErrXAuthKeyRequired.Error(),
-			})
-			c.Abort()
+		// Credentials are looked up in order of precedence:
+		// Authorization: Bearer, then X-Auth-Key / x-auth-token / X-Auth-Token,
+		// then the session cookie when cookie mode is enabled
+		authKey, present := bearerToken(c)
+		if !present {
+			authKey, present = authKeyHeader(c)
+		}
+
+		fromCookie := false
+		if !present {
+			if authKey = m.sessions.token(c); authKey != "" {
+				present = true
+				fromCookie = true
+			}
+		}
+
+		if !present {
+			AbortWithError(c, errors.ErrAuthRequired)
 			return
 		}
 
 		// Remove any whitespace
 		token := strings.TrimSpace(authKey)
 		if token == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": errors.ErrXAuthKeyEmpty.Error(),
-			})
-			c.Abort()
+			AbortWithError(c, errors.ErrXAuthKeyEmpty)
+			return
+		}
+
+		// Cookies are sent automatically by the browser, so state-changing
+		// requests must prove they can read the CSRF cookie
+		if fromCookie && !m.sessions.verifyCSRF(c) {
+			AbortWithError(c, errors.ErrInvalidCSRFToken)
+			return
+		}
+
+		// Personal access tokens are distinguished by their prefix
+		if strings.HasPrefix(token, models.AccessTokenPrefix) {
+			m.authenticateAccessToken(c, token)
 			return
 		}
 
 		// Validate the token
 		claims, err := m.jwtAuth.ValidateToken(c.Request.Context(), token)
+		if stderrors.Is(err, auth.ErrRevocationUnavailable) {
+			// The token may have been revoked, so it is not accepted, but
+			// the client is not told that it is invalid either
+			AbortWithError(c, fmt.Errorf("%w: %w", errors.ErrServiceUnavailable, err))
+			return
+		}
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": errors.ErrInvalidToken.Error(),
-			})
-			c.Abort()
+			AbortWithError(c, errors.ErrInvalidToken)
 			return
 		}
 
+		userID := claims.Subject
+		if claims.IsLegacy() {
+			// Tokens issued before the switch to public IDs carry the
+			// numeric database ID; resolve it until they have all expired
+			user, err := m.userService.GetUserByID(c.Request.Context(), claims.UserID)
+			if abortUnavailable(c, err) {
+				return
+			}
+			if err != nil {
+				AbortWithError(c, errors.ErrInvalidToken)
+				return
+			}
+			userID = user.ID
+		}
+
 		// Set user info in context
-		c.Set("user_id", claims.UserID)
-		c.Set("user_email", claims.Email)
+		setPrincipal(c, &Principal{
+			UserID: userID,
+			Email:  claims.Email,
+			Method: AuthMethodJWT,
+			Token:  token,
+		})
+
+		c.Next()
+	}
+}
+
+// StartSession sets the session cookies for token when cookie mode is enabled.
+// It reports whether the session is carried in a cookie.
+func (m *AuthMiddleware) StartSession(c *gin.Context, token string) (bool, error) {
+	if !m.sessions.Enabled() {
+		return false, nil
+	}
+	if err := m.sessions.Start(c, token, m.jwtAuth.TokenTTL()); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// EndSession clears the session cookies when cookie mode is enabled.
+func (m *AuthMiddleware) EndSession(c *gin.Context) {
+	if m.sessions.Enabled() {
+		m.sessions.Clear(c)
+	}
+}
+
+// RequireScope restricts a route to sessions that may act with the given scope.
+// Interactive JWT sessions carry full account access; personal access tokens
+// must have been granted the scope explicitly.
+func (m *AuthMiddleware) RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := CurrentUser(c)
+		if !ok {
+			AbortWithError(c, errors.ErrNotAuthenticated)
+			return
+		}
+
+		if !principal.HasScope(scope) {
+			AbortWithError(c, errors.ErrInsufficientScope)
+			return
+		}
 
 		c.Next()
 	}
 }
+
+// RequireRole restricts a route to users holding the given role. The role is
+// read from the database on every request so that demotions take effect
+// immediately rather than when the session token expires.
+func (m *AuthMiddleware) RequireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := CurrentUser(c)
+		if !ok {
+			AbortWithError(c, errors.ErrNotAuthenticated)
+			return
+		}
+
+		user, err := m.userService.GetUserByUUID(c.Request.Context(), principal.UserID)
+		if abortUnavailable(c, err) {
+			return
+		}
+		if err != nil || user.Role != role {
+			AbortWithError(c, errors.ErrForbidden)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func (m *AuthMiddleware) authenticateAccessToken(c *gin.Context, plaintext string) {
+	token, err := m.accessTokenService.Authenticate(c.Request.Context(), plaintext)
+	if abortUnavailable(c, err) {
+		return
+	}
+	if err != nil {
+		// Only the token's own problems are worth telling the client
+		if ErrorStatus(err) != http.StatusUnauthorized {
+			err = fmt.Errorf("%w: %w", errors.ErrInvalidToken, err)
+		}
+		AbortWithError(c, err)
+		return
+	}
+
+	// Set user info in context
+	setPrincipal(c, &Principal{
+		UserID: token.User.UUID,
+		Email:  token.User.Email,
+		Method: AuthMethodAccessToken,
+		Scopes: token.ScopeList(),
+	})
+
+	c.Next()
+}
+
+// bearerToken returns the credential from an "Authorization: Bearer" header
+// and whether such a header was present.
+func bearerToken(c *gin.Context) (string, bool) {
+	header := c.GetHeader("Authorization")
+	if len(header) < 7 || !strings.EqualFold(header[:7], "Bearer ") {
+		return "", false
+	}
+	return header[7:], true
+}
+
+// authKeyHeader returns the credential from the X-Auth-Key header or one of
+// its legacy spellings and whether any of them was present.
+func authKeyHeader(c *gin.Context) (string, bool) {
+	// Support both X-Auth-Key and x-auth-token headers
+	for _, name := range []string{"X-Auth-Key", "x-auth-token", "X-Auth-Token"} {
+		if value := c.GetHeader(name); value != "" {
+			return value, true
+		}
+	}
+	return "", false
+}
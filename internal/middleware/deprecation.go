@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jixlox0/studoto-backend/internal/metrics"
+)
+
+// Headers announcing a deprecated route (RFC 9745 and RFC 8594)
+const (
+	DeprecationHeader = "Deprecation"
+	SunsetHeader      = "Sunset"
+)
+
+// deprecatedRouteKey marks requests to routes under Deprecation
+const deprecatedRouteKey = "deprecated_route"
+
+// Deprecation marks every route of a group as deprecated in favour of the
+// same route under successorPrefix, e.g. /v1. Responses carry a Deprecation
+// header dated deprecatedAt, a Sunset header when sunset is set, and a Link
+// to the successor; each request is counted so that remaining callers can be
+// found before the routes are removed.
+func Deprecation(m *metrics.Metrics, deprecatedAt, sunset time.Time, successorPrefix string) gin.HandlerFunc {
+	deprecation := "@" + strconv.FormatInt(deprecatedAt.Unix(), 10)
+	var sunsetDate string
+	if !sunset.IsZero() {
+		sunsetDate = sunset.UTC().Format(http.TimeFormat)
+	}
+
+	return func(c *gin.Context) {
+		c.Header(DeprecationHeader, deprecation)
+		if sunsetDate != "" {
+			c.Header(SunsetHeader, sunsetDate)
+		}
+		c.Writer.Header().Add("Link", "<"+successorPrefix+c.Request.URL.Path+`>; rel="successor-version"`)
+		m.DeprecatedRouteUsed(c.Request.Method, c.FullPath())
+		c.Set(deprecatedRouteKey, true)
+
+		c.Next()
+	}
+}
+
+// DeprecatedRoute reports whether the request came in on a deprecated route,
+// for handlers that keep an older response shape there.
+func DeprecatedRoute(c *gin.Context) bool {
+	return c.GetBool(deprecatedRouteKey)
+}
+
+// unversionedRoute strips a leading version segment such as /v1 from a route
+// pattern, so that a versioned route and its legacy alias are treated as one
+// route, e.g. by rate limit policies.
+func unversionedRoute(route string) string {
+	rest, ok := strings.CutPrefix(route, "/v")
+	if !ok {
+		return route
+	}
+	version, tail, _ := strings.Cut(rest, "/")
+	if version == "" || strings.Trim(version, "0123456789") != "" {
+		return route
+	}
+	return "/" + tail
+}
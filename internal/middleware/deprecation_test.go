@@ -0,0 +1,26 @@
+package middleware
+
+import "testing"
+
+func TestUnversionedRoute(t *testing.T) {
+	tests := []struct {
+		route string
+		want  string
+	}{
+		{"/v1/x", "/x"},
+		{"/v10/x", "/x"},
+		{"/v1/auth/signin", "/auth/signin"},
+		{"/vx/x", "/vx/x"},
+		{"/v/x", "/v/x"},
+		{"/v", "/v"},
+		{"/v1", "/"},
+		{"/auth/signin", "/auth/signin"},
+		{"/videos/v1", "/videos/v1"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := unversionedRoute(tt.route); got != tt.want {
+			t.Errorf("unversionedRoute(%q) = %q, want %q", tt.route, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package middleware
+
+import "github.com/gin-gonic/gin"
+
+const detailedErrorsKey = "detailed_errors"
+
+// ErrorDetails records whether error responses may include the underlying
+// error, which is only appropriate outside production.
+func ErrorDetails(enabled bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(detailedErrorsKey, enabled)
+		c.Next()
+	}
+}
+
+// DetailedErrors reports whether ErrorDetails enabled error details for the
+// request.
+func DetailedErrors(c *gin.Context) bool {
+	return c.GetBool(detailedErrorsKey)
+}
@@ -0,0 +1,116 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	apperrors "github.com/jixlox0/studoto-backend/internal/errors"
+	"github.com/jixlox0/studoto-backend/internal/repository"
+)
+
+// errorStatuses maps the errors services and middleware return to the status
+// they are reported with. Errors are matched with errors.Is, so wrapped
+// errors map like the ones they wrap.
+var errorStatuses = []struct {
+	err    error
+	status int
+}{
+	{repository.ErrNotFound, http.StatusNotFound},
+	{apperrors.ErrResourceNotFound, http.StatusNotFound},
+	{apperrors.ErrUserNotFound, http.StatusNotFound},
+	{apperrors.ErrAccessTokenNotFound, http.StatusNotFound},
+	{apperrors.ErrRouteNotFound, http.StatusNotFound},
+
+	{apperrors.ErrMethodNotAllowed, http.StatusMethodNotAllowed},
+
+	{repository.ErrConflict, http.StatusConflict},
+	{apperrors.ErrConflict, http.StatusConflict},
+	{apperrors.ErrUserAlreadyExists, http.StatusConflict},
+	{apperrors.ErrNoDeletionPending, http.StatusConflict},
+	{apperrors.ErrIdempotencyKeyInUse, http.StatusConflict},
+
+	{apperrors.ErrIdempotencyKeyReused, http.StatusUnprocessableEntity},
+
+	{apperrors.ErrBodyTooLarge, http.StatusRequestEntityTooLarge},
+
+	{apperrors.ErrBadRequest, http.StatusBadRequest},
+	{apperrors.ErrMalformedBody, http.StatusBadRequest},
+	{apperrors.ErrValidationError, http.StatusBadRequest},
+	{apperrors.ErrCodeRequired, http.StatusBadRequest},
+	{apperrors.ErrOAuthExchange, http.StatusBadRequest},
+	{apperrors.ErrInvalidPassword, http.StatusBadRequest},
+	{apperrors.ErrInvalidProvider, http.StatusBadRequest},
+	{apperrors.ErrInvalidScope, http.StatusBadRequest},
+	{apperrors.ErrInvalidExportFormat, http.StatusBadRequest},
+	{apperrors.ErrInvalidIdempotencyKey, http.StatusBadRequest},
+	{apperrors.ErrInvalidLimit, http.StatusBadRequest},
+	{apperrors.ErrInvalidCursor, http.StatusBadRequest},
+	{apperrors.ErrInvalidSort, http.StatusBadRequest},
+	{apperrors.ErrInvalidFilter, http.StatusBadRequest},
+
+	{apperrors.ErrUnauthorized, http.StatusUnauthorized},
+	{apperrors.ErrNotAuthenticated, http.StatusUnauthorized},
+	{apperrors.ErrAuthRequired, http.StatusUnauthorized},
+	{apperrors.ErrXAuthKeyRequired, http.StatusUnauthorized},
+	{apperrors.ErrXAuthKeyEmpty, http.StatusUnauthorized},
+	{apperrors.ErrInvalidCredentials, http.StatusUnauthorized},
+	{apperrors.ErrInvalidToken, http.StatusUnauthorized},
+	{apperrors.ErrAccessTokenExpired, http.StatusUnauthorized},
+	{apperrors.ErrAccessTokenRevoked, http.StatusUnauthorized},
+
+	{apperrors.ErrForbidden, http.StatusForbidden},
+	{apperrors.ErrInsufficientScope, http.StatusForbidden},
+	{apperrors.ErrReauthenticationFailed, http.StatusForbidden},
+	{apperrors.ErrInvalidCSRFToken, http.StatusForbidden},
+
+	{apperrors.ErrTooManyRequests, http.StatusTooManyRequests},
+	{apperrors.ErrRequestCanceled, StatusClientClosedRequest},
+
+	{repository.ErrUnavailable, http.StatusServiceUnavailable},
+	{apperrors.ErrServiceUnavailable, http.StatusServiceUnavailable},
+	{apperrors.ErrRequestTimeout, http.StatusServiceUnavailable},
+}
+
+// ErrorStatus returns the HTTP status for an error returned by a service:
+// 404 for missing records, 409 for conflicts, 503 when the database is
+// unavailable and so on. Unknown errors are 500.
+func ErrorStatus(err error) int {
+	for _, entry := range errorStatuses {
+		if errors.Is(err, entry.err) {
+			return entry.status
+		}
+	}
+	return http.StatusInternalServerError
+}
+
+// publicError returns the error to show the client for err. Repository errors
+// are replaced by generic ones, since their messages can name tables and
+// constraints, and errors the client has no code for become internal errors.
+func publicError(err error) *apperrors.Error {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return apperrors.ErrResourceNotFound
+	case errors.Is(err, repository.ErrConflict):
+		return apperrors.ErrConflict
+	case errors.Is(err, repository.ErrUnavailable):
+		return apperrors.ErrServiceUnavailable
+	}
+
+	var appErr *apperrors.Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	return apperrors.ErrInternalError
+}
+
+// abortUnavailable responds with 503 and returns true when err reports that
+// the database is unavailable, so that an outage is not reported to the
+// client as a failed authentication.
+func abortUnavailable(c *gin.Context, err error) bool {
+	if !errors.Is(err, repository.ErrUnavailable) {
+		return false
+	}
+	AbortWithError(c, err)
+	return true
+}
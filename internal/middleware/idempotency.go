@@ -0,0 +1,275 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	stderrors "errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jixlox0/studoto-backend/internal/config"
+	"github.com/jixlox0/studoto-backend/internal/errors"
+	"github.com/jixlox0/studoto-backend/internal/idempotency"
+	"github.com/jixlox0/studoto-backend/internal/logging"
+)
+
+const (
+	// IdempotencyKeyHeader carries the client-chosen key, ideally a UUID
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is set on responses served from a stored result
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+const maxIdempotencyKeyLength = 255
+
+// maxIdempotentBodySize bounds the bodies read into memory to fingerprint
+// requests carrying a key
+const maxIdempotentBodySize = 1 << 20
+
+// storeTimeout bounds the Redis writes made after the handler has run, which
+// use a context detached from the (possibly expired) request
+const storeTimeout = 2 * time.Second
+
+const (
+	// noStoreKey marks requests whose response must not be stored
+	noStoreKey = "idempotency_no_store"
+	// subjectKey holds the subject stored in place of a response
+	subjectKey = "idempotency_subject"
+	// replayedSubjectKey holds the stored subject on a retry
+	replayedSubjectKey = "idempotency_replayed_subject"
+)
+
+// replayedHeaders are the response headers stored with a result. Headers set
+// by other middleware, such as the request ID and rate limit state, describe
+// the retry rather than the original request and are not replayed. Cookies
+// are never stored, since they may carry a session.
+var replayedHeaders = []string{"Content-Type", "Content-Disposition", "Location"}
+
+// Idempotency makes POST, PUT, PATCH and DELETE requests carrying an
+// Idempotency-Key safe to retry. The first request with a key is processed
+// and its response stored; retries with the same body get the stored response,
+// retries with a different body are rejected with 422, and retries arriving
+// while the first is still running get 409. Keys are scoped to the
+// authenticated user, so the handler must run after RequireAuth where there is
+// one. Server errors are not stored, so that they can be retried, and neither
+// are the responses of routes marked with NoStoredResponse. Handlers whose
+// responses carry credentials call RebuildOnReplay instead, so that retries
+// are answered with fresh credentials rather than stored ones.
+type Idempotency struct {
+	store   idempotency.Store
+	enabled bool
+	ttl     time.Duration
+	lockTTL time.Duration
+}
+
+func NewIdempotency(store idempotency.Store, cfg config.IdempotencyConfig) *Idempotency {
+	return &Idempotency{
+		store:   store,
+		enabled: cfg.Enabled,
+		ttl:     time.Duration(cfg.TTLHours) * time.Hour,
+		lockTTL: time.Duration(cfg.LockTimeoutSeconds) * time.Second,
+	}
+}
+
+func (i *Idempotency) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if !i.enabled || key == "" || !mutatingMethod(c.Request.Method) {
+			c.Next()
+			return
+		}
+		if !validIdempotencyKey(key) {
+			AbortWithError(c, errors.ErrInvalidIdempotencyKey)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxIdempotentBodySize))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if stderrors.As(err, &tooLarge) {
+				AbortWithError(c, errors.ErrBodyTooLarge)
+				return
+			}
+			AbortWithError(c, errors.ErrBadRequest)
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := c.Request.Context()
+		logger := logging.FromContext(ctx)
+		storeKey := idempotencyScope(c) + ":" + key
+		fingerprint := requestFingerprint(c, body)
+
+		lock, existing, err := i.store.Acquire(ctx, storeKey, fingerprint, i.lockTTL)
+		if err != nil {
+			// Without Redis the request is processed as if no key was sent
+			logger.Warn("Idempotency store unavailable", slog.Any("error", err))
+			c.Next()
+			return
+		}
+
+		if existing != nil {
+			switch {
+			case existing.Fingerprint != fingerprint:
+				AbortWithError(c, errors.ErrIdempotencyKeyReused)
+			case !existing.Completed:
+				AbortWithError(c, errors.ErrIdempotencyKeyInUse)
+			case existing.Subject != "":
+				// The handler runs again and rebuilds the response
+				c.Set(replayedSubjectKey, existing.Subject)
+				c.Header(IdempotentReplayedHeader, "true")
+				c.Next()
+			default:
+				replay(c, existing)
+				c.Abort()
+			}
+			return
+		}
+
+		writer := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		// The lock must not outlive a panicking handler, or retries would get
+		// 409 until it expires
+		completed := false
+		defer func() {
+			if !completed {
+				i.release(ctx, logger, lock)
+			}
+		}()
+
+		c.Next()
+
+		status := writer.Status()
+		if ctx.Err() != nil || status >= http.StatusInternalServerError || status == http.StatusTooManyRequests || c.GetBool(noStoreKey) {
+			return
+		}
+
+		record := &idempotency.Record{
+			Fingerprint: fingerprint,
+			Status:      status,
+		}
+		if subject := c.GetString(subjectKey); subject != "" {
+			record.Subject = subject
+		} else {
+			record.Header = make(http.Header)
+			record.Body = writer.body.Bytes()
+			for _, name := range replayedHeaders {
+				if values := writer.Header().Values(name); len(values) > 0 {
+					record.Header[name] = values
+				}
+			}
+		}
+
+		storeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), storeTimeout)
+		defer cancel()
+		if err := i.store.Complete(storeCtx, lock, record, i.ttl); err != nil {
+			logger.Warn("Failed to store idempotent response", slog.Any("error", err))
+			return
+		}
+		completed = true
+	}
+}
+
+// NoStoredResponse marks a route whose responses carry credentials, such as
+// session tokens or a new access token, which must not sit in Redis. Requests
+// to it still take the key's lock, so a retry sent while the first request
+// runs gets 409, but a later retry is processed again.
+func NoStoredResponse() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(noStoreKey, true)
+		c.Next()
+	}
+}
+
+// RebuildOnReplay stores subject, which identifies what the request created,
+// in place of the response. A retry with the same key and body runs the
+// handler again with ReplayedSubject returning subject, so that it can answer
+// with fresh credentials instead of repeating the work.
+func RebuildOnReplay(c *gin.Context, subject string) {
+	c.Set(subjectKey, subject)
+}
+
+// ReplayedSubject returns the subject stored by RebuildOnReplay when the
+// request is a retry of one already processed.
+func ReplayedSubject(c *gin.Context) (string, bool) {
+	subject := c.GetString(replayedSubjectKey)
+	return subject, subject != ""
+}
+
+func (i *Idempotency) release(ctx context.Context, logger *slog.Logger, lock *idempotency.Lock) {
+	releaseCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), storeTimeout)
+	defer cancel()
+	if err := i.store.Release(releaseCtx, lock); err != nil {
+		logger.Warn("Failed to release idempotency key", slog.Any("error", err))
+	}
+}
+
+func replay(c *gin.Context, record *idempotency.Record) {
+	for name, values := range record.Header {
+		for _, value := range values {
+			c.Writer.Header().Add(name, value)
+		}
+	}
+	c.Header(IdempotentReplayedHeader, "true")
+	c.Writer.WriteHeader(record.Status)
+	_, _ = c.Writer.Write(record.Body)
+}
+
+// idempotencyScope keeps keys of different callers apart. Anonymous requests
+// share one scope; a retry must still match the original body, which for
+// sign-up includes the password.
+func idempotencyScope(c *gin.Context) string {
+	if principal, ok := CurrentUser(c); ok {
+		return idempotency.UserScope(principal.UserID)
+	}
+	return "anonymous"
+}
+
+func requestFingerprint(c *gin.Context, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(c.Request.Method + " " + c.Request.URL.RequestURI() + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func mutatingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func validIdempotencyKey(key string) bool {
+	if len(key) > maxIdempotencyKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x21 || key[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// recordingWriter keeps a copy of the response body.
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
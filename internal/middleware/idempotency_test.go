@@ -0,0 +1,329 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jixlox0/studoto-backend/internal/config"
+	"github.com/jixlox0/studoto-backend/internal/idempotency"
+)
+
+// memoryStore is an in-memory idempotency.Store with the semantics of the
+// Redis store: Complete and Release only apply while the caller's lock token
+// still holds the key.
+type memoryStore struct {
+	mu      sync.Mutex
+	records map[string]*idempotency.Record
+	tokens  int
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{records: make(map[string]*idempotency.Record)}
+}
+
+func (s *memoryStore) Acquire(ctx context.Context, key, fingerprint string, ttl time.Duration) (*idempotency.Lock, *idempotency.Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.records[key]; ok {
+		record := *existing
+		return nil, &record, nil
+	}
+	s.tokens++
+	token := strconv.Itoa(s.tokens)
+	s.records[key] = &idempotency.Record{Fingerprint: fingerprint, LockToken: token}
+	return &idempotency.Lock{Key: key, Token: token}, nil, nil
+}
+
+func (s *memoryStore) Complete(ctx context.Context, lock *idempotency.Lock, record *idempotency.Record, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.holds(lock) {
+		return idempotency.ErrLockLost
+	}
+	record.Completed = true
+	record.LockToken = ""
+	s.records[lock.Key] = record
+	return nil
+}
+
+func (s *memoryStore) Release(ctx context.Context, lock *idempotency.Lock) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.holds(lock) {
+		return idempotency.ErrLockLost
+	}
+	delete(s.records, lock.Key)
+	return nil
+}
+
+func (s *memoryStore) holds(lock *idempotency.Lock) bool {
+	current, ok := s.records[lock.Key]
+	return ok && !current.Completed && current.LockToken == lock.Token
+}
+
+func (s *memoryStore) Keys(ctx context.Context, scope string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var keys []string
+	for key := range s.records {
+		if rest, ok := strings.CutPrefix(key, scope+":"); ok {
+			keys = append(keys, rest)
+		}
+	}
+	return keys, nil
+}
+
+func (s *memoryStore) DeleteScope(ctx context.Context, scope string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key := range s.records {
+		if strings.HasPrefix(key, scope+":") {
+			delete(s.records, key)
+		}
+	}
+	return nil
+}
+
+// expire drops a key, as when its lock times out in Redis
+func (s *memoryStore) expire(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+}
+
+func (s *memoryStore) record(key string) *idempotency.Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.records[key]
+}
+
+func newIdempotentRouter(store idempotency.Store) *gin.Engine {
+	i := NewIdempotency(store, config.IdempotencyConfig{Enabled: true, TTLHours: 24, LockTimeoutSeconds: 30})
+	r := gin.New()
+	r.Use(gin.RecoveryWithWriter(io.Discard), i.Handler())
+	return r
+}
+
+func idempotentRequest(r http.Handler, path, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set(IdempotencyKeyHeader, key)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyReplaysStoredResponse(t *testing.T) {
+	r := newIdempotentRouter(newMemoryStore())
+	calls := 0
+	r.POST("/courses", func(c *gin.Context) {
+		calls++
+		c.Header("Location", "/courses/crs-1")
+		c.SetCookie("session", "secret", 60, "/", "", true, true)
+		c.JSON(http.StatusCreated, gin.H{"id": "crs-1"})
+	})
+
+	first := idempotentRequest(r, "/courses", "key-1", `{"name":"Go"}`)
+	retry := idempotentRequest(r, "/courses", "key-1", `{"name":"Go"}`)
+
+	if calls != 1 {
+		t.Fatalf("handler called %d times, want 1", calls)
+	}
+	if retry.Code != first.Code || retry.Body.String() != first.Body.String() {
+		t.Fatalf("retry = %d %s, want %d %s", retry.Code, retry.Body, first.Code, first.Body)
+	}
+	if retry.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Errorf("%s not set on the replay", IdempotentReplayedHeader)
+	}
+	if got := retry.Header().Get("Location"); got != "/courses/crs-1" {
+		t.Errorf("Location = %q, want it replayed", got)
+	}
+	if got := retry.Header().Get("Set-Cookie"); got != "" {
+		t.Errorf("Set-Cookie = %q, want cookies never replayed", got)
+	}
+}
+
+func TestIdempotencyRebuildsResponseForSubject(t *testing.T) {
+	store := newMemoryStore()
+	r := newIdempotentRouter(store)
+	var replayed []string
+	r.POST("/auth/signup", func(c *gin.Context) {
+		subject, ok := ReplayedSubject(c)
+		if ok {
+			replayed = append(replayed, subject)
+		}
+		RebuildOnReplay(c, "usr-1")
+		c.JSON(http.StatusCreated, gin.H{"token": "token-" + strconv.Itoa(len(replayed))})
+	})
+
+	idempotentRequest(r, "/auth/signup", "key-1", `{"email":"ada@example.com"}`)
+	record := store.record("anonymous:key-1")
+	if record == nil || record.Subject != "usr-1" || len(record.Body) != 0 {
+		t.Fatalf("record = %+v, want the subject stored without the response", record)
+	}
+
+	retry := idempotentRequest(r, "/auth/signup", "key-1", `{"email":"ada@example.com"}`)
+	if len(replayed) != 1 || replayed[0] != "usr-1" {
+		t.Fatalf("replayed subjects = %v, want [usr-1]", replayed)
+	}
+	if retry.Code != http.StatusCreated || !strings.Contains(retry.Body.String(), "token-1") {
+		t.Fatalf("retry = %d %s, want a fresh response", retry.Code, retry.Body)
+	}
+	if retry.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Errorf("%s not set on the replay", IdempotentReplayedHeader)
+	}
+}
+
+func TestIdempotencyRejectsReusedKey(t *testing.T) {
+	r := newIdempotentRouter(newMemoryStore())
+	r.POST("/courses", func(c *gin.Context) { c.Status(http.StatusCreated) })
+
+	idempotentRequest(r, "/courses", "key-1", `{"name":"Go"}`)
+	if w := idempotentRequest(r, "/courses", "key-1", `{"name":"Rust"}`); w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("different body: status = %d, want 422", w.Code)
+	}
+}
+
+func TestIdempotencyRejectsLargeBody(t *testing.T) {
+	r := newIdempotentRouter(newMemoryStore())
+	r.POST("/courses", func(c *gin.Context) { c.Status(http.StatusCreated) })
+
+	body := `{"name":"` + strings.Repeat("a", maxIdempotentBodySize) + `"}`
+	if w := idempotentRequest(r, "/courses", "key-1", body); w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("large body: status = %d, want 413", w.Code)
+	}
+}
+
+func TestIdempotencyRejectsConcurrentRetry(t *testing.T) {
+	r := newIdempotentRouter(newMemoryStore())
+	var retry *httptest.ResponseRecorder
+	r.POST("/courses", func(c *gin.Context) {
+		if retry == nil {
+			// The retry arrives while the first request is still running
+			retry = idempotentRequest(r, "/courses", "key-1", `{"name":"Go"}`)
+		}
+		c.Status(http.StatusCreated)
+	})
+
+	if w := idempotentRequest(r, "/courses", "key-1", `{"name":"Go"}`); w.Code != http.StatusCreated {
+		t.Fatalf("first request: status = %d, want 201", w.Code)
+	}
+	if retry.Code != http.StatusConflict {
+		t.Fatalf("concurrent retry: status = %d, want 409", retry.Code)
+	}
+}
+
+func TestIdempotencyReleasesKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		noStore bool
+		handler func(calls int) gin.HandlerFunc
+	}{
+		{name: "server error", handler: func(calls int) gin.HandlerFunc {
+			return func(c *gin.Context) {
+				if calls == 1 {
+					c.Status(http.StatusServiceUnavailable)
+					return
+				}
+				c.Status(http.StatusCreated)
+			}
+		}},
+		{name: "panic", handler: func(calls int) gin.HandlerFunc {
+			return func(c *gin.Context) {
+				if calls == 1 {
+					panic("boom")
+				}
+				c.Status(http.StatusCreated)
+			}
+		}},
+		{name: "no stored response", noStore: true, handler: func(calls int) gin.HandlerFunc {
+			return func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"token": "secret"}) }
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMemoryStore()
+			r := newIdempotentRouter(store)
+			calls := 0
+			chain := []gin.HandlerFunc{func(c *gin.Context) {
+				calls++
+				tt.handler(calls)(c)
+			}}
+			if tt.noStore {
+				chain = append([]gin.HandlerFunc{NoStoredResponse()}, chain...)
+			}
+			r.POST("/courses", chain...)
+
+			idempotentRequest(r, "/courses", "key-1", `{}`)
+			if record := store.record("anonymous:key-1"); record != nil {
+				t.Fatalf("key still held after the first request: %+v", record)
+			}
+			if w := idempotentRequest(r, "/courses", "key-1", `{}`); w.Header().Get(IdempotentReplayedHeader) != "" {
+				t.Fatal("retry was replayed")
+			}
+			if calls != 2 {
+				t.Fatalf("handler called %d times, want the retry processed again", calls)
+			}
+		})
+	}
+}
+
+func TestIdempotencyKeepsSuccessorRecordWhenLockLost(t *testing.T) {
+	store := newMemoryStore()
+	r := newIdempotentRouter(store)
+	var successor *idempotency.Lock
+	r.POST("/courses", func(c *gin.Context) {
+		// The lock times out while the handler runs and a retry takes the key
+		store.expire("anonymous:key-1")
+		lock, _, err := store.Acquire(c, "anonymous:key-1", "retry", time.Minute)
+		if err != nil || lock == nil {
+			t.Fatalf("Acquire() = %v, %v", lock, err)
+		}
+		successor = lock
+		c.Status(http.StatusCreated)
+	})
+
+	idempotentRequest(r, "/courses", "key-1", `{}`)
+
+	record := store.record("anonymous:key-1")
+	if record == nil || record.Completed || record.LockToken != successor.Token {
+		t.Fatalf("record = %+v, want the successor's lock left in place", record)
+	}
+}
+
+func TestIdempotencyScopesKeysByUser(t *testing.T) {
+	store := newMemoryStore()
+	i := NewIdempotency(store, config.IdempotencyConfig{Enabled: true, TTLHours: 24, LockTimeoutSeconds: 30})
+	r := gin.New()
+	// Stands in for RequireAuth, which runs first
+	r.Use(func(c *gin.Context) {
+		setPrincipal(c, &Principal{UserID: c.GetHeader("X-User")})
+	}, i.Handler())
+	calls := 0
+	r.POST("/courses", func(c *gin.Context) {
+		calls++
+		c.Status(http.StatusCreated)
+	})
+
+	for _, user := range []string{"usr-1", "usr-2"} {
+		req := httptest.NewRequest(http.MethodPost, "/courses", strings.NewReader(`{}`))
+		req.Header.Set(IdempotencyKeyHeader, "key-1")
+		req.Header.Set("X-User", user)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	if calls != 2 {
+		t.Fatalf("handler called %d times, want once per user", calls)
+	}
+	for _, user := range []string{"usr-1", "usr-2"} {
+		if keys, _ := store.Keys(context.Background(), idempotency.UserScope(user)); len(keys) != 1 || keys[0] != "key-1" {
+			t.Errorf("%s keys = %v, want [key-1]", user, keys)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/jixlox0/studoto-backend/internal/i18n"
+)
+
+// Language picks the bundled locale that best fits the request's
+// Accept-Language header and stores a Localizer for it on the request
+// context, where error responses and services find it. The chosen locale is
+// reported in Content-Language.
+func Language(bundle *i18n.Bundle) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		locale := bundle.Match(c.GetHeader("Accept-Language"))
+		ctx := i18n.WithLocalizer(c.Request.Context(), bundle.Localizer(locale))
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Header("Content-Language", locale)
+		c.Writer.Header().Add("Vary", "Accept-Language")
+
+		c.Next()
+	}
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jixlox0/studoto-backend/internal/metrics"
+)
+
+// unmatchedRoute labels requests that did not match any route, so that
+// scanners probing random paths cannot blow up label cardinality
+const unmatchedRoute = "unmatched"
+
+// Metrics records request counts, latency and in-flight requests per route.
+func Metrics(m *metrics.Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		m.RequestStarted()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		m.RequestFinished(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jixlox0/studoto-backend/internal/logging"
+)
+
+const principalKey = "principal"
+
+// Principal describes the authenticated caller of a request.
+type Principal struct {
+	// UserID is the user's public identifier ("usr-...").
+	UserID string
+	Email  string
+	// Method is AuthMethodJWT or AuthMethodAccessToken.
+	Method string
+	// Scopes lists the scopes granted to an access token; it is empty for
+	// interactive sessions, which have full account access.
+	Scopes []string
+	// Token is the session token for interactive sessions, used to sign out.
+	Token string
+}
+
+// HasScope reports whether the principal may act with the given scope.
+func (p *Principal) HasScope(scope string) bool {
+	if p.Method != AuthMethodAccessToken {
+		return true
+	}
+	for _, granted := range p.Scopes {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// CurrentUser returns the principal stored by RequireAuth.
+func CurrentUser(c *gin.Context) (*Principal, bool) {
+	value, exists := c.Get(principalKey)
+	if !exists {
+		return nil, false
+	}
+	principal, ok := value.(*Principal)
+	return principal, ok
+}
+
+func setPrincipal(c *gin.Context, principal *Principal) {
+	c.Set(principalKey, principal)
+
+	// Tag the rest of the request's log lines with the caller
+	ctx := c.Request.Context()
+	logger := logging.FromContext(ctx).With(slog.String("user_id", principal.UserID))
+	c.Request = c.Request.WithContext(logging.WithLogger(ctx, logger))
+}
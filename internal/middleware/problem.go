@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jixlox0/studoto-backend/internal/i18n"
+	"github.com/jixlox0/studoto-backend/internal/models"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ProblemContentType is the media type of error responses (RFC 7807)
+const ProblemContentType = "application/problem+json"
+
+// problemType is the problem type of every error response. The type is left
+// generic and errors are told apart by the code extension member instead, so
+// clients need not resolve URIs.
+const problemType = "about:blank"
+
+// ErrorHandler renders the last error recorded with c.Error as problem
+// details when the handlers wrote no response of their own. It should come
+// after RequestID and Timeout, so that its responses carry the request ID and
+// are replaced once the request context is done.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if c.Writer.Written() || len(c.Errors) == 0 {
+			return
+		}
+		writeProblem(c, c.Errors.Last().Err)
+	}
+}
+
+// AbortWithError records err on the request, so that it is logged, stops the
+// remaining handlers and responds with its problem details. The status and
+// code come from err; errors without a code are reported as a generic 500.
+func AbortWithError(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+	writeProblem(c, err)
+}
+
+func writeProblem(c *gin.Context, err error) {
+	problem := NewProblem(c, err)
+	c.Header("Content-Type", ProblemContentType)
+	c.JSON(problem.Status, problem)
+}
+
+// NewProblem returns the problem details reported for err on the request,
+// with the detail and field errors in the request's language. The underlying
+// error of a 5xx response is only included, untranslated, when detailed
+// errors are enabled.
+func NewProblem(c *gin.Context, err error) *models.Problem {
+	status := ErrorStatus(err)
+	public := publicError(err)
+	localizer := i18n.FromContext(c.Request.Context())
+	detail := localizer.Error(public)
+
+	title := http.StatusText(status)
+	if title == "" {
+		title = detail
+	}
+	problem := &models.Problem{
+		Type:      problemType,
+		Title:     title,
+		Status:    status,
+		Detail:    detail,
+		Instance:  c.Request.URL.Path,
+		Code:      public.Code,
+		RequestID: GetRequestID(c),
+		Errors:    fieldErrors(localizer, err),
+	}
+	if sc := trace.SpanContextFromContext(c.Request.Context()); sc.IsValid() {
+		problem.TraceID = sc.TraceID().String()
+	}
+	if status >= http.StatusInternalServerError && DetailedErrors(c) && err != error(public) {
+		problem.Detail += ": " + err.Error()
+	}
+	return problem
+}
@@ -0,0 +1,175 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jixlox0/studoto-backend/internal/config"
+	"github.com/jixlox0/studoto-backend/internal/errors"
+	"github.com/jixlox0/studoto-backend/internal/logging"
+	"github.com/jixlox0/studoto-backend/internal/metrics"
+	"github.com/jixlox0/studoto-backend/internal/ratelimit"
+)
+
+// Values accepted in RateLimitPolicy.KeyBy
+const (
+	RateLimitKeyIP     = "ip"
+	RateLimitKeyUser   = "user"
+	RateLimitKeyAPIKey = "api_key"
+)
+
+// Response headers, following the IETF RateLimit header fields draft
+const (
+	RateLimitLimitHeader     = "RateLimit-Limit"
+	RateLimitRemainingHeader = "RateLimit-Remaining"
+	RateLimitResetHeader     = "RateLimit-Reset"
+	RateLimitPolicyHeader    = "RateLimit-Policy"
+)
+
+type rateLimitRule struct {
+	policy ratelimit.Policy
+	keyBy  string
+}
+
+// RateLimiter enforces the configured per-route policies. Its handler must
+// be installed on each route group, after RequireAuth where there is one, so
+// that routes are matched and user-keyed policies see the principal. Routes
+// are matched without their version prefix, so a policy for /auth/signin
+// also covers /v1/auth/signin and both count against the same limit.
+type RateLimiter struct {
+	limiter ratelimit.Limiter
+	rules   map[string][]rateLimitRule
+	enabled bool
+	metrics *metrics.Metrics
+}
+
+func NewRateLimiter(limiter ratelimit.Limiter, cfg config.RateLimitConfig, m *metrics.Metrics) (*RateLimiter, error) {
+	rules := make(map[string][]rateLimitRule)
+	for _, p := range cfg.Policies {
+		rule := rateLimitRule{
+			policy: ratelimit.Policy{
+				Name:      p.Name,
+				Algorithm: p.Algorithm,
+				Limit:     p.Limit,
+				Burst:     p.Burst,
+				Window:    p.Window,
+			},
+			keyBy: p.KeyBy,
+		}
+		if err := ratelimit.ValidatePolicy(rule.policy); err != nil {
+			return nil, err
+		}
+		switch rule.keyBy {
+		case RateLimitKeyIP, RateLimitKeyUser, RateLimitKeyAPIKey:
+		default:
+			return nil, fmt.Errorf("rate limit policy %q: unknown key %q", p.Name, p.KeyBy)
+		}
+
+		route := p.Method + " " + unversionedRoute(p.Route)
+		rules[route] = append(rules[route], rule)
+	}
+
+	return &RateLimiter{
+		limiter: limiter,
+		rules:   rules,
+		enabled: cfg.Enabled,
+		metrics: m,
+	}, nil
+}
+
+// Handler applies every policy configured for the matched route. When several
+// apply, the headers describe the one closest to being exhausted.
+func (r *RateLimiter) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rules := r.rules[c.Request.Method+" "+unversionedRoute(c.FullPath())]
+		if !r.enabled || len(rules) == 0 {
+			c.Next()
+			return
+		}
+
+		ctx := c.Request.Context()
+		var (
+			tightest *ratelimit.Result
+			policies []string
+		)
+		for _, rule := range rules {
+			result, err := r.limiter.Allow(ctx, rule.policy, rateLimitKey(c, rule.keyBy))
+			if err != nil {
+				// Fail open: throttling is not worth rejecting legitimate traffic
+				logging.FromContext(ctx).Warn("Rate limit check failed",
+					slog.String("policy", rule.policy.Name),
+					slog.Any("error", err),
+				)
+				continue
+			}
+
+			policies = append(policies, fmt.Sprintf("%d;w=%d", result.Limit, ceilSeconds(rule.policy.Window)))
+			if !result.Allowed {
+				r.metrics.RateLimited(rule.policy.Name)
+			}
+			if tightest == nil || tighter(result, tightest) {
+				tightest = result
+			}
+		}
+		if tightest == nil {
+			c.Next()
+			return
+		}
+
+		c.Header(RateLimitLimitHeader, strconv.Itoa(tightest.Limit))
+		c.Header(RateLimitRemainingHeader, strconv.Itoa(tightest.Remaining))
+		c.Header(RateLimitResetHeader, strconv.Itoa(ceilSeconds(tightest.ResetAfter)))
+		c.Header(RateLimitPolicyHeader, strings.Join(policies, ", "))
+
+		if !tightest.Allowed {
+			c.Header("Retry-After", strconv.Itoa(ceilSeconds(tightest.RetryAfter)))
+			AbortWithError(c, errors.ErrTooManyRequests)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// tighter orders results by whether they rejected the request, then by how
+// much quota is left.
+func tighter(a, b *ratelimit.Result) bool {
+	if a.Allowed != b.Allowed {
+		return !a.Allowed
+	}
+	if !a.Allowed {
+		return a.RetryAfter > b.RetryAfter
+	}
+	return a.Remaining < b.Remaining
+}
+
+func rateLimitKey(c *gin.Context, keyBy string) string {
+	switch keyBy {
+	case RateLimitKeyUser:
+		if principal, ok := CurrentUser(c); ok {
+			return "user:" + principal.UserID
+		}
+	case RateLimitKeyAPIKey:
+		credential, present := bearerToken(c)
+		if !present {
+			credential, present = authKeyHeader(c)
+		}
+		if credential = strings.TrimSpace(credential); present && credential != "" {
+			// Credentials are hashed so that they never reach Redis
+			sum := sha256.Sum256([]byte(credential))
+			return "key:" + hex.EncodeToString(sum[:16])
+		}
+	}
+	return "ip:" + c.ClientIP()
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,168 @@
+package middleware
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jixlox0/studoto-backend/internal/config"
+	"github.com/jixlox0/studoto-backend/internal/metrics"
+	"github.com/jixlox0/studoto-backend/internal/ratelimit"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// newTestMetrics returns metrics backed by a database and Redis client that
+// are never connected to.
+func newTestMetrics(t *testing.T) *metrics.Metrics {
+	t.Helper()
+	db, err := gorm.Open(postgres.Open("host=127.0.0.1 port=1"), &gorm.Config{DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1"})
+	t.Cleanup(func() { client.Close() })
+	m, err := metrics.NewMetrics(db, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+type failingLimiter struct{}
+
+func (failingLimiter) Allow(ctx context.Context, policy ratelimit.Policy, key string) (*ratelimit.Result, error) {
+	return nil, stderrors.New("connection refused")
+}
+
+func newRateLimitedRouter(t *testing.T, limiter ratelimit.Limiter, cfg config.RateLimitConfig) *gin.Engine {
+	t.Helper()
+	rl, err := NewRateLimiter(limiter, cfg, newTestMetrics(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := gin.New()
+	ok := func(c *gin.Context) { c.Status(http.StatusNoContent) }
+	r.POST("/auth/signin", rl.Handler(), ok)
+	r.POST("/v1/auth/signin", rl.Handler(), ok)
+	r.POST("/auth/signup", rl.Handler(), ok)
+	return r
+}
+
+func signinPolicies() config.RateLimitConfig {
+	return config.RateLimitConfig{
+		Enabled: true,
+		Policies: []config.RateLimitPolicy{
+			{Name: "signin", Method: http.MethodPost, Route: "/auth/signin", Limit: 2, Window: time.Minute,
+				KeyBy: RateLimitKeyIP, Algorithm: ratelimit.AlgorithmSlidingWindow},
+			{Name: "signin_hourly", Method: http.MethodPost, Route: "/auth/signin", Limit: 5, Window: time.Hour,
+				KeyBy: RateLimitKeyIP, Algorithm: ratelimit.AlgorithmSlidingWindow},
+		},
+	}
+}
+
+func TestRateLimiterHeaders(t *testing.T) {
+	r := newRateLimitedRouter(t, ratelimit.NewMemoryLimiter(), signinPolicies())
+
+	tests := []struct {
+		path          string
+		wantStatus    int
+		wantRemaining string
+		wantRetry     string
+	}{
+		{path: "/auth/signin", wantStatus: http.StatusNoContent, wantRemaining: "1"},
+		// Versioned routes count against the same limit
+		{path: "/v1/auth/signin", wantStatus: http.StatusNoContent, wantRemaining: "0"},
+		{path: "/auth/signin", wantStatus: http.StatusTooManyRequests, wantRemaining: "0", wantRetry: "60"},
+	}
+	for i, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tt.path, nil))
+
+		if w.Code != tt.wantStatus {
+			t.Fatalf("request %d: status = %d, want %d", i, w.Code, tt.wantStatus)
+		}
+		if ct := w.Header().Get("Content-Type"); tt.wantStatus == http.StatusTooManyRequests && ct != ProblemContentType {
+			t.Errorf("request %d: Content-Type = %q, want %q", i, ct, ProblemContentType)
+		}
+		// The per-minute policy is the tighter one throughout
+		for header, want := range map[string]string{
+			RateLimitLimitHeader:     "2",
+			RateLimitRemainingHeader: tt.wantRemaining,
+			RateLimitResetHeader:     "60",
+			RateLimitPolicyHeader:    "2;w=60, 5;w=3600",
+			"Retry-After":            tt.wantRetry,
+		} {
+			if got := w.Header().Get(header); got != want {
+				t.Errorf("request %d: %s = %q, want %q", i, header, got, want)
+			}
+		}
+	}
+}
+
+func TestRateLimiterPassesThrough(t *testing.T) {
+	disabled := signinPolicies()
+	disabled.Enabled = false
+
+	tests := []struct {
+		name    string
+		limiter ratelimit.Limiter
+		cfg     config.RateLimitConfig
+		path    string
+	}{
+		{name: "disabled", limiter: ratelimit.NewMemoryLimiter(), cfg: disabled, path: "/auth/signin"},
+		{name: "route without policy", limiter: ratelimit.NewMemoryLimiter(), cfg: signinPolicies(), path: "/auth/signup"},
+		{name: "limiter failing", limiter: failingLimiter{}, cfg: signinPolicies(), path: "/auth/signin"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newRateLimitedRouter(t, tt.limiter, tt.cfg)
+			for range 3 {
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, tt.path, nil))
+				if w.Code != http.StatusNoContent {
+					t.Fatalf("status = %d, want %d", w.Code, http.StatusNoContent)
+				}
+				if got := w.Header().Get(RateLimitLimitHeader); got != "" {
+					t.Fatalf("%s = %q, want no header", RateLimitLimitHeader, got)
+				}
+			}
+		})
+	}
+}
+
+func TestTighter(t *testing.T) {
+	allowed := func(remaining int) *ratelimit.Result {
+		return &ratelimit.Result{Allowed: true, Remaining: remaining}
+	}
+	rejected := func(retry time.Duration) *ratelimit.Result {
+		return &ratelimit.Result{RetryAfter: retry}
+	}
+
+	tests := []struct {
+		name string
+		a, b *ratelimit.Result
+		want bool
+	}{
+		{"rejected over allowed", rejected(time.Second), allowed(0), true},
+		{"allowed under rejected", allowed(0), rejected(time.Second), false},
+		{"longer wait", rejected(time.Minute), rejected(time.Second), true},
+		{"shorter wait", rejected(time.Second), rejected(time.Minute), false},
+		{"less remaining", allowed(1), allowed(5), true},
+		{"more remaining", allowed(5), allowed(1), false},
+	}
+	for _, tt := range tests {
+		if got := tighter(tt.a, tt.b); got != tt.want {
+			t.Errorf("%s: tighter() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
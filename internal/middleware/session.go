@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jixlox0/studoto-backend/internal/config"
+)
+
+// SessionCookies issues and clears the cookies used in cookie session mode:
+// an HttpOnly cookie carrying the JWT and a script-readable CSRF cookie whose
+// value must be echoed in a header on state-changing requests.
+type SessionCookies struct {
+	cfg config.SessionConfig
+}
+
+func NewSessionCookies(cfg config.SessionConfig) *SessionCookies {
+	return &SessionCookies{cfg: cfg}
+}
+
+// Enabled reports whether cookie session mode is turned on.
+func (s *SessionCookies) Enabled() bool {
+	return s.cfg.CookieMode
+}
+
+// Start writes the session and CSRF cookies for token. The CSRF token is also
+// returned in the response header so clients need not parse cookies.
+func (s *SessionCookies) Start(c *gin.Context, token string, ttl time.Duration) error {
+	csrfToken, err := generateCSRFToken()
+	if err != nil {
+		return err
+	}
+
+	maxAge := int(ttl.Seconds())
+	s.setCookie(c, s.cfg.CookieName, token, maxAge, true)
+	s.setCookie(c, s.cfg.CSRFCookieName, csrfToken, maxAge, false)
+	c.Header(s.cfg.CSRFHeaderName, csrfToken)
+	return nil
+}
+
+// Clear expires the session and CSRF cookies.
+func (s *SessionCookies) Clear(c *gin.Context) {
+	s.setCookie(c, s.cfg.CookieName, "", -1, true)
+	s.setCookie(c, s.cfg.CSRFCookieName, "", -1, false)
+}
+
+// token returns the session token carried in the cookie, if any.
+func (s *SessionCookies) token(c *gin.Context) string {
+	if !s.cfg.CookieMode {
+		return ""
+	}
+	value, err := c.Cookie(s.cfg.CookieName)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(value)
+}
+
+// verifyCSRF checks the double-submit token for state-changing requests.
+// Safe methods are always allowed.
+func (s *SessionCookies) verifyCSRF(c *gin.Context) bool {
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+
+	cookieValue, err := c.Cookie(s.cfg.CSRFCookieName)
+	if err != nil || cookieValue == "" {
+		return false
+	}
+	headerValue := c.GetHeader(s.cfg.CSRFHeaderName)
+	if headerValue == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(cookieValue), []byte(headerValue)) == 1
+}
+
+func (s *SessionCookies) setCookie(c *gin.Context, name, value string, maxAge int, httpOnly bool) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     s.cfg.CookiePath,
+		Domain:   s.cfg.CookieDomain,
+		MaxAge:   maxAge,
+		Secure:   s.cfg.CookieSecure,
+		HttpOnly: httpOnly,
+		SameSite: parseSameSite(s.cfg.CookieSameSite),
+	})
+}
+
+func parseSameSite(value string) http.SameSite {
+	switch strings.ToLower(value) {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteLaxMode
+	}
+}
+
+func generateCSRFToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	apperrors "github.com/jixlox0/studoto-backend/internal/errors"
+)
+
+// StatusClientClosedRequest is the non-standard status (popularised by nginx)
+// recorded when the client went away before the response was ready
+const StatusClientClosedRequest = 499
+
+// Timeout bounds the request context by timeout, so that database, Redis and
+// outbound calls made with it are cancelled when the deadline passes or the
+// client disconnects. An error response written after that point is
+// replaced by 503 for timeouts and 499 for disconnects, since the handler's
+// own status (e.g. a "not found" from a cancelled lookup) would be misleading.
+// A zero timeout only applies the error mapping.
+func Timeout(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		if timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+			c.Request = c.Request.WithContext(ctx)
+		}
+
+		writer := &contextErrorWriter{ResponseWriter: c.Writer, c: c, ctx: ctx}
+		c.Writer = writer
+
+		c.Next()
+
+		// Handlers that gave up without responding still get the context error
+		if !writer.Written() && ctx.Err() != nil {
+			writer.WriteHeader(http.StatusInternalServerError)
+		}
+	}
+}
+
+// ContextErrorStatus returns the status and error to report for a request
+// whose context is done, and false while the context is still live.
+func ContextErrorStatus(ctx context.Context) (int, error, bool) {
+	switch err := ctx.Err(); {
+	case err == nil:
+		return 0, nil, false
+	case errors.Is(err, context.DeadlineExceeded):
+		return http.StatusServiceUnavailable, apperrors.ErrRequestTimeout, true
+	default:
+		return StatusClientClosedRequest, apperrors.ErrRequestCanceled, true
+	}
+}
+
+// contextErrorWriter swaps error responses for the context error once the
+// request context is done. Successful responses are left alone: the work they
+// report has already been done.
+type contextErrorWriter struct {
+	gin.ResponseWriter
+	c        *gin.Context
+	ctx      context.Context
+	replaced bool
+}
+
+func (w *contextErrorWriter) WriteHeader(code int) {
+	if w.replaced {
+		return
+	}
+	if code >= http.StatusBadRequest && !w.Written() {
+		if status, err, ok := ContextErrorStatus(w.ctx); ok {
+			w.replaced = true
+			body, _ := json.Marshal(NewProblem(w.c, err))
+			w.Header().Set("Content-Type", ProblemContentType)
+			w.ResponseWriter.WriteHeader(status)
+			_, _ = w.ResponseWriter.Write(body)
+			return
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *contextErrorWriter) Write(data []byte) (int, error) {
+	if w.replaced {
+		return len(data), nil
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *contextErrorWriter) WriteString(s string) (int, error) {
+	if w.replaced {
+		return len(s), nil
+	}
+	return w.ResponseWriter.WriteString(s)
+}
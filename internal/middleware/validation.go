@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	apperrors "github.com/jixlox0/studoto-backend/internal/errors"
+	"github.com/jixlox0/studoto-backend/internal/i18n"
+	"github.com/jixlox0/studoto-backend/internal/models"
+)
+
+// ConfigureValidator makes gin's validator name fields as clients know them,
+// by their json or form tag instead of their Go name, and registers the
+// bundle's translations of validation errors.
+func ConfigureValidator(bundle *i18n.Bundle) error {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return nil
+	}
+	v.RegisterTagNameFunc(fieldName)
+	return bundle.RegisterValidator(v)
+}
+
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "form"} {
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// AbortWithBindError responds with 400 for an error from binding the request
+// with c.ShouldBind and friends. Validation failures list the offending
+// fields; anything else is reported as a malformed body.
+func AbortWithBindError(c *gin.Context, err error) {
+	var validationErrs validator.ValidationErrors
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &validationErrs) || errors.As(err, &typeErr) {
+		AbortWithError(c, fmt.Errorf("%w: %w", apperrors.ErrValidationError, err))
+		return
+	}
+	AbortWithError(c, fmt.Errorf("%w: %w", apperrors.ErrMalformedBody, err))
+}
+
+// fieldErrors returns the per-field details of a binding error, in the
+// localizer's language, or nil when err does not concern particular fields.
+func fieldErrors(localizer *i18n.Localizer, err error) []models.FieldError {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		details := make([]models.FieldError, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			details = append(details, models.FieldError{
+				Field:   fieldPath(fe),
+				Code:    fe.Tag(),
+				Message: localizer.FieldError(fe),
+			})
+		}
+		return details
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		typeName, _ := localizer.Message("type."+jsonTypeName(typeErr.Type), nil)
+		message, _ := localizer.Message("validation.type", map[string]string{
+			"field": typeErr.Field,
+			"type":  typeName,
+		})
+		return []models.FieldError{{
+			Field:   typeErr.Field,
+			Code:    "type",
+			Message: message,
+		}}
+	}
+	return nil
+}
+
+// fieldPath returns the path of the field within the request, without the
+// name of the request struct itself, e.g. "email" or "address.city".
+func fieldPath(fe validator.FieldError) string {
+	if _, path, ok := strings.Cut(fe.Namespace(), "."); ok {
+		return path
+	}
+	return fe.Field()
+}
+
+// jsonTypeName names the JSON type a Go type is decoded from, as used in the
+// type.* catalog keys.
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	}
+	return "object"
+}
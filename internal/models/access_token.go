@@ -0,0 +1,114 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// AccessTokenPrefix marks the plaintext form of a personal access token so it
+// can be told apart from a JWT when presented as a bearer credential.
+const AccessTokenPrefix = "stk_"
+
+// Personal access token scopes
+const (
+	ScopeProfileRead  = "profile:read"
+	ScopeTokensRead   = "tokens:read"
+	ScopeTokensWrite  = "tokens:write"
+	ScopeAccountWrite = "account:write"
+	ScopeAdmin        = "admin"
+)
+
+// AccessTokenScopes lists every scope a personal access token may be granted.
+var AccessTokenScopes = []string{
+	ScopeProfileRead,
+	ScopeTokensRead,
+	ScopeTokensWrite,
+	ScopeAccountWrite,
+	ScopeAdmin,
+}
+
+type AccessToken struct {
+	ID         uint       `gorm:"primaryKey" json:"-"`
+	UUID       string     `gorm:"uniqueIndex;size:100" json:"id"`
+	UserID     uint       `gorm:"index;not null" json:"-"`
+	User       User       `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	Name       string     `gorm:"size:100;not null" json:"name"`
+	TokenHash  string     `gorm:"column:token_hash;uniqueIndex;size:64;not null" json:"-"`
+	Hint       string     `gorm:"size:8" json:"hint"`
+	Scopes     string     `gorm:"not null" json:"-"`
+	LastUsedAt *time.Time `gorm:"column:last_used_at" json:"last_used_at,omitempty"`
+	ExpiresAt  *time.Time `gorm:"column:expires_at;index" json:"expires_at,omitempty"`
+	RevokedAt  *time.Time `gorm:"column:revoked_at" json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+func (AccessToken) TableName() string {
+	return "access_tokens"
+}
+
+// ScopeList returns the token scopes as a slice.
+func (t *AccessToken) ScopeList() []string {
+	if t.Scopes == "" {
+		return []string{}
+	}
+	return strings.Split(t.Scopes, ",")
+}
+
+// HasScope reports whether the token was granted the given scope.
+func (t *AccessToken) HasScope(scope string) bool {
+	for _, s := range t.ScopeList() {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// IsExpired reports whether the token is past its expiry time.
+func (t *AccessToken) IsExpired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
+
+// IsRevoked reports whether the token has been revoked by its owner.
+func (t *AccessToken) IsRevoked() bool {
+	return t.RevokedAt != nil
+}
+
+type AccessTokenResponse struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Hint       string     `json:"hint"`
+	Scopes     []string   `json:"scopes"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// NewAccessTokenResponse converts a stored token into its public representation.
+func NewAccessTokenResponse(token *AccessToken) *AccessTokenResponse {
+	return &AccessTokenResponse{
+		ID:         token.UUID,
+		Name:       token.Name,
+		Hint:       token.Hint,
+		Scopes:     token.ScopeList(),
+		LastUsedAt: token.LastUsedAt,
+		ExpiresAt:  token.ExpiresAt,
+		RevokedAt:  token.RevokedAt,
+		CreatedAt:  token.CreatedAt,
+	}
+}
+
+// CreatedAccessTokenResponse is returned once, at creation time, and is the
+// only response that ever carries the plaintext token.
+type CreatedAccessTokenResponse struct {
+	*AccessTokenResponse
+	Token string `json:"token"`
+}
+
+type CreateAccessTokenRequest struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	Scopes        []string `json:"scopes" binding:"required,min=1"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"omitempty,min=1,max=365"`
+}
@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+// Audit actions
+const (
+	AuditActionSignup               = "auth.signup"
+	AuditActionSigninSucceeded      = "auth.signin.succeeded"
+	AuditActionSigninFailed         = "auth.signin.failed"
+	AuditActionOAuthLogin           = "auth.oauth.login"
+	AuditActionSignout              = "auth.signout"
+	AuditActionPasswordChanged      = "user.password.changed"
+	AuditActionPasswordChangeFailed = "user.password.change_failed"
+	AuditActionAdminRoleChanged     = "admin.user.role_changed"
+	AuditActionDeletionRequested    = "account.deletion_requested"
+	AuditActionDeletionCancelled    = "account.deletion_cancelled"
+	AuditActionDataExported         = "account.data_exported"
+	AuditActionAccountPurged        = "account.purged"
+)
+
+// AnonymizedUserID replaces a user's identifier in audit events once their
+// account has been erased.
+const AnonymizedUserID = "deleted-user"
+
+// AuditEvent is an append-only record of a security-relevant action.
+// Actor and target are public user identifiers; either may be empty, e.g.
+// for a failed signin against an unknown email.
+type AuditEvent struct {
+	ID        uint      `gorm:"primaryKey" json:"-"`
+	UUID      string    `gorm:"uniqueIndex;size:100" json:"id"`
+	ActorID   string    `gorm:"column:actor_id;size:100;index" json:"actor_id,omitempty"`
+	TargetID  string    `gorm:"column:target_id;size:100;index" json:"target_id,omitempty"`
+	Action    string    `gorm:"size:100;not null;index" json:"action"`
+	IP        string    `gorm:"column:ip;size:64" json:"ip,omitempty"`
+	UserAgent string    `gorm:"column:user_agent;size:512" json:"user_agent,omitempty"`
+	Metadata  JSONMap   `gorm:"type:jsonb" json:"metadata,omitempty"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+func (AuditEvent) TableName() string {
+	return "audit_events"
+}
+
+type AuditEventFilter struct {
+	ActorID  string     `form:"actor_id"`
+	TargetID string     `form:"target_id"`
+	Action   string     `form:"action"`
+	From     *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To       *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	Page     int        `form:"page" binding:"omitempty,min=1"`
+	PageSize int        `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+type AuditEventPage struct {
+	Events   []AuditEvent `json:"events"`
+	Total    int64        `json:"total"`
+	Page     int          `json:"page"`
+	PageSize int          `json:"page_size"`
+}
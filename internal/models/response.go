@@ -33,3 +33,52 @@ func NewSuccessResponse(data any) *SuccessResponse {
 		Data:    data,
 	}
 }
+
+// PaginatedResponse is a SuccessResponse holding one page of a list, with the
+// cursors and links of the neighbouring pages.
+type PaginatedResponse struct {
+	SuccessResponse
+	Pagination Pagination      `json:"pagination"`
+	Links      PaginationLinks `json:"links"`
+}
+
+// Pagination describes the position of a page. The cursors are opaque and
+// empty when there is no page in that direction.
+type Pagination struct {
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+	HasNext    bool   `json:"has_next"`
+	HasPrev    bool   `json:"has_prev"`
+}
+
+// PaginationLinks are the request for the current page and, when they exist,
+// for the next and previous ones.
+type PaginationLinks struct {
+	Self string `json:"self"`
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
+}
+
+// Problem is an error response in the problem details format of RFC 7807.
+// Code is stable and meant for programs; Title and Detail are meant for
+// people and may change.
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      string       `json:"code"`
+	TraceID   string       `json:"trace_id,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError describes why one field of a request failed validation. Code is
+// the rule that failed, e.g. "required" or "email".
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
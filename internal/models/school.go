@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// School is an organization offering courses. Slug identifies it in URLs and
+// is unique.
+type School struct {
+	ID        uint           `gorm:"primaryKey" json:"-"`
+	UUID      string         `gorm:"uniqueIndex;size:100" json:"id"`
+	Slug      string         `gorm:"uniqueIndex;size:100;not null" json:"slug"`
+	Name      string         `gorm:"size:200;not null" json:"name"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+func (School) TableName() string {
+	return "schools"
+}
+
+// Course is a unit of learning content offered by a school. Slug is unique
+// within the school.
+type Course struct {
+	ID          uint           `gorm:"primaryKey" json:"-"`
+	UUID        string         `gorm:"uniqueIndex;size:100" json:"id"`
+	SchoolID    uint           `gorm:"uniqueIndex:idx_courses_school_slug;not null" json:"-"`
+	School      School         `gorm:"constraint:OnDelete:CASCADE" json:"-"`
+	Slug        string         `gorm:"uniqueIndex:idx_courses_school_slug;size:100;not null" json:"slug"`
+	Title       string         `gorm:"size:200;not null" json:"title"`
+	Description string         `json:"description,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+func (Course) TableName() string {
+	return "courses"
+}
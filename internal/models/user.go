@@ -6,32 +6,65 @@ import (
 	"gorm.io/gorm"
 )
 
+// User roles
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// User is an account. DeletionScheduledAt is set when the user asks for their
+// account to be deleted; the account is purged once it has passed unless the
+// request is cancelled first.
 type User struct {
-	ID           uint           `gorm:"primaryKey" json:"-"`
-	UUID         string         `gorm:"uniqueIndex;size:100" json:"id"`
-	Email        string         `gorm:"uniqueIndex;not null" json:"email"`
-	PasswordHash string         `gorm:"column:password_hash" json:"-"`
-	Name         string         `gorm:"not null" json:"name"`
-	AvatarURL    string         `gorm:"column:avatar_url" json:"avatar_url,omitempty"`
-	Provider     string         `gorm:"index" json:"provider,omitempty"`
-	ProviderID   string         `gorm:"column:provider_id;index" json:"provider_id,omitempty"`
-	CreatedAt    time.Time      `json:"created_at"`
-	UpdatedAt    time.Time      `json:"updated_at"`
-	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
+	ID                  uint           `gorm:"primaryKey" json:"-"`
+	UUID                string         `gorm:"uniqueIndex;size:100" json:"id"`
+	Email               string         `gorm:"uniqueIndex;not null" json:"email"`
+	PasswordHash        string         `gorm:"column:password_hash" json:"-"`
+	Name                string         `gorm:"not null" json:"name"`
+	AvatarURL           string         `gorm:"column:avatar_url" json:"avatar_url,omitempty"`
+	Provider            string         `gorm:"index" json:"provider,omitempty"`
+	ProviderID          string         `gorm:"column:provider_id;index" json:"provider_id,omitempty"`
+	Role                string         `gorm:"size:20;not null;default:user" json:"role"`
+	DeletionRequestedAt *time.Time     `gorm:"column:deletion_requested_at" json:"deletion_requested_at,omitempty"`
+	DeletionScheduledAt *time.Time     `gorm:"column:deletion_scheduled_at;index" json:"deletion_scheduled_at,omitempty"`
+	CreatedAt           time.Time      `json:"created_at"`
+	UpdatedAt           time.Time      `json:"updated_at"`
+	DeletedAt           gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
 type UserResponse struct {
+	ID        string    `json:"id"`
 	Email     string    `json:"email"`
 	Name      string    `json:"name"`
 	AvatarURL string    `json:"avatar_url,omitempty"`
+	Role      string    `json:"role"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+func NewUserResponse(user *User) *UserResponse {
+	return &UserResponse{
+		ID:        user.UUID,
+		Email:     user.Email,
+		Name:      user.Name,
+		AvatarURL: user.AvatarURL,
+		Role:      user.Role,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+	}
+}
+
 func (User) TableName() string {
 	return "users"
 }
 
+// AuthResponse is returned after a successful signup, signin or OAuth login.
+// Token is omitted when the session is carried in a cookie instead.
+type AuthResponse struct {
+	Token string `json:"token,omitempty"`
+	User  *User  `json:"user,omitempty"`
+}
+
 type CreateUserRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required,min=6"`
@@ -42,3 +75,24 @@ type LoginRequest struct {
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required"`
 }
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=6"`
+}
+
+type UpdateUserRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=user admin"`
+}
+
+// DeleteAccountRequest re-authenticates a deletion request. Password accounts
+// must supply their password; OAuth-only accounts confirm their email instead.
+type DeleteAccountRequest struct {
+	Password     string `json:"password"`
+	ConfirmEmail string `json:"confirm_email"`
+}
+
+type AccountDeletionResponse struct {
+	DeletionRequestedAt time.Time `json:"deletion_requested_at"`
+	DeletionScheduledAt time.Time `json:"deletion_scheduled_at"`
+}
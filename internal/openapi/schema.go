@@ -0,0 +1,206 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	rawJSONType   = reflect.TypeOf(json.RawMessage{})
+	byteSliceType = reflect.TypeOf([]byte{})
+)
+
+// Schemas generates schemas for Go types and collects the named ones as
+// component schemas, so that each struct is described once and referenced.
+type Schemas struct {
+	components map[string]*Schema
+}
+
+func NewSchemas() *Schemas {
+	return &Schemas{components: make(map[string]*Schema)}
+}
+
+// Components returns the component schemas generated so far.
+func (s *Schemas) Components() map[string]*Schema {
+	return s.components
+}
+
+// For returns the schema of v's type: a reference for named structs, an
+// inline schema for everything else. Fields are named by their json tag and
+// constrained by their binding tag (required, email, min, max and oneof), so
+// only request fields are ever marked required.
+func (s *Schemas) For(v any) *Schema {
+	return s.schema(reflect.TypeOf(v))
+}
+
+func (s *Schemas) schema(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == rawJSONType:
+		return &Schema{}
+	case t == byteSliceType:
+		return &Schema{Type: "string", Format: "byte"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: s.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		// Component names are capitalized, so that unexported types
+		// documenting ad hoc payloads read like the rest
+		name := strings.ToUpper(t.Name()[:1]) + t.Name()[1:]
+		if _, ok := s.components[name]; !ok {
+			// Registered before the fields are walked, so that recursive
+			// types end in a reference instead of looping
+			s.components[name] = &Schema{}
+			*s.components[name] = *s.object(t)
+		}
+		return Ref(name)
+	}
+	// Interfaces and anything else accept any value
+	return &Schema{}
+}
+
+func (s *Schemas) object(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	s.addFields(schema, t)
+	return schema
+}
+
+func (s *Schemas) addFields(schema *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || !field.IsExported() && !field.Anonymous {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+
+		// Embedded structs without a name of their own are flattened, as
+		// encoding/json does
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			for embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				s.addFields(schema, embedded)
+				continue
+			}
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := s.schema(field.Type)
+		required := applyBinding(property, field.Type, field.Tag.Get("binding"))
+		schema.Properties[name] = property
+		if required {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+// applyBinding adds the constraints of a gin binding tag to schema and
+// reports whether the field is required.
+func applyBinding(schema *Schema, t reflect.Type, binding string) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	required := false
+	for _, rule := range strings.Split(binding, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = true
+		case "email":
+			schema.Format = "email"
+		case "oneof":
+			for _, value := range strings.Fields(param) {
+				schema.Enum = append(schema.Enum, value)
+			}
+		case "min", "max":
+			n, err := strconv.Atoi(param)
+			if err != nil {
+				continue
+			}
+			setBound(schema, t.Kind(), name == "min", n)
+		}
+	}
+	return required
+}
+
+func setBound(schema *Schema, kind reflect.Kind, lower bool, n int) {
+	switch kind {
+	case reflect.String:
+		if lower {
+			schema.MinLength = &n
+		} else {
+			schema.MaxLength = &n
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		if lower {
+			schema.MinItems = &n
+		} else {
+			schema.MaxItems = &n
+		}
+	default:
+		f := float64(n)
+		if lower {
+			schema.Minimum = &f
+		} else {
+			schema.Maximum = &f
+		}
+	}
+}
+
+// QueryParameters describes the fields of a struct bound with
+// c.ShouldBindQuery as query parameters, named by their form tag.
+func (s *Schemas) QueryParameters(v any) []*Parameter {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	var params []*Parameter
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("form"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		schema := s.schema(field.Type)
+		required := applyBinding(schema, field.Type, field.Tag.Get("binding"))
+		params = append(params, &Parameter{Name: name, In: "query", Required: required, Schema: schema})
+	}
+	return params
+}
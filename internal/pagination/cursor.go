@@ -0,0 +1,73 @@
+package pagination
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/jixlox0/studoto-backend/internal/errors"
+)
+
+// cursor is a position in a sorted list: the sort values of the row it was
+// taken from. A next cursor points past the last row of a page; a prev
+// cursor points before the first one and pages backwards.
+type cursor struct {
+	Sort   string `json:"s"`
+	Values []any  `json:"v"`
+	Prev   bool   `json:"p,omitempty"`
+}
+
+// encode returns the cursor as an opaque, URL-safe string.
+func (c *cursor) encode() (string, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor parses a cursor issued for order and converts its values back
+// to the types of the order's fields.
+func decodeCursor(value string, order []Sort) (*cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errors.ErrInvalidCursor, err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var c cursor
+	if err := dec.Decode(&c); err != nil {
+		return nil, fmt.Errorf("%w: %w", errors.ErrInvalidCursor, err)
+	}
+	// A cursor is a position in one order; in another it means nothing
+	if c.Sort != sortKey(order) || len(c.Values) != len(order) {
+		return nil, fmt.Errorf("%w: issued for sort %q", errors.ErrInvalidCursor, c.Sort)
+	}
+
+	for i, o := range order {
+		if c.Values[i], err = cursorValue(o.Field.Type, c.Values[i]); err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", errors.ErrInvalidCursor, o.Field.Column, err)
+		}
+	}
+	return &c, nil
+}
+
+func cursorValue(t Type, value any) (any, error) {
+	switch v := value.(type) {
+	case json.Number:
+		if t == Int {
+			return v.Int64()
+		}
+	case string:
+		if t == String || t == Time {
+			return parseValue(t, v)
+		}
+	case bool:
+		if t == Bool {
+			return v, nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected value %s", strconv.Quote(fmt.Sprint(value)))
+}
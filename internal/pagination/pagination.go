@@ -0,0 +1,286 @@
+// Package pagination implements the limit, cursor, sort and filter query
+// parameters of list endpoints. Each endpoint declares in a Spec the fields
+// clients may sort and filter on; Parse rejects anything else, Find applies
+// the result to a GORM query with keyset pagination, and NewResponse renders
+// the page in the paginated envelope.
+//
+//	GET /v1/api/admin/users?limit=20&sort=-created_at&filter[role]=admin&filter[created_at][gte]=2024-01-01T00:00:00Z
+//
+// Cursors are opaque to clients: they are taken from the next and prev links
+// of a response and only valid with the sort they were issued for.
+package pagination
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jixlox0/studoto-backend/internal/errors"
+)
+
+// Query parameters
+const (
+	LimitParam  = "limit"
+	CursorParam = "cursor"
+	SortParam   = "sort"
+	// FilterParam prefixes filters, written filter[field]=value or
+	// filter[field][op]=value
+	FilterParam = "filter"
+)
+
+// Type is the type of a field's values, used to parse filter values and
+// cursor positions.
+type Type int
+
+const (
+	String Type = iota
+	Int
+	Time // RFC 3339
+	Bool
+)
+
+// Filter operators. filter[field]=value is short for filter[field][eq]=value;
+// the values of in are separated by commas.
+const (
+	Eq  = "eq"
+	Ne  = "ne"
+	Lt  = "lt"
+	Lte = "lte"
+	Gt  = "gt"
+	Gte = "gte"
+	In  = "in"
+)
+
+var operatorSQL = map[string]string{
+	Eq:  "=",
+	Ne:  "<>",
+	Lt:  "<",
+	Lte: "<=",
+	Gt:  ">",
+	Gte: ">=",
+	In:  "IN",
+}
+
+// Field is a column clients may sort or filter on. Sortable columns must be
+// NOT NULL, as NULLs have no place in a keyset order.
+type Field struct {
+	// Name identifies the field in query parameters
+	Name   string
+	Column string
+	Type   Type
+	// Sortable allows sort=name and sort=-name
+	Sortable bool
+	// Operators are the filter operators allowed on the field; with none the
+	// field cannot be filtered on
+	Operators []string
+}
+
+// Spec declares how a list endpoint may be paged, sorted and filtered.
+type Spec struct {
+	Fields []Field
+	// Key is a unique column ending every sort, so that rows with equal sort
+	// values keep a stable order across pages. It is not exposed to clients.
+	Key Field
+	// DefaultSort is used when the request has no sort, e.g. "-created_at"
+	DefaultSort  string
+	DefaultLimit int
+	MaxLimit     int
+}
+
+// Sort is one term of an order.
+type Sort struct {
+	Field Field
+	Desc  bool
+}
+
+// Filter restricts a field with an operator. Value has the Go type of the
+// field's Type, or is a slice of it for In.
+type Filter struct {
+	Field Field
+	Op    string
+	Value any
+}
+
+// Params are the parsed pagination parameters of a request.
+type Params struct {
+	Limit   int
+	Sort    []Sort
+	Filters []Filter
+
+	cursor *cursor
+}
+
+// Parse reads the pagination parameters from a request's query. Unknown sort
+// and filter fields, operators a field does not allow, malformed values and
+// cursors issued for another sort are rejected with the errors.ErrInvalid*
+// errors.
+func (s *Spec) Parse(query url.Values) (*Params, error) {
+	params := &Params{Limit: s.DefaultLimit}
+
+	if value := query.Get(LimitParam); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > s.MaxLimit {
+			return nil, fmt.Errorf("%w: %q is not between 1 and %d", errors.ErrInvalidLimit, value, s.MaxLimit)
+		}
+		params.Limit = limit
+	}
+
+	sortValue := query.Get(SortParam)
+	if sortValue == "" {
+		sortValue = s.DefaultSort
+	}
+	order, err := s.parseSort(sortValue)
+	if err != nil {
+		return nil, err
+	}
+	params.Sort = order
+
+	if params.Filters, err = s.parseFilters(query); err != nil {
+		return nil, err
+	}
+
+	if value := query.Get(CursorParam); value != "" {
+		if params.cursor, err = decodeCursor(value, params.Sort); err != nil {
+			return nil, err
+		}
+	}
+	return params, nil
+}
+
+// parseSort parses comma-separated field names, each optionally prefixed
+// with "-" for descending order, and appends the key.
+func (s *Spec) parseSort(value string) ([]Sort, error) {
+	var order []Sort
+	for _, term := range strings.Split(value, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		name, desc := strings.CutPrefix(term, "-")
+		field, ok := s.field(name)
+		if !ok || !field.Sortable {
+			return nil, fmt.Errorf("%w: %q", errors.ErrInvalidSort, name)
+		}
+		if slices.ContainsFunc(order, func(o Sort) bool { return o.Field.Name == name }) {
+			return nil, fmt.Errorf("%w: %q is given twice", errors.ErrInvalidSort, name)
+		}
+		order = append(order, Sort{Field: field, Desc: desc})
+	}
+
+	// The key follows the direction of the last term, so that an index on
+	// (column, key) serves the query
+	desc := len(order) > 0 && order[len(order)-1].Desc
+	return append(order, Sort{Field: s.Key, Desc: desc}), nil
+}
+
+// parseFilters parses every filter[...] parameter. Filters are returned in
+// a stable order, so that the same query always builds the same SQL.
+func (s *Spec) parseFilters(query url.Values) ([]Filter, error) {
+	var keys []string
+	for key := range query {
+		if strings.HasPrefix(key, FilterParam+"[") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	var filters []Filter
+	for _, key := range keys {
+		name, op, ok := parseFilterKey(key)
+		if !ok {
+			return nil, fmt.Errorf("%w: %q", errors.ErrInvalidFilter, key)
+		}
+		field, ok := s.field(name)
+		if !ok || !slices.Contains(field.Operators, op) {
+			return nil, fmt.Errorf("%w: %s %s is not allowed", errors.ErrInvalidFilter, name, op)
+		}
+
+		for _, raw := range query[key] {
+			var (
+				value any
+				err   error
+			)
+			if op == In {
+				value, err = parseValues(field.Type, strings.Split(raw, ","))
+			} else {
+				value, err = parseValue(field.Type, raw)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s: %w", errors.ErrInvalidFilter, key, err)
+			}
+			filters = append(filters, Filter{Field: field, Op: op, Value: value})
+		}
+	}
+	return filters, nil
+}
+
+// parseFilterKey splits "filter[name]" and "filter[name][op]".
+func parseFilterKey(key string) (name, op string, ok bool) {
+	rest := strings.TrimPrefix(key, FilterParam+"[")
+	name, rest, ok = strings.Cut(rest, "]")
+	if !ok || name == "" {
+		return "", "", false
+	}
+	if rest == "" {
+		return name, Eq, true
+	}
+	op, ok = strings.CutPrefix(rest, "[")
+	if !ok || !strings.HasSuffix(op, "]") {
+		return "", "", false
+	}
+	op = strings.TrimSuffix(op, "]")
+	if _, known := operatorSQL[op]; !known {
+		return "", "", false
+	}
+	return name, op, true
+}
+
+func (s *Spec) field(name string) (Field, bool) {
+	for _, f := range s.Fields {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
+
+func parseValue(t Type, raw string) (any, error) {
+	switch t {
+	case Int:
+		return strconv.ParseInt(raw, 10, 64)
+	case Time:
+		return time.Parse(time.RFC3339Nano, raw)
+	case Bool:
+		return strconv.ParseBool(raw)
+	}
+	return raw, nil
+}
+
+func parseValues(t Type, raws []string) ([]any, error) {
+	values := make([]any, len(raws))
+	for i, raw := range raws {
+		value, err := parseValue(t, strings.TrimSpace(raw))
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
+
+// sortKey is the canonical form of an order, e.g. "-created_at,-id", which
+// cursors are bound to.
+func sortKey(order []Sort) string {
+	terms := make([]string, len(order))
+	for i, o := range order {
+		terms[i] = o.Field.Column
+		if o.Desc {
+			terms[i] = "-" + terms[i]
+		}
+	}
+	return strings.Join(terms, ",")
+}
@@ -0,0 +1,164 @@
+package pagination
+
+import (
+	stderrors "errors"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jixlox0/studoto-backend/internal/errors"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	createdAt = Field{Name: "created_at", Column: "created_at", Type: Time, Sortable: true,
+		Operators: []string{Gte, Lt}}
+	name = Field{Name: "name", Column: "name", Type: String, Sortable: true}
+	role = Field{Name: "role", Column: "role", Type: String, Operators: []string{Eq, In}}
+	key  = Field{Name: "id", Column: "id", Type: Int}
+)
+
+var testSpec = &Spec{
+	Fields:       []Field{createdAt, name, role},
+	Key:          key,
+	DefaultSort:  "-created_at",
+	DefaultLimit: 20,
+	MaxLimit:     100,
+}
+
+func TestParse(t *testing.T) {
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		query   url.Values
+		want    *Params
+		wantErr error
+	}{
+		{name: "defaults", query: url.Values{},
+			want: &Params{Limit: 20, Sort: []Sort{{createdAt, true}, {key, true}}}},
+		{name: "mixed directions", query: url.Values{"limit": {"5"}, "sort": {"name,-created_at"}},
+			want: &Params{Limit: 5, Sort: []Sort{{name, false}, {createdAt, true}, {key, true}}}},
+		{name: "key follows the last term", query: url.Values{"sort": {"-created_at,name"}},
+			want: &Params{Limit: 20, Sort: []Sort{{createdAt, true}, {name, false}, {key, false}}}},
+		{name: "filters", query: url.Values{"filter[role][in]": {"admin, user"}, "filter[created_at][gte]": {"2024-01-01T00:00:00Z"}},
+			want: &Params{Limit: 20, Sort: []Sort{{createdAt, true}, {key, true}}, Filters: []Filter{
+				{createdAt, Gte, since},
+				{role, In, []any{"admin", "user"}},
+			}}},
+		{name: "shorthand equality", query: url.Values{"filter[role]": {"admin"}},
+			want: &Params{Limit: 20, Sort: []Sort{{createdAt, true}, {key, true}}, Filters: []Filter{{role, Eq, "admin"}}}},
+
+		{name: "limit too large", query: url.Values{"limit": {"101"}}, wantErr: errors.ErrInvalidLimit},
+		{name: "limit zero", query: url.Values{"limit": {"0"}}, wantErr: errors.ErrInvalidLimit},
+		{name: "limit not a number", query: url.Values{"limit": {"ten"}}, wantErr: errors.ErrInvalidLimit},
+		{name: "unknown sort", query: url.Values{"sort": {"password_hash"}}, wantErr: errors.ErrInvalidSort},
+		{name: "unsortable field", query: url.Values{"sort": {"role"}}, wantErr: errors.ErrInvalidSort},
+		{name: "key is not exposed", query: url.Values{"sort": {"id"}}, wantErr: errors.ErrInvalidSort},
+		{name: "sort given twice", query: url.Values{"sort": {"name,-name"}}, wantErr: errors.ErrInvalidSort},
+		{name: "operator not allowed", query: url.Values{"filter[role][gt]": {"a"}}, wantErr: errors.ErrInvalidFilter},
+		{name: "unknown operator", query: url.Values{"filter[role][like]": {"a"}}, wantErr: errors.ErrInvalidFilter},
+		{name: "unfilterable field", query: url.Values{"filter[name]": {"Ada"}}, wantErr: errors.ErrInvalidFilter},
+		{name: "malformed filter", query: url.Values{"filter[role": {"admin"}}, wantErr: errors.ErrInvalidFilter},
+		{name: "malformed value", query: url.Values{"filter[created_at][gte]": {"yesterday"}}, wantErr: errors.ErrInvalidFilter},
+		{name: "malformed cursor", query: url.Values{"cursor": {"not a cursor"}}, wantErr: errors.ErrInvalidCursor},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := testSpec.Parse(tt.query)
+			if !stderrors.Is(err, tt.wantErr) {
+				t.Fatalf("Parse() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCursorRoundTrip(t *testing.T) {
+	params, err := testSpec.Parse(url.Values{"sort": {"name,-created_at"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := time.Date(2024, 1, 2, 3, 4, 5, 600000000, time.UTC)
+	// Row values come from the model, where IDs are unsigned
+	issued := &cursor{Sort: sortKey(params.Sort), Values: []any{"Ada", at, uint(42)}, Prev: true}
+	value, err := issued.encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := decodeCursor(value, params.Sort)
+	if err != nil {
+		t.Fatalf("decodeCursor() error = %v", err)
+	}
+	if got.Values[0] != "Ada" || !got.Values[1].(time.Time).Equal(at) || got.Values[2] != int64(42) || !got.Prev {
+		t.Errorf("decodeCursor() = %+v, want Ada, %v, 42 going backwards", got, at)
+	}
+
+	// The same cursor means nothing in another order
+	for _, sort := range []string{"-name,-created_at", "name", "-created_at"} {
+		other, err := testSpec.Parse(url.Values{"sort": {sort}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := decodeCursor(value, other.Sort); !stderrors.Is(err, errors.ErrInvalidCursor) {
+			t.Errorf("decodeCursor() with sort %s error = %v, want ErrInvalidCursor", sort, err)
+		}
+	}
+	if _, err := testSpec.Parse(url.Values{"cursor": {value}}); !stderrors.Is(err, errors.ErrInvalidCursor) {
+		t.Errorf("Parse() with a cursor from another sort error = %v, want ErrInvalidCursor", err)
+	}
+}
+
+func TestDecodeCursorRejectsWrongTypes(t *testing.T) {
+	order := []Sort{{createdAt, true}, {key, true}}
+	for _, values := range [][]any{
+		{"2024-01-01T00:00:00Z", "42"},
+		{"yesterday", 42},
+		{42, 42},
+	} {
+		value, err := (&cursor{Sort: sortKey(order), Values: values}).encode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := decodeCursor(value, order); !stderrors.Is(err, errors.ErrInvalidCursor) {
+			t.Errorf("decodeCursor(%v) error = %v, want ErrInvalidCursor", values, err)
+		}
+	}
+}
+
+func TestKeysetCondition(t *testing.T) {
+	order := []Sort{{name, false}, {createdAt, true}, {key, true}}
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	values := []any{"Ada", at, int64(42)}
+	col := func(f Field) clause.Column { return clause.Column{Name: f.Column} }
+	vars := []any{
+		col(name), "Ada",
+		col(name), "Ada", col(createdAt), at,
+		col(name), "Ada", col(createdAt), at, col(key), int64(42),
+	}
+
+	tests := []struct {
+		name      string
+		backwards bool
+		wantSQL   string
+	}{
+		{"forwards", false, "((? > ?) OR (? = ? AND ? < ?) OR (? = ? AND ? = ? AND ? < ?))"},
+		{"backwards", true, "((? < ?) OR (? = ? AND ? > ?) OR (? = ? AND ? = ? AND ? > ?))"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := keysetCondition(order, values, tt.backwards)
+			if got.SQL != tt.wantSQL {
+				t.Errorf("SQL = %s, want %s", got.SQL, tt.wantSQL)
+			}
+			if !reflect.DeepEqual(got.Vars, vars) {
+				t.Errorf("Vars = %v, want %v", got.Vars, vars)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package pagination
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// Page is one page of a list, with the cursors of its neighbours. A cursor
+// is empty when there is no page in that direction.
+type Page[T any] struct {
+	Items      []T
+	Limit      int
+	NextCursor string
+	PrevCursor string
+}
+
+// Find loads the page of T described by params. query selects the rows to
+// page through, e.g. conn(ctx, db).Model(&models.User{}); the filters, order,
+// cursor position and limit are added to it. Errors come straight from GORM.
+func Find[T any](query *gorm.DB, params *Params) (*Page[T], error) {
+	fields, err := sortFields[T](query, params.Sort)
+	if err != nil {
+		return nil, err
+	}
+
+	query = params.Apply(query)
+	backwards := params.cursor != nil && params.cursor.Prev
+	if params.cursor != nil {
+		query = query.Where(keysetCondition(params.Sort, params.cursor.Values, backwards))
+	}
+	// Pages are read in the direction of travel and turned around
+	// afterwards, so that the rows next to the cursor are the ones read
+	for _, o := range params.Sort {
+		query = query.Order(clause.OrderByColumn{Column: clause.Column{Name: o.Field.Column}, Desc: o.Desc != backwards})
+	}
+
+	// One more row than asked for tells whether there is a further page
+	var items []T
+	if err := query.Limit(params.Limit + 1).Find(&items).Error; err != nil {
+		return nil, err
+	}
+	more := len(items) > params.Limit
+	if more {
+		items = items[:params.Limit]
+	}
+	if backwards {
+		slices.Reverse(items)
+	}
+	if items == nil {
+		items = []T{}
+	}
+
+	page := &Page[T]{Items: items, Limit: params.Limit}
+	if len(items) == 0 {
+		return page, nil
+	}
+	// Forwards, there is a previous page whenever a cursor was followed;
+	// backwards, there is always a next page, the one the cursor came from
+	hasNext := more || backwards
+	hasPrev := backwards && more || !backwards && params.cursor != nil
+	key := sortKey(params.Sort)
+	if hasNext {
+		c := &cursor{Sort: key, Values: rowValues(query, fields, &items[len(items)-1])}
+		if page.NextCursor, err = c.encode(); err != nil {
+			return nil, err
+		}
+	}
+	if hasPrev {
+		c := &cursor{Sort: key, Values: rowValues(query, fields, &items[0]), Prev: true}
+		if page.PrevCursor, err = c.encode(); err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
+// Apply adds the filters to query. Find applies them itself; Apply is for
+// queries that need the same rows without paging, such as counts.
+func (p *Params) Apply(query *gorm.DB) *gorm.DB {
+	for _, f := range p.Filters {
+		query = query.Where(clause.Expr{
+			SQL:  "? " + operatorSQL[f.Op] + " ?",
+			Vars: []any{clause.Column{Name: f.Field.Column}, f.Value},
+		})
+	}
+	return query
+}
+
+// keysetCondition selects the rows after values in the order, or before them
+// when going backwards:
+//
+//	(a > ?) OR (a = ? AND b > ?) OR (a = ? AND b = ? AND key > ?)
+func keysetCondition(order []Sort, values []any, backwards bool) clause.Expr {
+	var (
+		disjuncts []string
+		vars      []any
+	)
+	for i, o := range order {
+		var conjuncts []string
+		for j := 0; j < i; j++ {
+			conjuncts = append(conjuncts, "? = ?")
+			vars = append(vars, clause.Column{Name: order[j].Field.Column}, values[j])
+		}
+		op := ">"
+		if o.Desc != backwards {
+			op = "<"
+		}
+		conjuncts = append(conjuncts, "? "+op+" ?")
+		vars = append(vars, clause.Column{Name: o.Field.Column}, values[i])
+		disjuncts = append(disjuncts, "("+strings.Join(conjuncts, " AND ")+")")
+	}
+	return clause.Expr{SQL: "(" + strings.Join(disjuncts, " OR ") + ")", Vars: vars}
+}
+
+// sortFields looks up the model fields of the sort columns, whose values
+// make up the cursors.
+func sortFields[T any](query *gorm.DB, order []Sort) ([]*schema.Field, error) {
+	stmt := &gorm.Statement{DB: query}
+	if err := stmt.Parse(new(T)); err != nil {
+		return nil, err
+	}
+	fields := make([]*schema.Field, len(order))
+	for i, o := range order {
+		fields[i] = stmt.Schema.LookUpField(o.Field.Column)
+		if fields[i] == nil {
+			return nil, fmt.Errorf("pagination: %s has no column %q", stmt.Schema.Name, o.Field.Column)
+		}
+	}
+	return fields, nil
+}
+
+func rowValues[T any](query *gorm.DB, fields []*schema.Field, row *T) []any {
+	rv := reflect.ValueOf(row).Elem()
+	values := make([]any, len(fields))
+	for i, field := range fields {
+		values[i], _ = field.ValueOf(query.Statement.Context, rv)
+	}
+	return values
+}
@@ -0,0 +1,88 @@
+package pagination
+
+import (
+	"net/url"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/jixlox0/studoto-backend/internal/database/dbtest"
+	"github.com/jixlox0/studoto-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+func findUsers(t *testing.T, tx *gorm.DB, query url.Values) *Page[models.User] {
+	t.Helper()
+	params, err := testSpec.Parse(query)
+	if err != nil {
+		t.Fatalf("Parse(%v) error = %v", query, err)
+	}
+	page, err := Find[models.User](tx.Model(&models.User{}).Where("provider = ?", "pagination"), params)
+	if err != nil {
+		t.Fatalf("Find(%v) error = %v", query, err)
+	}
+	return page
+}
+
+func pageIDs(page *Page[models.User]) []string {
+	ids := make([]string, len(page.Items))
+	for i, u := range page.Items {
+		ids[i] = u.UUID
+	}
+	return ids
+}
+
+func TestFindPagesThroughTies(t *testing.T) {
+	tx := dbtest.Tx(t)
+	dbtest.LoadFixtures(t, tx, os.DirFS("testdata"), "users.yaml")
+
+	// Newest first; the three users created together are ordered by ID, and
+	// a page boundary falls between them
+	want := [][]string{
+		{"usr-page-5", "usr-page-4"},
+		{"usr-page-3", "usr-page-2"},
+		{"usr-page-1"},
+	}
+
+	pages := make([]*Page[models.User], len(want))
+	query := url.Values{"limit": {"2"}}
+	for i := range want {
+		pages[i] = findUsers(t, tx, query)
+		if got := pageIDs(pages[i]); !slices.Equal(got, want[i]) {
+			t.Fatalf("page %d = %v, want %v", i, got, want[i])
+		}
+		if hasNext, wantNext := pages[i].NextCursor != "", i < len(want)-1; hasNext != wantNext {
+			t.Fatalf("page %d: has next = %v, want %v", i, hasNext, wantNext)
+		}
+		if hasPrev, wantPrev := pages[i].PrevCursor != "", i > 0; hasPrev != wantPrev {
+			t.Fatalf("page %d: has prev = %v, want %v", i, hasPrev, wantPrev)
+		}
+		query = url.Values{"limit": {"2"}, "cursor": {pages[i].NextCursor}}
+	}
+
+	// Paging backwards returns the same pages, in reading order
+	for i := len(want) - 1; i > 0; i-- {
+		back := findUsers(t, tx, url.Values{"limit": {"2"}, "cursor": {pages[i].PrevCursor}})
+		if got := pageIDs(back); !slices.Equal(got, want[i-1]) {
+			t.Fatalf("page before %d = %v, want %v", i, got, want[i-1])
+		}
+		// The first page has nothing before it; every page reached
+		// backwards has the one it came from after it
+		if hasPrev := back.PrevCursor != ""; hasPrev != (i-1 > 0) {
+			t.Fatalf("page before %d: has prev = %v", i, hasPrev)
+		}
+		if back.NextCursor == "" {
+			t.Fatalf("page before %d has no next cursor", i)
+		}
+	}
+}
+
+func TestFindSinglePage(t *testing.T) {
+	tx := dbtest.Tx(t)
+	dbtest.LoadFixtures(t, tx, os.DirFS("testdata"), "users.yaml")
+
+	page := findUsers(t, tx, url.Values{"sort": {"name"}, "limit": {"5"}})
+	if len(page.Items) != 5 || page.NextCursor != "" || page.PrevCursor != "" {
+		t.Fatalf("single page: %v, next %q, prev %q", pageIDs(page), page.NextCursor, page.PrevCursor)
+	}
+}
@@ -0,0 +1,41 @@
+package pagination
+
+import (
+	"net/url"
+	"strconv"
+
+	"github.com/jixlox0/studoto-backend/internal/models"
+)
+
+// NewResponse renders a page in the paginated envelope. The links repeat the
+// request's query, with the cursor replaced, so that filters and sort carry
+// over; they are relative to the host, which is not trusted to be the one
+// clients used.
+func NewResponse[T any](requestURL *url.URL, page *Page[T]) *models.PaginatedResponse {
+	response := &models.PaginatedResponse{
+		SuccessResponse: *models.NewSuccessResponse(page.Items),
+		Pagination: models.Pagination{
+			Limit:      page.Limit,
+			NextCursor: page.NextCursor,
+			PrevCursor: page.PrevCursor,
+			HasNext:    page.NextCursor != "",
+			HasPrev:    page.PrevCursor != "",
+		},
+		Links: models.PaginationLinks{Self: requestURL.RequestURI()},
+	}
+	if page.NextCursor != "" {
+		response.Links.Next = pageLink(requestURL, page.Limit, page.NextCursor)
+	}
+	if page.PrevCursor != "" {
+		response.Links.Prev = pageLink(requestURL, page.Limit, page.PrevCursor)
+	}
+	return response
+}
+
+func pageLink(requestURL *url.URL, limit int, cursor string) string {
+	query := requestURL.Query()
+	query.Set(CursorParam, cursor)
+	query.Set(LimitParam, strconv.Itoa(limit))
+	link := url.URL{Path: requestURL.Path, RawQuery: query.Encode()}
+	return link.String()
+}
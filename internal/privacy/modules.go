@@ -0,0 +1,104 @@
+package privacy
+
+import (
+	"context"
+
+	"github.com/jixlox0/studoto-backend/internal/idempotency"
+	"github.com/jixlox0/studoto-backend/internal/models"
+	"github.com/jixlox0/studoto-backend/internal/repository"
+)
+
+// NewDefaultRegistry returns a registry with a module for every table that
+// holds personal data. New tables that reference users must register here.
+func NewDefaultRegistry(userRepo repository.UserRepository, tokenRepo repository.AccessTokenRepository, auditRepo repository.AuditEventRepository, idempotencyStore idempotency.Store) *Registry {
+	registry := NewRegistry()
+	registry.Register(&userModule{userRepo: userRepo})
+	registry.Register(&accessTokenModule{tokenRepo: tokenRepo})
+	registry.Register(&auditEventModule{auditRepo: auditRepo})
+	registry.Register(&idempotencyModule{store: idempotencyStore})
+	return registry
+}
+
+type userModule struct {
+	userRepo repository.UserRepository
+}
+
+func (m *userModule) Name() string {
+	return "profile"
+}
+
+func (m *userModule) Export(ctx context.Context, user *models.User) (any, error) {
+	return user, nil
+}
+
+func (m *userModule) Purge(ctx context.Context, user *models.User) error {
+	return m.userRepo.HardDelete(ctx, user)
+}
+
+type accessTokenModule struct {
+	tokenRepo repository.AccessTokenRepository
+}
+
+func (m *accessTokenModule) Name() string {
+	return "access_tokens"
+}
+
+func (m *accessTokenModule) Export(ctx context.Context, user *models.User) (any, error) {
+	tokens, err := m.tokenRepo.ListByUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*models.AccessTokenResponse, 0, len(tokens))
+	for i := range tokens {
+		responses = append(responses, models.NewAccessTokenResponse(&tokens[i]))
+	}
+	return responses, nil
+}
+
+func (m *accessTokenModule) Purge(ctx context.Context, user *models.User) error {
+	return m.tokenRepo.DeleteByUser(ctx, user.ID)
+}
+
+type auditEventModule struct {
+	auditRepo repository.AuditEventRepository
+}
+
+func (m *auditEventModule) Name() string {
+	return "security_activity"
+}
+
+func (m *auditEventModule) Export(ctx context.Context, user *models.User) (any, error) {
+	return m.auditRepo.ListAllByUser(ctx, user.UUID)
+}
+
+// Purge keeps the events, so the audit trail stays complete, but strips
+// everything that identifies the user.
+func (m *auditEventModule) Purge(ctx context.Context, user *models.User) error {
+	return m.auditRepo.AnonymizeUser(ctx, user.UUID, user.Email)
+}
+
+type idempotencyModule struct {
+	store idempotency.Store
+}
+
+func (m *idempotencyModule) Name() string {
+	return "idempotency_keys"
+}
+
+// Export lists the keys only: the stored responses are copies of data the
+// other modules export.
+func (m *idempotencyModule) Export(ctx context.Context, user *models.User) (any, error) {
+	keys, err := m.store.Keys(ctx, idempotency.UserScope(user.UUID))
+	if err != nil {
+		return nil, err
+	}
+	if keys == nil {
+		keys = []string{}
+	}
+	return keys, nil
+}
+
+func (m *idempotencyModule) Purge(ctx context.Context, user *models.User) error {
+	return m.store.DeleteScope(ctx, idempotency.UserScope(user.UUID))
+}
@@ -0,0 +1,67 @@
+// Package privacy implements personal data export and erasure. Each part of
+// the system that stores data about a user contributes a Module, and the
+// Registry runs them together when a user asks for their data or for their
+// account to be deleted.
+package privacy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jixlox0/studoto-backend/internal/models"
+)
+
+// Module exports and erases the personal data held by one part of the system.
+type Module interface {
+	// Name identifies the module in exports, e.g. "access_tokens".
+	Name() string
+	// Export returns everything the module stores about user in a form
+	// that can be encoded as JSON.
+	Export(ctx context.Context, user *models.User) (any, error)
+	// Purge deletes or anonymizes everything the module stores about user.
+	Purge(ctx context.Context, user *models.User) error
+}
+
+// Registry holds the registered modules in registration order.
+type Registry struct {
+	modules []Module
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds a module. Modules are exported in registration order and
+// purged in reverse, so the module owning the user row should be registered first.
+func (r *Registry) Register(module Module) {
+	r.modules = append(r.modules, module)
+}
+
+// Modules returns the registered modules in registration order.
+func (r *Registry) Modules() []Module {
+	return r.modules
+}
+
+// Export collects every module's data for user, keyed by module name.
+func (r *Registry) Export(ctx context.Context, user *models.User) (map[string]any, error) {
+	data := make(map[string]any, len(r.modules))
+	for _, module := range r.modules {
+		moduleData, err := module.Export(ctx, user)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export %s: %w", module.Name(), err)
+		}
+		data[module.Name()] = moduleData
+	}
+	return data, nil
+}
+
+// Purge erases user's data from every module, dependents first.
+func (r *Registry) Purge(ctx context.Context, user *models.User) error {
+	for i := len(r.modules) - 1; i >= 0; i-- {
+		module := r.modules[i]
+		if err := module.Purge(ctx, user); err != nil {
+			return fmt.Errorf("failed to purge %s: %w", module.Name(), err)
+		}
+	}
+	return nil
+}
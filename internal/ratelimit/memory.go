@@ -0,0 +1,114 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often expired keys are dropped from memory
+const sweepInterval = time.Minute
+
+type memoryEntry struct {
+	// hits holds request times for the sliding window, oldest first
+	hits []time.Time
+	// tokens and updated hold the token bucket state
+	tokens  float64
+	updated time.Time
+	expires time.Time
+}
+
+type memoryLimiter struct {
+	mu        sync.Mutex
+	entries   map[string]*memoryEntry
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewMemoryLimiter returns a limiter that counts in process memory. Limits
+// are per instance, so with several replicas the effective limit is
+// multiplied by the number of replicas.
+func NewMemoryLimiter() Limiter {
+	return &memoryLimiter{
+		entries: make(map[string]*memoryEntry),
+		now:     time.Now,
+	}
+}
+
+func (l *memoryLimiter) Allow(ctx context.Context, policy Policy, key string) (*Result, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	entryKey := policy.Name + ":" + key
+	entry, ok := l.entries[entryKey]
+	if !ok {
+		entry = &memoryEntry{tokens: float64(policy.capacity()), updated: now}
+		l.entries[entryKey] = entry
+	}
+
+	if policy.Algorithm == AlgorithmTokenBucket {
+		return l.takeToken(entry, policy, now), nil
+	}
+	return l.slide(entry, policy, now), nil
+}
+
+func (l *memoryLimiter) slide(entry *memoryEntry, policy Policy, now time.Time) *Result {
+	cutoff := now.Add(-policy.Window)
+	kept := entry.hits[:0]
+	for _, hit := range entry.hits {
+		if hit.After(cutoff) {
+			kept = append(kept, hit)
+		}
+	}
+	entry.hits = kept
+
+	result := &Result{Limit: policy.Limit}
+	if len(entry.hits) < policy.Limit {
+		entry.hits = append(entry.hits, now)
+		result.Allowed = true
+	}
+	result.Remaining = policy.Limit - len(entry.hits)
+	result.ResetAfter = entry.hits[0].Add(policy.Window).Sub(now)
+	if !result.Allowed {
+		result.RetryAfter = result.ResetAfter
+	}
+	entry.expires = now.Add(policy.Window)
+	return result
+}
+
+func (l *memoryLimiter) takeToken(entry *memoryEntry, policy Policy, now time.Time) *Result {
+	capacity := float64(policy.capacity())
+	// Tokens per nanosecond
+	rate := float64(policy.Limit) / float64(policy.Window)
+
+	entry.tokens = math.Min(capacity, entry.tokens+float64(now.Sub(entry.updated))*rate)
+	entry.updated = now
+
+	result := &Result{Limit: policy.capacity()}
+	if entry.tokens >= 1 {
+		entry.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration(math.Ceil((1 - entry.tokens) / rate))
+	}
+	result.Remaining = int(entry.tokens)
+	result.ResetAfter = time.Duration(math.Ceil((capacity - entry.tokens) / rate))
+	entry.expires = now.Add(result.ResetAfter)
+	return result
+}
+
+func (l *memoryLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	for key, entry := range l.entries {
+		if now.After(entry.expires) {
+			delete(l.entries, key)
+		}
+	}
+	l.lastSweep = now
+}
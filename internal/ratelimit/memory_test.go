@@ -0,0 +1,128 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// clock is a settable time source for the limiters' now field
+type clock struct{ t time.Time }
+
+func newClock() *clock { return &clock{t: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)} }
+
+func (c *clock) now() time.Time          { return c.t }
+func (c *clock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestMemoryLimiter(c *clock) *memoryLimiter {
+	l := NewMemoryLimiter().(*memoryLimiter)
+	l.now = c.now
+	return l
+}
+
+// step is one Allow call made after advancing the clock
+type step struct {
+	advance       time.Duration
+	wantAllowed   bool
+	wantRemaining int
+	wantRetry     time.Duration
+}
+
+func runSteps(t *testing.T, l Limiter, c *clock, policy Policy, steps []step) {
+	t.Helper()
+	for i, s := range steps {
+		c.advance(s.advance)
+		result, err := l.Allow(context.Background(), policy, "ip:1")
+		if err != nil {
+			t.Fatalf("step %d: Allow() error = %v", i, err)
+		}
+		if result.Allowed != s.wantAllowed || result.Remaining != s.wantRemaining || result.RetryAfter != s.wantRetry {
+			t.Fatalf("step %d: Allow() = allowed %v, remaining %d, retry after %v; want %v, %d, %v",
+				i, result.Allowed, result.Remaining, result.RetryAfter, s.wantAllowed, s.wantRemaining, s.wantRetry)
+		}
+	}
+}
+
+func TestMemorySlidingWindow(t *testing.T) {
+	policy := Policy{Name: "signin", Algorithm: AlgorithmSlidingWindow, Limit: 3, Window: time.Minute}
+	c := newClock()
+	l := newTestMemoryLimiter(c)
+
+	runSteps(t, l, c, policy, []step{
+		{wantAllowed: true, wantRemaining: 2},
+		{advance: 10 * time.Second, wantAllowed: true, wantRemaining: 1},
+		{advance: 10 * time.Second, wantAllowed: true, wantRemaining: 0},
+		// The first hit leaves the window 40s later
+		{advance: 10 * time.Second, wantAllowed: false, wantRemaining: 0, wantRetry: 30 * time.Second},
+		{advance: 29 * time.Second, wantAllowed: false, wantRemaining: 0, wantRetry: time.Second},
+		{advance: time.Second, wantAllowed: true, wantRemaining: 0},
+		// Rejected requests are not counted, so the second hit frees a slot
+		{advance: 10 * time.Second, wantAllowed: true, wantRemaining: 0},
+	})
+}
+
+func TestMemoryTokenBucket(t *testing.T) {
+	// Bursts of 2, refilled at one token every 10s
+	policy := Policy{Name: "export", Algorithm: AlgorithmTokenBucket, Limit: 6, Burst: 2, Window: time.Minute}
+	c := newClock()
+	l := newTestMemoryLimiter(c)
+
+	runSteps(t, l, c, policy, []step{
+		{wantAllowed: true, wantRemaining: 1},
+		{wantAllowed: true, wantRemaining: 0},
+		{wantAllowed: false, wantRemaining: 0, wantRetry: 10 * time.Second},
+		{advance: 5 * time.Second, wantAllowed: false, wantRemaining: 0, wantRetry: 5 * time.Second},
+		{advance: 5 * time.Second, wantAllowed: true, wantRemaining: 0},
+		// The bucket never holds more than Burst tokens
+		{advance: time.Hour, wantAllowed: true, wantRemaining: 1},
+		{wantAllowed: true, wantRemaining: 0},
+		{wantAllowed: false, wantRemaining: 0, wantRetry: 10 * time.Second},
+	})
+}
+
+func TestMemoryLimiterSeparatesKeysAndPolicies(t *testing.T) {
+	policy := Policy{Name: "signin", Algorithm: AlgorithmSlidingWindow, Limit: 1, Window: time.Minute}
+	other := Policy{Name: "signup", Algorithm: AlgorithmSlidingWindow, Limit: 1, Window: time.Minute}
+	ctx := context.Background()
+	l := newTestMemoryLimiter(newClock())
+
+	for _, call := range []struct {
+		policy Policy
+		key    string
+		want   bool
+	}{
+		{policy, "ip:1", true},
+		{policy, "ip:1", false},
+		{policy, "ip:2", true},
+		{other, "ip:1", true},
+	} {
+		result, err := l.Allow(ctx, call.policy, call.key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Allowed != call.want {
+			t.Errorf("Allow(%s, %s) allowed = %v, want %v", call.policy.Name, call.key, result.Allowed, call.want)
+		}
+	}
+}
+
+func TestMemoryLimiterSweepsExpiredKeys(t *testing.T) {
+	policy := Policy{Name: "signin", Algorithm: AlgorithmSlidingWindow, Limit: 1, Window: time.Second}
+	ctx := context.Background()
+	c := newClock()
+	l := newTestMemoryLimiter(c)
+
+	if _, err := l.Allow(ctx, policy, "ip:1"); err != nil {
+		t.Fatal(err)
+	}
+	c.advance(sweepInterval)
+	if _, err := l.Allow(ctx, policy, "ip:2"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := l.entries["signin:ip:1"]; ok {
+		t.Error("expired entry was not swept")
+	}
+	if _, ok := l.entries["signin:ip:2"]; !ok {
+		t.Error("live entry was swept")
+	}
+}
@@ -0,0 +1,150 @@
+// Package ratelimit implements sliding-window and token-bucket rate limiting.
+// Counters are kept in Redis so that limits hold across instances; while
+// Redis is unreachable each instance falls back to counting in memory.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/jixlox0/studoto-backend/internal/config"
+	"github.com/redis/go-redis/v9"
+)
+
+// Algorithms accepted in Policy.Algorithm
+const (
+	// AlgorithmSlidingWindow allows Limit requests in any Window-long period.
+	AlgorithmSlidingWindow = "sliding_window"
+	// AlgorithmTokenBucket allows bursts of up to Burst requests and refills
+	// at Limit requests per Window.
+	AlgorithmTokenBucket = "token_bucket"
+)
+
+// fallbackCooldown is how long Redis is skipped after a failure, so that an
+// outage costs one failed round trip every few seconds rather than one per
+// request
+const fallbackCooldown = 5 * time.Second
+
+// Policy is one limit applied to a key.
+type Policy struct {
+	Name      string
+	Algorithm string
+	Limit     int
+	// Burst is the token bucket size; it defaults to Limit.
+	Burst  int
+	Window time.Duration
+}
+
+func (p Policy) capacity() int {
+	if p.Algorithm == AlgorithmTokenBucket && p.Burst > 0 {
+		return p.Burst
+	}
+	return p.Limit
+}
+
+// Result describes the outcome of one Allow call.
+type Result struct {
+	Allowed bool
+	// Limit is the number of requests allowed at once: the window limit, or
+	// the bucket size for the token bucket.
+	Limit     int
+	Remaining int
+	// ResetAfter is the time until the quota is fully replenished.
+	ResetAfter time.Duration
+	// RetryAfter is the time until the next request would be allowed; it is
+	// zero when the request was allowed.
+	RetryAfter time.Duration
+}
+
+type Limiter interface {
+	// Allow counts one request against key under policy.
+	Allow(ctx context.Context, policy Policy, key string) (*Result, error)
+}
+
+// ValidatePolicy reports configuration mistakes in a policy.
+func ValidatePolicy(p Policy) error {
+	switch p.Algorithm {
+	case AlgorithmSlidingWindow, AlgorithmTokenBucket:
+	default:
+		return fmt.Errorf("rate limit policy %q: unknown algorithm %q", p.Name, p.Algorithm)
+	}
+	if p.Limit <= 0 {
+		return fmt.Errorf("rate limit policy %q: limit must be positive", p.Name)
+	}
+	if p.Window <= 0 {
+		return fmt.Errorf("rate limit policy %q: window must be positive", p.Name)
+	}
+	if p.Burst < 0 {
+		return fmt.Errorf("rate limit policy %q: burst must not be negative", p.Name)
+	}
+	return nil
+}
+
+// NewLimiter returns a Redis limiter that falls back to an in-memory limiter
+// while Redis is failing.
+func NewLimiter(client *redis.Client, cfg config.RateLimitConfig, logger *slog.Logger) Limiter {
+	timeout := time.Duration(cfg.RedisTimeoutMs) * time.Millisecond
+	return &fallbackLimiter{
+		primary:  NewRedisLimiter(client, timeout),
+		fallback: NewMemoryLimiter(),
+		logger:   logger,
+		now:      time.Now,
+	}
+}
+
+type fallbackLimiter struct {
+	primary  Limiter
+	fallback Limiter
+	logger   *slog.Logger
+	now      func() time.Time
+
+	mu        sync.Mutex
+	down      bool
+	downUntil time.Time
+}
+
+func (l *fallbackLimiter) Allow(ctx context.Context, policy Policy, key string) (*Result, error) {
+	if l.skipPrimary() {
+		return l.fallback.Allow(ctx, policy, key)
+	}
+
+	result, err := l.primary.Allow(ctx, policy, key)
+	if err != nil {
+		// A request that was itself cancelled says nothing about Redis
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		l.markDown(err)
+		return l.fallback.Allow(ctx, policy, key)
+	}
+	l.markUp()
+	return result, nil
+}
+
+func (l *fallbackLimiter) skipPrimary() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.down && l.now().Before(l.downUntil)
+}
+
+func (l *fallbackLimiter) markDown(err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.down {
+		l.logger.Warn("Rate limiter falling back to in-memory counters", slog.Any("error", err))
+	}
+	l.down = true
+	l.downUntil = l.now().Add(fallbackCooldown)
+}
+
+func (l *fallbackLimiter) markUp() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.down {
+		l.logger.Info("Rate limiter using Redis again")
+	}
+	l.down = false
+}
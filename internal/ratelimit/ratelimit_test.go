@@ -0,0 +1,85 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+// stubLimiter allows every request, or fails with err when it is set
+type stubLimiter struct {
+	err   error
+	calls int
+}
+
+func (l *stubLimiter) Allow(ctx context.Context, policy Policy, key string) (*Result, error) {
+	l.calls++
+	if l.err != nil {
+		return nil, l.err
+	}
+	return &Result{Allowed: true, Limit: policy.Limit, Remaining: policy.Limit - 1}, nil
+}
+
+func TestFallbackLimiter(t *testing.T) {
+	policy := Policy{Name: "signin", Algorithm: AlgorithmSlidingWindow, Limit: 1, Window: time.Minute}
+	ctx := context.Background()
+	c := newClock()
+	primary := &stubLimiter{err: errors.New("connection refused")}
+	l := &fallbackLimiter{
+		primary:  primary,
+		fallback: newTestMemoryLimiter(c),
+		logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+		now:      c.now,
+	}
+
+	// The memory limiter takes over and enforces the limit
+	for i, want := range []bool{true, false} {
+		result, err := l.Allow(ctx, policy, "ip:1")
+		if err != nil {
+			t.Fatalf("call %d: Allow() error = %v", i, err)
+		}
+		if result.Allowed != want {
+			t.Fatalf("call %d: allowed = %v, want %v", i, result.Allowed, want)
+		}
+	}
+	if primary.calls != 1 {
+		t.Fatalf("primary called %d times during the cooldown, want 1", primary.calls)
+	}
+
+	// After the cooldown Redis is tried again, and used once it answers
+	primary.err = nil
+	c.advance(fallbackCooldown)
+	result, err := l.Allow(ctx, policy, "ip:1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Allowed || primary.calls != 2 {
+		t.Fatalf("after the cooldown: allowed = %v, primary calls = %d; want true, 2", result.Allowed, primary.calls)
+	}
+	if l.down {
+		t.Error("limiter still marked down after Redis answered")
+	}
+}
+
+func TestFallbackLimiterIgnoresCancelledRequests(t *testing.T) {
+	policy := Policy{Name: "signin", Algorithm: AlgorithmSlidingWindow, Limit: 1, Window: time.Minute}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c := newClock()
+	l := &fallbackLimiter{
+		primary:  &stubLimiter{err: context.Canceled},
+		fallback: newTestMemoryLimiter(c),
+		logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+		now:      c.now,
+	}
+
+	if _, err := l.Allow(ctx, policy, "ip:1"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Allow() error = %v, want context.Canceled", err)
+	}
+	if l.down {
+		t.Error("a cancelled request marked Redis down")
+	}
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jixlox0/studoto-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+type AccessTokenRepository interface {
+	Create(ctx context.Context, token *models.AccessToken) error
+	FindByHash(ctx context.Context, tokenHash string) (*models.AccessToken, error)
+	FindByUUID(ctx context.Context, userID uint, tokenUUID string) (*models.AccessToken, error)
+	ListByUser(ctx context.Context, userID uint) ([]models.AccessToken, error)
+	Revoke(ctx context.Context, token *models.AccessToken) error
+	TouchLastUsed(ctx context.Context, id uint, usedAt time.Time) error
+	DeleteByUser(ctx context.Context, userID uint) error
+}
+
+type accessTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewAccessTokenRepository(db *gorm.DB) AccessTokenRepository {
+	return &accessTokenRepository{db: db}
+}
+
+func (r *accessTokenRepository) Create(ctx context.Context, token *models.AccessToken) error {
+	return translateError(conn(ctx, r.db).Create(token).Error)
+}
+
+func (r *accessTokenRepository) FindByHash(ctx context.Context, tokenHash string) (*models.AccessToken, error) {
+	var token models.AccessToken
+	if err := conn(ctx, r.db).Preload("User").Where("token_hash = ?", tokenHash).First(&token).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &token, nil
+}
+
+func (r *accessTokenRepository) FindByUUID(ctx context.Context, userID uint, tokenUUID string) (*models.AccessToken, error) {
+	var token models.AccessToken
+	if err := conn(ctx, r.db).Where("user_id = ? AND uuid = ?", userID, tokenUUID).First(&token).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &token, nil
+}
+
+func (r *accessTokenRepository) ListByUser(ctx context.Context, userID uint) ([]models.AccessToken, error) {
+	var tokens []models.AccessToken
+	if err := conn(ctx, r.db).Where("user_id = ?", userID).Order("created_at DESC").Find(&tokens).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return tokens, nil
+}
+
+func (r *accessTokenRepository) Revoke(ctx context.Context, token *models.AccessToken) error {
+	now := time.Now()
+	token.RevokedAt = &now
+	token.UpdatedAt = now
+
+	err := conn(ctx, r.db).Model(token).Updates(map[string]any{
+		"revoked_at": now,
+		"updated_at": now,
+	}).Error
+	return translateError(err)
+}
+
+// TouchLastUsed records when a token was last presented. It skips the
+// updated_at bump so that bookkeeping writes don't look like edits.
+func (r *accessTokenRepository) TouchLastUsed(ctx context.Context, id uint, usedAt time.Time) error {
+	err := conn(ctx, r.db).Model(&models.AccessToken{}).
+		Where("id = ?", id).
+		UpdateColumn("last_used_at", usedAt).Error
+	return translateError(err)
+}
+
+func (r *accessTokenRepository) DeleteByUser(ctx context.Context, userID uint) error {
+	return translateError(conn(ctx, r.db).Where("user_id = ?", userID).Delete(&models.AccessToken{}).Error)
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"github.com/jixlox0/studoto-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+// AuditEventRepository stores audit events. It is append-only by design:
+// events can be created and queried but never deleted, and the only update
+// is the anonymization required when a user's account is erased.
+type AuditEventRepository interface {
+	Create(ctx context.Context, event *models.AuditEvent) error
+	List(ctx context.Context, filter *models.AuditEventFilter) ([]models.AuditEvent, int64, error)
+	ListByUser(ctx context.Context, userUUID string, limit int) ([]models.AuditEvent, error)
+	ListAllByUser(ctx context.Context, userUUID string) ([]models.AuditEvent, error)
+	AnonymizeUser(ctx context.Context, userUUID, email string) error
+}
+
+type auditEventRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditEventRepository(db *gorm.DB) AuditEventRepository {
+	return &auditEventRepository{db: db}
+}
+
+func (r *auditEventRepository) Create(ctx context.Context, event *models.AuditEvent) error {
+	return translateError(conn(ctx, r.db).Create(event).Error)
+}
+
+func (r *auditEventRepository) List(ctx context.Context, filter *models.AuditEventFilter) ([]models.AuditEvent, int64, error) {
+	query := conn(ctx, r.db).Model(&models.AuditEvent{})
+	if filter.ActorID != "" {
+		query = query.Where("actor_id = ?", filter.ActorID)
+	}
+	if filter.TargetID != "" {
+		query = query.Where("target_id = ?", filter.TargetID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, translateError(err)
+	}
+
+	var events []models.AuditEvent
+	offset := (filter.Page - 1) * filter.PageSize
+	if err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(filter.PageSize).Find(&events).Error; err != nil {
+		return nil, 0, translateError(err)
+	}
+	return events, total, nil
+}
+
+// ListByUser returns the most recent events in which the user was either the
+// actor or the target.
+func (r *auditEventRepository) ListByUser(ctx context.Context, userUUID string, limit int) ([]models.AuditEvent, error) {
+	var events []models.AuditEvent
+	if err := conn(ctx, r.db).Where("actor_id = ? OR target_id = ?", userUUID, userUUID).
+		Order("created_at DESC, id DESC").
+		Limit(limit).
+		Find(&events).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return events, nil
+}
+
+func (r *auditEventRepository) ListAllByUser(ctx context.Context, userUUID string) ([]models.AuditEvent, error) {
+	var events []models.AuditEvent
+	if err := conn(ctx, r.db).Where("actor_id = ? OR target_id = ?", userUUID, userUUID).
+		Order("created_at ASC, id ASC").
+		Find(&events).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return events, nil
+}
+
+// AnonymizeUser strips the user's identifier, client details and metadata
+// from every event that involves them, leaving the action and timestamp.
+// Events that only name the user by email in their metadata, such as failed
+// sign-ins recorded before emails were left out, are scrubbed as well.
+func (r *auditEventRepository) AnonymizeUser(ctx context.Context, userUUID, email string) error {
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		scrub := map[string]any{
+			"ip":         "",
+			"user_agent": "",
+			"metadata":   models.JSONMap{},
+		}
+		if err := tx.Model(&models.AuditEvent{}).
+			Where("actor_id = ? OR target_id = ? OR lower(metadata->>'email') = lower(?)", userUUID, userUUID, email).
+			Updates(scrub).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.AuditEvent{}).
+			Where("actor_id = ?", userUUID).
+			Update("actor_id", models.AnonymizedUserID).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.AuditEvent{}).
+			Where("target_id = ?", userUUID).
+			Update("target_id", models.AnonymizedUserID).Error
+	})
+	return translateError(err)
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// Errors returned by every repository in place of driver and GORM errors, so
+// that callers can tell a missing row from a duplicate or a broken database.
+// Conflict and unavailable errors keep the database error in their chain for
+// logging.
+var (
+	ErrNotFound    = errors.New("record not found")
+	ErrConflict    = errors.New("record conflicts with an existing one")
+	ErrUnavailable = errors.New("database unavailable")
+)
+
+// Postgres error codes and classes that translateError recognises; see
+// https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	pgUniqueViolation      = "23505"
+	pgClassConnection      = "08"
+	pgClassInsufficientRes = "53"
+	pgAdminShutdown        = "57P01"
+	pgCrashShutdown        = "57P02"
+	pgCannotConnectNow     = "57P03"
+)
+
+// translateError maps err to ErrNotFound, ErrConflict or ErrUnavailable where
+// it is one of those, and returns it unchanged otherwise. Context errors are
+// left alone so that timeouts and cancellations are reported as such.
+func translateError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, ErrNotFound), errors.Is(err, ErrConflict), errors.Is(err, ErrUnavailable):
+		return err
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return err
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ErrNotFound
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case pgErr.Code == pgUniqueViolation:
+			return fmt.Errorf("%w: %s: %w", ErrConflict, pgErr.ConstraintName, err)
+		case strings.HasPrefix(pgErr.Code, pgClassConnection),
+			strings.HasPrefix(pgErr.Code, pgClassInsufficientRes),
+			pgErr.Code == pgAdminShutdown, pgErr.Code == pgCrashShutdown, pgErr.Code == pgCannotConnectNow:
+			return fmt.Errorf("%w: %w", ErrUnavailable, err)
+		}
+		return err
+	}
+
+	var connectErr *pgconn.ConnectError
+	var netErr net.Error
+	if errors.As(err, &connectErr) || errors.As(err, &netErr) ||
+		errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) {
+		return fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+	return err
+}
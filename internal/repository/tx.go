@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"math/rand"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// Postgres error codes of transactions that failed only because of concurrent
+// transactions, and succeed when retried
+const (
+	pgSerializationFailure = "40001"
+	pgDeadlockDetected     = "40P01"
+)
+
+const (
+	defaultTxAttempts = 3
+	txRetryBaseDelay  = 10 * time.Millisecond
+)
+
+// TxManager runs several repository calls atomically. The transaction travels
+// in the context: every repository method called with the context passed to
+// fn runs inside it, so services need no transaction-specific repositories.
+type TxManager interface {
+	// WithinTx runs fn in a transaction, committed if fn returns nil and
+	// rolled back otherwise. Called with a context that already carries a
+	// transaction, fn joins it instead.
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+	// WithinSerializableTx runs fn in a serializable transaction, retrying
+	// it from the start when Postgres aborts it because of a concurrent
+	// transaction. fn may therefore run more than once and should have no
+	// side effects outside the database.
+	WithinSerializableTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type txManager struct {
+	db          *gorm.DB
+	maxAttempts int
+}
+
+func NewTxManager(db *gorm.DB) TxManager {
+	return &txManager{db: db, maxAttempts: defaultTxAttempts}
+}
+
+type txKey struct{}
+
+func (m *txManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return m.run(ctx, nil, fn)
+}
+
+func (m *txManager) WithinSerializableTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	// Retrying inside an outer transaction would repeat only part of it, so
+	// joined transactions leave retries to the outermost caller
+	if _, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return fn(ctx)
+	}
+
+	opts := &sql.TxOptions{Isolation: sql.LevelSerializable}
+	var err error
+	for attempt := 1; attempt <= m.maxAttempts; attempt++ {
+		if err = m.run(ctx, opts, fn); !isRetryable(err) {
+			return err
+		}
+		if attempt < m.maxAttempts {
+			// Jitter keeps the conflicting transactions from colliding again
+			delay := time.Duration(attempt)*txRetryBaseDelay + time.Duration(rand.Int63n(int64(txRetryBaseDelay)))
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(delay):
+			}
+		}
+	}
+	return err
+}
+
+func (m *txManager) run(ctx context.Context, opts *sql.TxOptions, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return fn(ctx)
+	}
+
+	var txOpts []*sql.TxOptions
+	if opts != nil {
+		txOpts = append(txOpts, opts)
+	}
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	}, txOpts...)
+	return translateError(err)
+}
+
+// conn returns the transaction carried by ctx, or db outside a transaction,
+// bound to ctx.
+func conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
+
+func isRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && (pgErr.Code == pgSerializationFailure || pgErr.Code == pgDeadlockDetected)
+}
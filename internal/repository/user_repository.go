@@ -1,19 +1,27 @@
 package repository
 
 import (
-	"errors"
+	"context"
 	"time"
 
 	"github.com/jixlox0/studoto-backend/internal/models"
+	"github.com/jixlox0/studoto-backend/internal/pagination"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type UserRepository interface {
-	Create(user *models.User) error
-	FindByEmail(email string) (*models.User, error)
-	FindByID(id uint) (*models.UserResponse, error)
-	FindByProvider(provider, providerID string) (*models.User, error)
-	Update(user *models.User) error
+	Create(ctx context.Context, user *models.User) error
+	FindByEmail(ctx context.Context, email string) (*models.User, error)
+	FindByID(ctx context.Context, id uint) (*models.User, error)
+	FindByUUID(ctx context.Context, uuid string) (*models.User, error)
+	FindByProvider(ctx context.Context, provider, providerID string) (*models.User, error)
+	Update(ctx context.Context, user *models.User) error
+	FindDueForDeletion(ctx context.Context, now time.Time) ([]models.User, error)
+	LockDueForDeletion(ctx context.Context, uuid string, now time.Time) (*models.User, error)
+	CancelDeletion(ctx context.Context, uuid string) error
+	HardDelete(ctx context.Context, user *models.User) error
+	List(ctx context.Context, params *pagination.Params) (*pagination.Page[models.User], error)
 }
 
 type userRepository struct {
@@ -24,7 +32,7 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 	return &userRepository{db: db}
 }
 
-func (r *userRepository) Create(user *models.User) error {
+func (r *userRepository) Create(ctx context.Context, user *models.User) error {
 	// Ensure timestamps are set if they're zero
 	now := time.Now()
 	if user.CreatedAt.IsZero() {
@@ -34,58 +42,108 @@ func (r *userRepository) Create(user *models.User) error {
 		user.UpdatedAt = now
 	}
 
-	if err := r.db.Create(user).Error; err != nil {
-		return err
+	if err := conn(ctx, r.db).Create(user).Error; err != nil {
+		return translateError(err)
 	}
 	return nil
 }
 
-func (r *userRepository) FindByEmail(email string) (*models.User, error) {
+func (r *userRepository) FindByEmail(ctx context.Context, email string) (*models.User, error) {
 	var user models.User
-	if err := r.db.Where("email = ?", email).First(&user).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("user not found")
-		}
-		return nil, err
+	if err := conn(ctx, r.db).Where("email = ?", email).First(&user).Error; err != nil {
+		return nil, translateError(err)
 	}
 	return &user, nil
 }
 
-func (r *userRepository) FindByID(id uint) (*models.UserResponse, error) {
+func (r *userRepository) FindByID(ctx context.Context, id uint) (*models.User, error) {
 	var user models.User
-	if err := r.db.Where("id = ?", id).First(&user).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("user not found")
-		}
-		return nil, err
+	if err := conn(ctx, r.db).Where("id = ?", id).First(&user).Error; err != nil {
+		return nil, translateError(err)
 	}
+	return &user, nil
+}
 
-	return &models.UserResponse{
-		Email:     user.Email,
-		Name:      user.Name,
-		AvatarURL: user.AvatarURL,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
-	}, nil
+func (r *userRepository) FindByUUID(ctx context.Context, uuid string) (*models.User, error) {
+	var user models.User
+	if err := conn(ctx, r.db).Where("uuid = ?", uuid).First(&user).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return &user, nil
 }
 
-func (r *userRepository) FindByProvider(provider, providerID string) (*models.User, error) {
+func (r *userRepository) FindByProvider(ctx context.Context, provider, providerID string) (*models.User, error) {
 	var user models.User
-	if err := r.db.Where("provider = ? AND provider_id = ?", provider, providerID).First(&user).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("user not found")
-		}
-		return nil, err
+	if err := conn(ctx, r.db).Where("provider = ? AND provider_id = ?", provider, providerID).First(&user).Error; err != nil {
+		return nil, translateError(err)
 	}
 	return &user, nil
 }
 
-func (r *userRepository) Update(user *models.User) error {
+func (r *userRepository) Update(ctx context.Context, user *models.User) error {
 	// Ensure UpdatedAt is set
 	user.UpdatedAt = time.Now()
 
-	if err := r.db.Save(user).Error; err != nil {
-		return err
+	if err := conn(ctx, r.db).Save(user).Error; err != nil {
+		return translateError(err)
 	}
 	return nil
 }
+
+// FindDueForDeletion returns users whose deletion grace period has ended.
+func (r *userRepository) FindDueForDeletion(ctx context.Context, now time.Time) ([]models.User, error) {
+	var users []models.User
+	if err := conn(ctx, r.db).Unscoped().Where("deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= ?", now).Find(&users).Error; err != nil {
+		return nil, translateError(err)
+	}
+	return users, nil
+}
+
+// LockDueForDeletion locks the user's row for the rest of the transaction
+// carried by ctx, provided its deletion is still due. It returns ErrNotFound
+// when the deletion was cancelled or the row is locked by another transaction,
+// such as another instance purging it.
+func (r *userRepository) LockDueForDeletion(ctx context.Context, uuid string, now time.Time) (*models.User, error) {
+	var user models.User
+	err := conn(ctx, r.db).Unscoped().
+		Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("uuid = ? AND deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= ?", uuid, now).
+		First(&user).Error
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return &user, nil
+}
+
+// CancelDeletion clears the user's deletion schedule. It returns ErrNotFound
+// when no deletion is pending, including when the account was purged first.
+func (r *userRepository) CancelDeletion(ctx context.Context, uuid string) error {
+	result := conn(ctx, r.db).Model(&models.User{}).
+		Where("uuid = ? AND deletion_scheduled_at IS NOT NULL", uuid).
+		Updates(map[string]any{
+			"deletion_requested_at": nil,
+			"deletion_scheduled_at": nil,
+			"updated_at":            time.Now(),
+		})
+	if result.Error != nil {
+		return translateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// HardDelete permanently removes the user row, bypassing soft deletion.
+func (r *userRepository) HardDelete(ctx context.Context, user *models.User) error {
+	return translateError(conn(ctx, r.db).Unscoped().Delete(user).Error)
+}
+
+// List returns one page of users.
+func (r *userRepository) List(ctx context.Context, params *pagination.Params) (*pagination.Page[models.User], error) {
+	page, err := pagination.Find[models.User](conn(ctx, r.db).Model(&models.User{}), params)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return page, nil
+}
@@ -0,0 +1,124 @@
+// Package seed fills a development or demo database with sample data. Data is
+// grouped into named sets that can be run on their own or together; every set
+// is idempotent, so running it again leaves existing rows as they are and only
+// adds what is missing.
+package seed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jixlox0/studoto-backend/internal/config"
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrProduction is returned when seeding is attempted in production, or
+	// in any environment not known to be safe to seed.
+	ErrProduction = errors.New("seeding is disabled in production")
+	// ErrStaging is returned when seeding staging was not explicitly allowed.
+	ErrStaging = errors.New("seeding staging must be allowed explicitly")
+)
+
+// Guard reports whether env may be seeded. Development and test may always
+// be seeded and staging only with allowStaging; every other environment,
+// however it is spelled, is treated as production.
+func Guard(env string, allowStaging bool) error {
+	switch strings.ToLower(strings.TrimSpace(env)) {
+	case config.EnvDevelopment, config.EnvTest:
+		return nil
+	case config.EnvStaging:
+		if allowStaging {
+			return nil
+		}
+		return ErrStaging
+	}
+	return ErrProduction
+}
+
+// Set creates one group of sample data.
+type Set interface {
+	// Name identifies the set on the command line, e.g. "admin".
+	Name() string
+	// Description says what the set creates.
+	Description() string
+	// Run creates the set's data in db, skipping rows that already exist.
+	Run(ctx context.Context, db *gorm.DB) error
+}
+
+// Registry holds the registered sets in registration order.
+type Registry struct {
+	sets []Set
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds a set. Sets run in registration order, so a set should be
+// registered after the sets whose data it refers to.
+func (r *Registry) Register(set Set) {
+	r.sets = append(r.sets, set)
+}
+
+// Sets returns the registered sets in registration order.
+func (r *Registry) Sets() []Set {
+	return r.sets
+}
+
+// Run runs the named sets, or every set when names is empty, each in its own
+// transaction. Whatever the caller has checked, it refuses to run in any
+// environment but development, test and staging; whether staging may be
+// seeded is left to the caller's Guard.
+func (r *Registry) Run(ctx context.Context, db *gorm.DB, env string, names ...string) error {
+	if err := Guard(env, true); err != nil {
+		return err
+	}
+
+	sets, err := r.selectSets(names)
+	if err != nil {
+		return err
+	}
+	for _, set := range sets {
+		err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return set.Run(ctx, tx)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to seed %s: %w", set.Name(), err)
+		}
+	}
+	return nil
+}
+
+// selectSets returns the named sets in registration order, regardless of the
+// order they were named in.
+func (r *Registry) selectSets(names []string) ([]Set, error) {
+	if len(names) == 0 {
+		return r.sets, nil
+	}
+
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+	var sets []Set
+	for _, set := range r.sets {
+		if wanted[set.Name()] {
+			sets = append(sets, set)
+			delete(wanted, set.Name())
+		}
+	}
+	if len(wanted) > 0 {
+		unknown := make([]string, 0, len(wanted))
+		for _, name := range names {
+			if wanted[name] {
+				unknown = append(unknown, name)
+				delete(wanted, name)
+			}
+		}
+		return nil, fmt.Errorf("unknown seed set(s): %s", strings.Join(unknown, ", "))
+	}
+	return sets, nil
+}
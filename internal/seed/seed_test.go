@@ -0,0 +1,29 @@
+package seed
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestGuard(t *testing.T) {
+	tests := []struct {
+		env          string
+		allowStaging bool
+		want         error
+	}{
+		{env: "development"},
+		{env: "Test"},
+		{env: "staging", want: ErrStaging},
+		{env: "staging", allowStaging: true},
+		{env: "production", want: ErrProduction},
+		{env: "Production", allowStaging: true, want: ErrProduction},
+		{env: " PRODUCTION ", want: ErrProduction},
+		{env: "prod", want: ErrProduction},
+		{env: "", want: ErrProduction},
+	}
+	for _, tt := range tests {
+		if err := Guard(tt.env, tt.allowStaging); !errors.Is(err, tt.want) {
+			t.Errorf("Guard(%q, %v) = %v, want %v", tt.env, tt.allowStaging, err, tt.want)
+		}
+	}
+}
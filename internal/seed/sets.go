@@ -0,0 +1,277 @@
+package seed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jixlox0/studoto-backend/internal/models"
+	"github.com/jixlox0/studoto-backend/pkg/uuid"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StudentEmailDomain is the domain of the sample students' addresses; it is
+// reserved, so mail to the students never leaves the machine.
+const StudentEmailDomain = "demo.studoto.test"
+
+// DemoSchoolSlug identifies the demo school, which holds the sample courses.
+const DemoSchoolSlug = "demo"
+
+// Options configures the default sets.
+type Options struct {
+	AdminEmail      string
+	AdminPassword   string
+	StudentPassword string
+	Students        int
+}
+
+// DefaultOptions returns the credentials documented for local development.
+func DefaultOptions() Options {
+	return Options{
+		AdminEmail:      "admin@studoto.test",
+		AdminPassword:   "studoto-admin",
+		StudentPassword: "studoto-student",
+		Students:        10,
+	}
+}
+
+// NewDefaultRegistry returns a registry with every built-in set.
+func NewDefaultRegistry(opts Options) *Registry {
+	registry := NewRegistry()
+	registry.Register(&adminSet{email: opts.AdminEmail, password: opts.AdminPassword})
+	registry.Register(schoolSet{})
+	registry.Register(&studentsSet{count: opts.Students, password: opts.StudentPassword})
+	registry.Register(contentSet{})
+	return registry
+}
+
+// ErrAdminEmailTaken is returned when the administrator's address belongs to
+// an account that is not an administrator. It is not promoted, since anyone
+// could have signed up with the address before seeding.
+var ErrAdminEmailTaken = errors.New("admin email belongs to an account that is not an administrator")
+
+// adminSet creates an administrator. An existing administrator with the
+// address is restored if it was deleted, and keeps its password.
+type adminSet struct {
+	email    string
+	password string
+}
+
+func (s *adminSet) Name() string {
+	return "admin"
+}
+
+func (s *adminSet) Description() string {
+	return fmt.Sprintf("administrator account %s", s.email)
+}
+
+func (s *adminSet) Run(ctx context.Context, db *gorm.DB) error {
+	existing, err := findUser(db, s.email)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		if existing.Role != models.RoleAdmin {
+			return fmt.Errorf("%w: %s", ErrAdminEmailTaken, s.email)
+		}
+		return db.Unscoped().Model(existing).Updates(map[string]any{
+			"deleted_at":            nil,
+			"deletion_requested_at": nil,
+			"deletion_scheduled_at": nil,
+		}).Error
+	}
+
+	hash, err := hash(s.password)
+	if err != nil {
+		return err
+	}
+	return db.Create(newUser(s.email, "Studoto Admin", models.RoleAdmin, hash)).Error
+}
+
+// schoolSet creates the demo school.
+type schoolSet struct{}
+
+func (schoolSet) Name() string {
+	return "school"
+}
+
+func (schoolSet) Description() string {
+	return fmt.Sprintf("demo school %q", DemoSchoolSlug)
+}
+
+func (schoolSet) Run(ctx context.Context, db *gorm.DB) error {
+	_, err := demoSchool(db)
+	return err
+}
+
+// demoSchool returns the demo school, creating it or restoring it if needed.
+func demoSchool(db *gorm.DB) (*models.School, error) {
+	var school models.School
+	err := db.Unscoped().Where("slug = ?", DemoSchoolSlug).First(&school).Error
+	if err == nil {
+		if school.DeletedAt.Valid {
+			err = db.Unscoped().Model(&school).Update("deleted_at", nil).Error
+		}
+		return &school, err
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	now := time.Now()
+	school = models.School{
+		UUID:      uuid.Generate(uuid.PrefixSchool),
+		Slug:      DemoSchoolSlug,
+		Name:      "Studoto Demo School",
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	return &school, db.Create(&school).Error
+}
+
+// sampleCourses are the courses of the demo school.
+var sampleCourses = []models.Course{
+	{Slug: "intro-programming", Title: "Introduction to Programming",
+		Description: "Variables, control flow and functions, with short exercises after every lesson."},
+	{Slug: "algorithms", Title: "Algorithms and Data Structures",
+		Description: "Sorting, searching, graphs and the complexity of each."},
+	{Slug: "databases", Title: "Relational Databases",
+		Description: "Modelling data, writing SQL queries and keeping them fast."},
+	{Slug: "web-development", Title: "Web Development",
+		Description: "HTTP, HTML and building a small web application end to end."},
+}
+
+// contentSet creates the sample courses in the demo school, creating the
+// school first if the school set has not run.
+type contentSet struct{}
+
+func (contentSet) Name() string {
+	return "content"
+}
+
+func (contentSet) Description() string {
+	return fmt.Sprintf("%d sample courses in the demo school", len(sampleCourses))
+}
+
+func (contentSet) Run(ctx context.Context, db *gorm.DB) error {
+	school, err := demoSchool(db)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, sample := range sampleCourses {
+		var count int64
+		if err := db.Unscoped().Model(&models.Course{}).
+			Where("school_id = ? AND slug = ?", school.ID, sample.Slug).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+
+		course := sample
+		course.UUID = uuid.Generate(uuid.PrefixCourse)
+		course.SchoolID = school.ID
+		course.CreatedAt = now
+		course.UpdatedAt = now
+		if err := db.Omit(clause.Associations).Create(&course).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// studentNames are the sample students, in the order they are numbered.
+var studentNames = []string{
+	"Ada Lovelace",
+	"Alan Turing",
+	"Grace Hopper",
+	"Edsger Dijkstra",
+	"Barbara Liskov",
+	"Donald Knuth",
+	"Margaret Hamilton",
+	"Ken Thompson",
+	"Frances Allen",
+	"John McCarthy",
+}
+
+// studentsSet creates sample students named student01@demo.studoto.test and
+// so on, sharing one password.
+type studentsSet struct {
+	count    int
+	password string
+}
+
+func (s *studentsSet) Name() string {
+	return "students"
+}
+
+func (s *studentsSet) Description() string {
+	return fmt.Sprintf("%d sample students student01@%s and up", s.count, StudentEmailDomain)
+}
+
+func (s *studentsSet) Run(ctx context.Context, db *gorm.DB) error {
+	// Hashing is deliberately slow, so every student shares one hash
+	var passwordHash string
+	for i := 1; i <= s.count; i++ {
+		email := fmt.Sprintf("student%02d@%s", i, StudentEmailDomain)
+		existing, err := findUser(db, email)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			continue
+		}
+
+		if passwordHash == "" {
+			if passwordHash, err = hash(s.password); err != nil {
+				return err
+			}
+		}
+		name := studentNames[(i-1)%len(studentNames)]
+		if err := db.Create(newUser(email, name, models.RoleUser, passwordHash)).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// findUser looks up a user by email, including soft-deleted ones, since
+// their address is still taken. It returns nil if there is none.
+func findUser(db *gorm.DB, email string) (*models.User, error) {
+	var user models.User
+	err := db.Unscoped().Where("email = ?", email).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func newUser(email, name, role, passwordHash string) *models.User {
+	now := time.Now()
+	return &models.User{
+		UUID:         uuid.Generate(uuid.PrefixUser),
+		Email:        email,
+		Name:         name,
+		Role:         role,
+		PasswordHash: passwordHash,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+}
+
+func hash(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashed), nil
+}
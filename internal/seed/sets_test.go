@@ -0,0 +1,90 @@
+package seed_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"testing"
+
+	"github.com/jixlox0/studoto-backend/internal/database/dbtest"
+	"github.com/jixlox0/studoto-backend/internal/models"
+	"github.com/jixlox0/studoto-backend/internal/seed"
+	"gorm.io/gorm"
+)
+
+// countRows returns the number of rows of each seeded table
+func countRows(t *testing.T, tx *gorm.DB) map[string]int64 {
+	t.Helper()
+	counts := make(map[string]int64)
+	for _, model := range []any{&models.User{}, &models.School{}, &models.Course{}} {
+		var n int64
+		if err := tx.Unscoped().Model(model).Count(&n).Error; err != nil {
+			t.Fatal(err)
+		}
+		counts[fmt.Sprintf("%T", model)] = n
+	}
+	return counts
+}
+
+func findUser(t *testing.T, tx *gorm.DB, email string) *models.User {
+	t.Helper()
+	var user models.User
+	if err := tx.Unscoped().Where("email = ?", email).First(&user).Error; err != nil {
+		t.Fatalf("user %s: %v", email, err)
+	}
+	return &user
+}
+
+func TestSeedIsIdempotent(t *testing.T) {
+	tx := dbtest.Tx(t)
+
+	dbtest.Seed(t, tx)
+	seeded := countRows(t, tx)
+	dbtest.Seed(t, tx)
+
+	if again := countRows(t, tx); !maps.Equal(again, seeded) {
+		t.Fatalf("rows after seeding twice = %v, want %v", again, seeded)
+	}
+
+	var school models.School
+	if err := tx.Where("slug = ?", seed.DemoSchoolSlug).First(&school).Error; err != nil {
+		t.Fatalf("demo school: %v", err)
+	}
+	var courses int64
+	if err := tx.Model(&models.Course{}).Where("school_id = ?", school.ID).Count(&courses).Error; err != nil {
+		t.Fatal(err)
+	}
+	if courses == 0 {
+		t.Error("the demo school has no courses")
+	}
+}
+
+func TestAdminSet(t *testing.T) {
+	tx := dbtest.Tx(t)
+	dbtest.LoadFixtures(t, tx, os.DirFS("testdata"), "admins.yaml")
+
+	run := func(email string) error {
+		opts := seed.DefaultOptions()
+		opts.AdminEmail = email
+		return seed.NewDefaultRegistry(opts).Run(context.Background(), tx, dbtest.Env(t), "admin")
+	}
+
+	// A deleted administrator is restored and keeps its password
+	if err := run("deleted-admin@fixtures.studoto.test"); err != nil {
+		t.Fatalf("seeding a deleted admin: %v", err)
+	}
+	admin := findUser(t, tx, "deleted-admin@fixtures.studoto.test")
+	if admin.DeletedAt.Valid || admin.DeletionScheduledAt != nil || admin.PasswordHash != "kept-hash" {
+		t.Errorf("deleted admin = %+v, want restored with its password", admin)
+	}
+
+	// Someone who signed up with the address first is not promoted
+	if err := run("squatter@fixtures.studoto.test"); !errors.Is(err, seed.ErrAdminEmailTaken) {
+		t.Fatalf("seeding over a user: error = %v, want %v", err, seed.ErrAdminEmailTaken)
+	}
+	if user := findUser(t, tx, "squatter@fixtures.studoto.test"); user.Role != models.RoleUser {
+		t.Errorf("user role = %q, want it left as %q", user.Role, models.RoleUser)
+	}
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/jixlox0/studoto-backend/internal/errors"
+	"github.com/jixlox0/studoto-backend/internal/models"
+	"github.com/jixlox0/studoto-backend/internal/repository"
+	"github.com/jixlox0/studoto-backend/pkg/uuid"
+)
+
+// lastUsedResolution limits how often last_used_at is written for a token
+// that is used in quick succession.
+const lastUsedResolution = time.Minute
+
+// Grantor is the caller creating an access token, such as the request's
+// principal. Tokens carry no scope their creator does not hold.
+type Grantor interface {
+	HasScope(scope string) bool
+}
+
+type AccessTokenService interface {
+	Create(ctx context.Context, userUUID string, grantor Grantor, req *models.CreateAccessTokenRequest) (*models.CreatedAccessTokenResponse, error)
+	List(ctx context.Context, userUUID string) ([]*models.AccessTokenResponse, error)
+	Revoke(ctx context.Context, userUUID string, tokenUUID string) error
+	Authenticate(ctx context.Context, plaintext string) (*models.AccessToken, error)
+}
+
+type accessTokenService struct {
+	tokenRepo repository.AccessTokenRepository
+	userRepo  repository.UserRepository
+}
+
+func NewAccessTokenService(tokenRepo repository.AccessTokenRepository, userRepo repository.UserRepository) AccessTokenService {
+	return &accessTokenService{
+		tokenRepo: tokenRepo,
+		userRepo:  userRepo,
+	}
+}
+
+// Create issues a token for the user with the requested scopes. A token
+// cannot widen the access of the one used to create it, and only
+// administrators may grant the admin scope.
+func (s *accessTokenService) Create(ctx context.Context, userUUID string, grantor Grantor, req *models.CreateAccessTokenRequest) (*models.CreatedAccessTokenResponse, error) {
+	scopes, err := normalizeScopes(req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.FindByUUID(ctx, userUUID)
+	if err != nil {
+		return nil, notFoundAs(err, errors.ErrUserNotFound)
+	}
+
+	for _, scope := range scopes {
+		if !grantor.HasScope(scope) || scope == models.ScopeAdmin && user.Role != models.RoleAdmin {
+			return nil, errors.ErrInsufficientScope
+		}
+	}
+
+	plaintext, err := generateAccessToken()
+	if err != nil {
+		return nil, err
+	}
+
+	token := &models.AccessToken{
+		UUID:      uuid.Generate(uuid.PrefixToken),
+		UserID:    user.ID,
+		Name:      strings.TrimSpace(req.Name),
+		TokenHash: HashAccessToken(plaintext),
+		Hint:      plaintext[len(plaintext)-4:],
+		Scopes:    strings.Join(scopes, ","),
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		token.ExpiresAt = &expiresAt
+	}
+
+	if err := s.tokenRepo.Create(ctx, token); err != nil {
+		return nil, err
+	}
+
+	return &models.CreatedAccessTokenResponse{
+		AccessTokenResponse: models.NewAccessTokenResponse(token),
+		Token:               plaintext,
+	}, nil
+}
+
+func (s *accessTokenService) List(ctx context.Context, userUUID string) ([]*models.AccessTokenResponse, error) {
+	user, err := s.userRepo.FindByUUID(ctx, userUUID)
+	if err != nil {
+		return nil, notFoundAs(err, errors.ErrUserNotFound)
+	}
+
+	tokens, err := s.tokenRepo.ListByUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*models.AccessTokenResponse, 0, len(tokens))
+	for i := range tokens {
+		responses = append(responses, models.NewAccessTokenResponse(&tokens[i]))
+	}
+	return responses, nil
+}
+
+func (s *accessTokenService) Revoke(ctx context.Context, userUUID string, tokenUUID string) error {
+	user, err := s.userRepo.FindByUUID(ctx, userUUID)
+	if err != nil {
+		return notFoundAs(err, errors.ErrUserNotFound)
+	}
+
+	token, err := s.tokenRepo.FindByUUID(ctx, user.ID, tokenUUID)
+	if err != nil {
+		return notFoundAs(err, errors.ErrAccessTokenNotFound)
+	}
+	if token.IsRevoked() {
+		return nil
+	}
+	return s.tokenRepo.Revoke(ctx, token)
+}
+
+// Authenticate resolves a plaintext personal access token to its stored record,
+// rejecting revoked and expired tokens and recording the time of use.
+func (s *accessTokenService) Authenticate(ctx context.Context, plaintext string) (*models.AccessToken, error) {
+	if !strings.HasPrefix(plaintext, models.AccessTokenPrefix) {
+		return nil, errors.ErrInvalidToken
+	}
+
+	token, err := s.tokenRepo.FindByHash(ctx, HashAccessToken(plaintext))
+	if err != nil {
+		return nil, notFoundAs(err, errors.ErrInvalidToken)
+	}
+	if token.User.ID == 0 {
+		return nil, errors.ErrInvalidToken
+	}
+
+	now := time.Now()
+	if token.IsRevoked() {
+		return nil, errors.ErrAccessTokenRevoked
+	}
+	if token.IsExpired(now) {
+		return nil, errors.ErrAccessTokenExpired
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= lastUsedResolution {
+		// Usage tracking is best effort and must not block authentication
+		_ = s.tokenRepo.TouchLastUsed(ctx, token.ID, now)
+		token.LastUsedAt = &now
+	}
+
+	return token, nil
+}
+
+// HashAccessToken returns the hex-encoded SHA-256 digest under which a
+// personal access token is stored. Tokens carry 256 bits of entropy, so a
+// fast hash is sufficient and keeps lookups indexable.
+func HashAccessToken(plaintext string) string {
+	sum := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(sum[:])
+}
+
+func generateAccessToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return models.AccessTokenPrefix + hex.EncodeToString(b), nil
+}
+
+func normalizeScopes(requested []string) ([]string, error) {
+	seen := make(map[string]bool, len(requested))
+	scopes := make([]string, 0, len(requested))
+	for _, scope := range requested {
+		scope = strings.TrimSpace(scope)
+		if !isKnownScope(scope) {
+			return nil, errors.ErrInvalidScope
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes, nil
+}
+
+func isKnownScope(scope string) bool {
+	for _, known := range models.AccessTokenScopes {
+		if scope == known {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"slices"
+	"testing"
+
+	"github.com/jixlox0/studoto-backend/internal/errors"
+	"github.com/jixlox0/studoto-backend/internal/models"
+	"github.com/jixlox0/studoto-backend/internal/repository"
+)
+
+// Only the methods Create calls are implemented; the embedded interfaces
+// panic on anything else.
+type stubUserRepository struct {
+	repository.UserRepository
+	user *models.User
+}
+
+func (r *stubUserRepository) FindByUUID(ctx context.Context, uuid string) (*models.User, error) {
+	return r.user, nil
+}
+
+type stubAccessTokenRepository struct {
+	repository.AccessTokenRepository
+	created []*models.AccessToken
+}
+
+func (r *stubAccessTokenRepository) Create(ctx context.Context, token *models.AccessToken) error {
+	r.created = append(r.created, token)
+	return nil
+}
+
+// scopes grants the listed scopes, like a principal authenticated with an
+// access token
+type scopes []string
+
+func (s scopes) HasScope(scope string) bool { return slices.Contains(s, scope) }
+
+// session grants every scope, like an interactive principal
+type session struct{}
+
+func (session) HasScope(string) bool { return true }
+
+func TestAccessTokenCreateLimitsScopes(t *testing.T) {
+	tests := []struct {
+		name    string
+		role    string
+		grantor Grantor
+		scopes  []string
+		wantErr error
+	}{
+		{name: "session grants any user scope", role: models.RoleUser, grantor: session{},
+			scopes: []string{models.ScopeProfileRead, models.ScopeAccountWrite}},
+		{name: "token grants its own scopes", role: models.RoleUser, grantor: scopes{models.ScopeTokensWrite, models.ScopeProfileRead},
+			scopes: []string{models.ScopeProfileRead}},
+		{name: "token cannot widen its scopes", role: models.RoleUser, grantor: scopes{models.ScopeTokensWrite},
+			scopes: []string{models.ScopeAccountWrite}, wantErr: errors.ErrInsufficientScope},
+		{name: "non-admin session cannot grant admin", role: models.RoleUser, grantor: session{},
+			scopes: []string{models.ScopeAdmin}, wantErr: errors.ErrInsufficientScope},
+		{name: "admin session grants admin", role: models.RoleAdmin, grantor: session{},
+			scopes: []string{models.ScopeAdmin}},
+		{name: "admin token without admin scope cannot grant it", role: models.RoleAdmin, grantor: scopes{models.ScopeTokensWrite},
+			scopes: []string{models.ScopeAdmin}, wantErr: errors.ErrInsufficientScope},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens := &stubAccessTokenRepository{}
+			users := &stubUserRepository{user: &models.User{ID: 1, UUID: "usr-1", Role: tt.role}}
+			s := NewAccessTokenService(tokens, users)
+
+			_, err := s.Create(context.Background(), "usr-1", tt.grantor, &models.CreateAccessTokenRequest{Name: "ci", Scopes: tt.scopes})
+			if !stderrors.Is(err, tt.wantErr) {
+				t.Fatalf("Create() error = %v, want %v", err, tt.wantErr)
+			}
+			if created := len(tokens.created) == 1; created != (tt.wantErr == nil) {
+				t.Fatalf("token created = %v, want %v", created, tt.wantErr == nil)
+			}
+		})
+	}
+}
@@ -0,0 +1,303 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jixlox0/studoto-backend/internal/config"
+	"github.com/jixlox0/studoto-backend/internal/errors"
+	"github.com/jixlox0/studoto-backend/internal/logging"
+	"github.com/jixlox0/studoto-backend/internal/models"
+	"github.com/jixlox0/studoto-backend/internal/privacy"
+	"github.com/jixlox0/studoto-backend/internal/repository"
+	"github.com/jixlox0/studoto-backend/pkg/auth"
+)
+
+// Export formats
+const (
+	ExportFormatJSON = "json"
+	ExportFormatZIP  = "zip"
+)
+
+// ExportArchive is a downloadable copy of a user's personal data.
+type ExportArchive struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+type AccountService interface {
+	RequestDeletion(ctx context.Context, userUUID string, req *models.DeleteAccountRequest) (*models.AccountDeletionResponse, error)
+	CancelDeletion(ctx context.Context, userUUID string) error
+	Export(ctx context.Context, userUUID string, format string) (*ExportArchive, error)
+	PurgeDue(ctx context.Context) error
+}
+
+type accountService struct {
+	userRepo    repository.UserRepository
+	txManager   repository.TxManager
+	jwtAuth     *auth.JWTAuth
+	registry    *privacy.Registry
+	auditor     Auditor
+	gracePeriod time.Duration
+}
+
+func NewAccountService(userRepo repository.UserRepository, txManager repository.TxManager, jwtAuth *auth.JWTAuth, registry *privacy.Registry, auditor Auditor, cfg config.AccountConfig) AccountService {
+	return &accountService{
+		userRepo:    userRepo,
+		txManager:   txManager,
+		jwtAuth:     jwtAuth,
+		registry:    registry,
+		auditor:     auditor,
+		gracePeriod: time.Duration(cfg.DeletionGraceDays) * 24 * time.Hour,
+	}
+}
+
+// RequestDeletion schedules the account for deletion after the grace period.
+// Repeated requests keep the original schedule.
+func (s *accountService) RequestDeletion(ctx context.Context, userUUID string, req *models.DeleteAccountRequest) (*models.AccountDeletionResponse, error) {
+	user, err := s.userRepo.FindByUUID(ctx, userUUID)
+	if err != nil {
+		return nil, notFoundAs(err, errors.ErrUserNotFound)
+	}
+
+	if !reauthenticate(ctx, user, req) {
+		s.auditor.Record(ctx, &models.AuditEvent{
+			ActorID:  user.UUID,
+			TargetID: user.UUID,
+			Action:   models.AuditActionDeletionRequested,
+			Metadata: models.JSONMap{"result": "reauthentication_failed"},
+		})
+		return nil, errors.ErrReauthenticationFailed
+	}
+
+	if user.DeletionScheduledAt == nil {
+		now := time.Now()
+		scheduledAt := now.Add(s.gracePeriod)
+		user.DeletionRequestedAt = &now
+		user.DeletionScheduledAt = &scheduledAt
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			return nil, err
+		}
+
+		s.auditor.Record(ctx, &models.AuditEvent{
+			ActorID:  user.UUID,
+			TargetID: user.UUID,
+			Action:   models.AuditActionDeletionRequested,
+			Metadata: models.JSONMap{"scheduled_at": scheduledAt},
+		})
+	}
+
+	return &models.AccountDeletionResponse{
+		DeletionRequestedAt: *user.DeletionRequestedAt,
+		DeletionScheduledAt: *user.DeletionScheduledAt,
+	}, nil
+}
+
+func (s *accountService) CancelDeletion(ctx context.Context, userUUID string) error {
+	user, err := s.userRepo.FindByUUID(ctx, userUUID)
+	if err != nil {
+		return notFoundAs(err, errors.ErrUserNotFound)
+	}
+
+	// The schedule is cleared only if still set, so a cancellation racing
+	// with the purge either stops it or reports that nothing is pending
+	if err := s.userRepo.CancelDeletion(ctx, user.UUID); err != nil {
+		return notFoundAs(err, errors.ErrNoDeletionPending)
+	}
+
+	s.auditor.Record(ctx, &models.AuditEvent{
+		ActorID:  user.UUID,
+		TargetID: user.UUID,
+		Action:   models.AuditActionDeletionCancelled,
+	})
+	return nil
+}
+
+// Export gathers everything held about the user from every registered
+// privacy module, as a single JSON document or a ZIP with one file per module.
+func (s *accountService) Export(ctx context.Context, userUUID string, format string) (*ExportArchive, error) {
+	if format == "" {
+		format = ExportFormatJSON
+	}
+	if format != ExportFormatJSON && format != ExportFormatZIP {
+		return nil, errors.ErrInvalidExportFormat
+	}
+
+	user, err := s.userRepo.FindByUUID(ctx, userUUID)
+	if err != nil {
+		return nil, notFoundAs(err, errors.ErrUserNotFound)
+	}
+
+	data, err := s.registry.Export(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	exportedAt := time.Now().UTC()
+	basename := fmt.Sprintf("studoto-export-%s-%s", user.UUID, exportedAt.Format("20060102T150405Z"))
+
+	var archive *ExportArchive
+	if format == ExportFormatZIP {
+		archive, err = buildZIPExport(basename, user, exportedAt, data)
+	} else {
+		archive, err = buildJSONExport(basename, user, exportedAt, data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.auditor.Record(ctx, &models.AuditEvent{
+		ActorID:  user.UUID,
+		TargetID: user.UUID,
+		Action:   models.AuditActionDataExported,
+		Metadata: models.JSONMap{"format": format},
+	})
+	return archive, nil
+}
+
+// PurgeDue erases every account whose deletion grace period has ended.
+// A failure for one account is logged and does not stop the others.
+func (s *accountService) PurgeDue(ctx context.Context) error {
+	users, err := s.userRepo.FindDueForDeletion(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+
+	for i := range users {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if err := s.purge(ctx, users[i].UUID); err != nil {
+			logging.FromContext(ctx).Error("Failed to purge account", "user_id", users[i].UUID, "error", err)
+		}
+	}
+	return nil
+}
+
+// purge erases one account. Every instance runs the purge worker, and the
+// user may cancel after the account was listed, so the row is locked and
+// checked again first; an account no longer due, or being purged by another
+// instance, is skipped.
+func (s *accountService) purge(ctx context.Context, userUUID string) error {
+	purged := false
+	// Either every module's data goes or none does, so a failed purge is
+	// retried in full on the next run rather than leaving orphaned rows
+	err := s.txManager.WithinTx(ctx, func(ctx context.Context) error {
+		user, err := s.userRepo.LockDueForDeletion(ctx, userUUID, time.Now())
+		if stderrors.Is(err, repository.ErrNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		// Sessions stay valid until revoked; a purge that cannot revoke
+		// them is retried on the next run
+		if err := s.jwtAuth.InvalidateUserTokens(ctx, user.UUID, user.ID); err != nil {
+			return fmt.Errorf("failed to revoke sessions: %w", err)
+		}
+		if err := s.registry.Purge(ctx, user); err != nil {
+			return err
+		}
+		purged = true
+		return nil
+	})
+	if err != nil || !purged {
+		return err
+	}
+
+	modules := make([]string, 0, len(s.registry.Modules()))
+	for _, module := range s.registry.Modules() {
+		modules = append(modules, module.Name())
+	}
+	s.auditor.Record(ctx, &models.AuditEvent{
+		TargetID: models.AnonymizedUserID,
+		Action:   models.AuditActionAccountPurged,
+		Metadata: models.JSONMap{"modules": modules},
+	})
+	return nil
+}
+
+// reauthenticate confirms a sensitive request came from the account owner.
+func reauthenticate(ctx context.Context, user *models.User, req *models.DeleteAccountRequest) bool {
+	if user.PasswordHash != "" {
+		return comparePassword(ctx, user.PasswordHash, req.Password) == nil
+	}
+	confirm := strings.ToLower(strings.TrimSpace(req.ConfirmEmail))
+	return confirm != "" && subtle.ConstantTimeCompare([]byte(confirm), []byte(strings.ToLower(user.Email))) == 1
+}
+
+func buildJSONExport(basename string, user *models.User, exportedAt time.Time, data map[string]any) (*ExportArchive, error) {
+	body, err := json.MarshalIndent(map[string]any{
+		"user_id":     user.UUID,
+		"exported_at": exportedAt,
+		"data":        data,
+	}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return &ExportArchive{
+		Filename:    basename + ".json",
+		ContentType: "application/json",
+		Data:        body,
+	}, nil
+}
+
+func buildZIPExport(basename string, user *models.User, exportedAt time.Time, data map[string]any) (*ExportArchive, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	names := make([]string, 0, len(data))
+	for name := range data {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	files := make([]string, 0, len(names))
+	for _, name := range names {
+		filename := name + ".json"
+		files = append(files, filename)
+		if err := writeZIPJSON(zw, filename, data[name]); err != nil {
+			return nil, err
+		}
+	}
+
+	manifest := map[string]any{
+		"user_id":     user.UUID,
+		"exported_at": exportedAt,
+		"files":       files,
+	}
+	if err := writeZIPJSON(zw, "manifest.json", manifest); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return &ExportArchive{
+		Filename:    basename + ".zip",
+		ContentType: "application/zip",
+		Data:        buf.Bytes(),
+	}, nil
+}
+
+func writeZIPJSON(zw *zip.Writer, filename string, v any) error {
+	w, err := zw.Create(filename)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+	"time"
+
+	"github.com/jixlox0/studoto-backend/internal/config"
+	"github.com/jixlox0/studoto-backend/internal/errors"
+	"github.com/jixlox0/studoto-backend/internal/models"
+	"github.com/jixlox0/studoto-backend/internal/repository"
+)
+
+// deletionUserRepository lists due accounts whose deletion has since been
+// cancelled or which another instance is purging, so none can be locked.
+type deletionUserRepository struct {
+	repository.UserRepository
+	due      []models.User
+	locked   []string
+	cancelOK bool
+}
+
+func (r *deletionUserRepository) FindByUUID(ctx context.Context, uuid string) (*models.User, error) {
+	return &models.User{UUID: uuid}, nil
+}
+
+func (r *deletionUserRepository) FindDueForDeletion(ctx context.Context, now time.Time) ([]models.User, error) {
+	return r.due, nil
+}
+
+func (r *deletionUserRepository) LockDueForDeletion(ctx context.Context, uuid string, now time.Time) (*models.User, error) {
+	r.locked = append(r.locked, uuid)
+	return nil, repository.ErrNotFound
+}
+
+func (r *deletionUserRepository) CancelDeletion(ctx context.Context, uuid string) error {
+	if !r.cancelOK {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+type stubTxManager struct{}
+
+func (stubTxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (stubTxManager) WithinSerializableTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+type recordingAuditor struct {
+	Auditor
+	events []*models.AuditEvent
+}
+
+func (a *recordingAuditor) Record(ctx context.Context, event *models.AuditEvent) {
+	a.events = append(a.events, event)
+}
+
+func TestPurgeDueSkipsAccountsNoLongerDue(t *testing.T) {
+	users := &deletionUserRepository{due: []models.User{{UUID: "usr-1"}, {UUID: "usr-2"}}}
+	auditor := &recordingAuditor{}
+	// Neither sessions nor module data are touched, so both can be nil
+	s := NewAccountService(users, stubTxManager{}, nil, nil, auditor, config.AccountConfig{DeletionGraceDays: 30})
+
+	if err := s.PurgeDue(context.Background()); err != nil {
+		t.Fatalf("PurgeDue() error = %v", err)
+	}
+	if len(users.locked) != 2 {
+		t.Fatalf("locked %v, want every due account checked again", users.locked)
+	}
+	if len(auditor.events) != 0 {
+		t.Fatalf("audited %d events, want none for skipped accounts", len(auditor.events))
+	}
+}
+
+func TestCancelDeletion(t *testing.T) {
+	tests := []struct {
+		name     string
+		cancelOK bool
+		wantErr  error
+	}{
+		{name: "pending", cancelOK: true},
+		{name: "nothing pending or already purged", wantErr: errors.ErrNoDeletionPending},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auditor := &recordingAuditor{}
+			s := NewAccountService(&deletionUserRepository{cancelOK: tt.cancelOK}, stubTxManager{}, nil, nil, auditor, config.AccountConfig{})
+
+			if err := s.CancelDeletion(context.Background(), "usr-1"); !stderrors.Is(err, tt.wantErr) {
+				t.Fatalf("CancelDeletion() error = %v, want %v", err, tt.wantErr)
+			}
+			if audited := len(auditor.events) == 1; audited != (tt.wantErr == nil) {
+				t.Fatalf("audited = %v, want %v", audited, tt.wantErr == nil)
+			}
+		})
+	}
+}
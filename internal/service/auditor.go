@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/jixlox0/studoto-backend/internal/logging"
+	"github.com/jixlox0/studoto-backend/internal/models"
+	"github.com/jixlox0/studoto-backend/internal/repository"
+	"github.com/jixlox0/studoto-backend/pkg/uuid"
+)
+
+const (
+	defaultAuditPageSize      = 50
+	defaultRecentActivityRows = 20
+	maxRecentActivityRows     = 100
+)
+
+// Auditor records security-relevant events and answers queries over them.
+type Auditor interface {
+	// Record stores event, filling in its ID, timestamp and the client
+	// details carried by ctx. Failures are logged rather than returned so
+	// that auditing never breaks the action being audited.
+	Record(ctx context.Context, event *models.AuditEvent)
+	Query(ctx context.Context, filter *models.AuditEventFilter) (*models.AuditEventPage, error)
+	RecentForUser(ctx context.Context, userUUID string, limit int) ([]models.AuditEvent, error)
+}
+
+type auditor struct {
+	auditRepo repository.AuditEventRepository
+}
+
+func NewAuditor(auditRepo repository.AuditEventRepository) Auditor {
+	return &auditor{auditRepo: auditRepo}
+}
+
+func (a *auditor) Record(ctx context.Context, event *models.AuditEvent) {
+	client := ClientInfoFromContext(ctx)
+	event.UUID = uuid.Generate(uuid.PrefixAudit)
+	event.IP = client.IP
+	event.UserAgent = truncate(client.UserAgent, 512)
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+
+	// The action being audited has already happened, so the record is
+	// written even if the request is cancelled or times out meanwhile
+	if err := a.auditRepo.Create(context.WithoutCancel(ctx), event); err != nil {
+		logging.FromContext(ctx).Error("Failed to record audit event", "action", event.Action, "error", err)
+	}
+}
+
+func (a *auditor) Query(ctx context.Context, filter *models.AuditEventFilter) (*models.AuditEventPage, error) {
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PageSize < 1 {
+		filter.PageSize = defaultAuditPageSize
+	}
+
+	events, total, err := a.auditRepo.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AuditEventPage{
+		Events:   events,
+		Total:    total,
+		Page:     filter.Page,
+		PageSize: filter.PageSize,
+	}, nil
+}
+
+func (a *auditor) RecentForUser(ctx context.Context, userUUID string, limit int) ([]models.AuditEvent, error) {
+	if limit < 1 {
+		limit = defaultRecentActivityRows
+	}
+	if limit > maxRecentActivityRows {
+		limit = maxRecentActivityRows
+	}
+	return a.auditRepo.ListByUser(ctx, userUUID, limit)
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
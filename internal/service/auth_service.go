@@ -2,48 +2,63 @@ package service
 
 import (
 	"context"
+	stderrors "errors"
+	"fmt"
 	"math/rand"
 	"time"
 
 	"github.com/jixlox0/studoto-backend/internal/errors"
+	"github.com/jixlox0/studoto-backend/internal/metrics"
 	"github.com/jixlox0/studoto-backend/internal/models"
 	"github.com/jixlox0/studoto-backend/internal/repository"
 	"github.com/jixlox0/studoto-backend/pkg/auth"
 	"github.com/jixlox0/studoto-backend/pkg/oauth"
 	"github.com/jixlox0/studoto-backend/pkg/uuid"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type AuthService interface {
-	Signup(req *models.CreateUserRequest) (*models.SuccessResponse, error)
-	Signin(req *models.LoginRequest) (*models.SuccessResponse, error)
-	OAuthLogin(provider, code string) (*models.SuccessResponse, error)
+	Signup(ctx context.Context, req *models.CreateUserRequest) (*models.SuccessResponse, error)
+	// ResumeSignup answers a retried signup whose first request already
+	// created the account, with a new token for that account.
+	ResumeSignup(ctx context.Context, userUUID string) (*models.SuccessResponse, error)
+	Signin(ctx context.Context, req *models.LoginRequest) (*models.SuccessResponse, error)
+	OAuthLogin(ctx context.Context, provider, code string) (*models.SuccessResponse, error)
 	GetOAuthURL(provider string) (string, error)
+	Signout(ctx context.Context, userUUID string, token string) error
 }
 
 type authService struct {
 	userRepo     repository.UserRepository
+	txManager    repository.TxManager
 	jwtAuth      *auth.JWTAuth
 	oauthService oauth.OAuthService
+	auditor      Auditor
+	metrics      *metrics.Metrics
 }
 
-func NewAuthService(userRepo repository.UserRepository, jwtAuth *auth.JWTAuth, oauthService oauth.OAuthService) AuthService {
+func NewAuthService(userRepo repository.UserRepository, txManager repository.TxManager, jwtAuth *auth.JWTAuth, oauthService oauth.OAuthService, auditor Auditor, metrics *metrics.Metrics) AuthService {
 	return &authService{
 		userRepo:     userRepo,
+		txManager:    txManager,
 		jwtAuth:      jwtAuth,
 		oauthService: oauthService,
+		auditor:      auditor,
+		metrics:      metrics,
 	}
 }
 
-func (s *authService) Signup(req *models.CreateUserRequest) (*models.SuccessResponse, error) {
+func (s *authService) Signup(ctx context.Context, req *models.CreateUserRequest) (*models.SuccessResponse, error) {
 	// Check if user already exists
-	existingUser, _ := s.userRepo.FindByEmail(req.Email)
-	if existingUser != nil {
+	_, err := s.userRepo.FindByEmail(ctx, req.Email)
+	if err == nil {
 		return nil, errors.ErrUserAlreadyExists
 	}
+	if !stderrors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
 
 	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	hashedPassword, err := hashPassword(ctx, req.Password)
 	if err != nil {
 		return nil, err
 	}
@@ -52,49 +67,112 @@ func (s *authService) Signup(req *models.CreateUserRequest) (*models.SuccessResp
 	user := &models.User{
 		UUID:         uuid.Generate(uuid.PrefixUser),
 		Email:        req.Email,
-		PasswordHash: string(hashedPassword),
+		PasswordHash: hashedPassword,
 		Name:         req.Name,
 	}
 
-	if err := s.userRepo.Create(user); err != nil {
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		// A concurrent signup with the same email got in first
+		if stderrors.Is(err, repository.ErrConflict) {
+			return nil, errors.ErrUserAlreadyExists
+		}
 		return nil, err
 	}
 
+	s.auditor.Record(ctx, &models.AuditEvent{
+		ActorID:  user.UUID,
+		TargetID: user.UUID,
+		Action:   models.AuditActionSignup,
+	})
+	s.metrics.SignupCompleted("password")
+
 	// Generate token
-	token, err := s.jwtAuth.GenerateToken(context.Background(), user.ID, user.Email)
+	token, err := s.jwtAuth.GenerateToken(ctx, user.UUID, user.Email)
 	if err != nil {
 		return nil, err
 	}
 
-	response := map[string]any{
-		"token": token,
-		"user":  user,
+	return models.NewSuccessResponse(&models.AuthResponse{
+		Token: token,
+		User:  user,
+	}), nil
+}
+
+func (s *authService) ResumeSignup(ctx context.Context, userUUID string) (*models.SuccessResponse, error) {
+	user, err := s.userRepo.FindByUUID(ctx, userUUID)
+	if err != nil {
+		return nil, notFoundAs(err, errors.ErrUserNotFound)
+	}
+
+	token, err := s.jwtAuth.GenerateToken(ctx, user.UUID, user.Email)
+	if err != nil {
+		return nil, err
 	}
-	return models.NewSuccessResponse(response), nil
+
+	return models.NewSuccessResponse(&models.AuthResponse{
+		Token: token,
+		User:  user,
+	}), nil
 }
 
-func (s *authService) Signin(req *models.LoginRequest) (*models.SuccessResponse, error) {
-	user, err := s.userRepo.FindByEmail(req.Email)
+func (s *authService) Signin(ctx context.Context, req *models.LoginRequest) (*models.SuccessResponse, error) {
+	user, err := s.userRepo.FindByEmail(ctx, req.Email)
+	if err != nil && !stderrors.Is(err, repository.ErrNotFound) {
+		return nil, err
+	}
 	if err != nil {
+		// The submitted email is not recorded: it belongs to no account, so
+		// no erasure request could ever remove it
+		s.auditor.Record(ctx, &models.AuditEvent{
+			Action:   models.AuditActionSigninFailed,
+			Metadata: models.JSONMap{"reason": "unknown_email"},
+		})
+		s.metrics.SigninFailed("unknown_email")
 		return nil, errors.ErrUserNotFound
 	}
 
 	// Check password
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+	if err := comparePassword(ctx, user.PasswordHash, req.Password); err != nil {
+		s.auditor.Record(ctx, &models.AuditEvent{
+			TargetID: user.UUID,
+			Action:   models.AuditActionSigninFailed,
+			Metadata: models.JSONMap{"reason": "invalid_password"},
+		})
+		s.metrics.SigninFailed("invalid_password")
 		return nil, errors.ErrInvalidPassword
 	}
 
 	// Generate token
-	token, err := s.jwtAuth.GenerateToken(context.Background(), user.ID, user.Email)
+	token, err := s.jwtAuth.GenerateToken(ctx, user.UUID, user.Email)
 	if err != nil {
 		return nil, err
 	}
 
-	return models.NewSuccessResponse(map[string]any{
-		"token": token,
+	s.auditor.Record(ctx, &models.AuditEvent{
+		ActorID:  user.UUID,
+		TargetID: user.UUID,
+		Action:   models.AuditActionSigninSucceeded,
+	})
+
+	return models.NewSuccessResponse(&models.AuthResponse{
+		Token: token,
 	}), nil
 }
 
+// Signout invalidates the given session token.
+func (s *authService) Signout(ctx context.Context, userUUID string, token string) error {
+	if err := s.jwtAuth.InvalidateToken(ctx, token); err != nil {
+		return err
+	}
+
+	s.auditor.Record(ctx, &models.AuditEvent{
+		ActorID:  userUUID,
+		TargetID: userUUID,
+		Action:   models.AuditActionSignout,
+	})
+	return nil
+}
+
 func (s *authService) GetOAuthURL(provider string) (string, error) {
 	state := generateState()
 
@@ -108,56 +186,94 @@ func (s *authService) GetOAuthURL(provider string) (string, error) {
 	}
 }
 
-func (s *authService) OAuthLogin(provider, code string) (*models.SuccessResponse, error) {
+func (s *authService) OAuthLogin(ctx context.Context, provider, code string) (*models.SuccessResponse, error) {
 	var oauthUser *oauth.OAuthUser
 	var err error
 
 	switch provider {
 	case "google":
-		oauthUser, err = s.oauthService.ExchangeGoogleCode(code)
+		oauthUser, err = s.oauthService.ExchangeGoogleCode(ctx, code)
 	case "github":
-		oauthUser, err = s.oauthService.ExchangeGitHubCode(code)
+		oauthUser, err = s.oauthService.ExchangeGitHubCode(ctx, code)
 	default:
 		return nil, errors.ErrInvalidProvider
 	}
 
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %w", errors.ErrOAuthExchange, err)
 	}
 
-	// Check if user exists by provider
-	user, err := s.userRepo.FindByProvider(provider, oauthUser.ID)
-	if err != nil {
-		// User doesn't exist, create new user
-		user = &models.User{
-			UUID:       uuid.Generate(uuid.PrefixUser),
-			Email:      oauthUser.Email,
-			Name:       oauthUser.Name,
-			AvatarURL:  oauthUser.AvatarURL,
-			Provider:   provider,
-			ProviderID: oauthUser.ID,
+	// The lookup and the create or update run as one serializable
+	// transaction, so concurrent callbacks for the same account cannot both
+	// create it; the loser is retried and finds the winner's row, or hits
+	// the unique index on the provider identity
+	var user *models.User
+	created := false
+	err = s.txManager.WithinSerializableTx(ctx, func(ctx context.Context) error {
+		created = false
+		existing, err := s.userRepo.FindByProvider(ctx, provider, oauthUser.ID)
+		if err != nil && !stderrors.Is(err, repository.ErrNotFound) {
+			return err
 		}
-
-		if err := s.userRepo.Create(user); err != nil {
-			return nil, err
+		if err != nil {
+			// User doesn't exist, create new user
+			user = &models.User{
+				UUID:       uuid.Generate(uuid.PrefixUser),
+				Email:      oauthUser.Email,
+				Name:       oauthUser.Name,
+				AvatarURL:  oauthUser.AvatarURL,
+				Provider:   provider,
+				ProviderID: oauthUser.ID,
+			}
+			created = true
+			return s.userRepo.Create(ctx, user)
 		}
-	} else {
+
 		// Update user info if needed
+		user = existing
 		if user.AvatarURL != oauthUser.AvatarURL || user.Name != oauthUser.Name {
 			user.AvatarURL = oauthUser.AvatarURL
 			user.Name = oauthUser.Name
-			s.userRepo.Update(user)
+			return s.userRepo.Update(ctx, user)
+		}
+		return nil
+	})
+	if stderrors.Is(err, repository.ErrConflict) {
+		// A concurrent callback for the same identity created the account
+		// first, in which case this is a login to it; otherwise the
+		// provider's email belongs to an account created another way
+		existing, findErr := s.userRepo.FindByProvider(ctx, provider, oauthUser.ID)
+		if findErr != nil {
+			if stderrors.Is(findErr, repository.ErrNotFound) {
+				return nil, errors.ErrUserAlreadyExists
+			}
+			return nil, findErr
 		}
+		user, created, err = existing, false, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if created {
+		s.metrics.SignupCompleted(provider)
 	}
 
 	// Generate token
-	token, err := s.jwtAuth.GenerateToken(context.Background(), user.ID, user.Email)
+	token, err := s.jwtAuth.GenerateToken(ctx, user.UUID, user.Email)
 	if err != nil {
 		return nil, err
 	}
 
-	return models.NewSuccessResponse(map[string]any{
-		"token": token,
+	s.auditor.Record(ctx, &models.AuditEvent{
+		ActorID:  user.UUID,
+		TargetID: user.UUID,
+		Action:   models.AuditActionOAuthLogin,
+		Metadata: models.JSONMap{"provider": provider},
+	})
+	s.metrics.OAuthLogin(provider)
+
+	return models.NewSuccessResponse(&models.AuthResponse{
+		Token: token,
 	}), nil
 }
 
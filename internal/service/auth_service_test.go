@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+
+	"github.com/jixlox0/studoto-backend/internal/errors"
+	"github.com/jixlox0/studoto-backend/internal/metrics"
+	"github.com/jixlox0/studoto-backend/internal/models"
+	"github.com/jixlox0/studoto-backend/internal/repository"
+	"github.com/jixlox0/studoto-backend/pkg/auth"
+	"github.com/jixlox0/studoto-backend/pkg/oauth"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// newTestMetrics returns metrics backed by a database and Redis client that
+// are never connected to.
+func newTestMetrics(t *testing.T) *metrics.Metrics {
+	t.Helper()
+	db, err := gorm.Open(postgres.Open("host=127.0.0.1 port=1"), &gorm.Config{DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1"})
+	t.Cleanup(func() { client.Close() })
+	m, err := metrics.NewMetrics(db, client)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+type stubOAuthService struct {
+	oauth.OAuthService
+	user *oauth.OAuthUser
+}
+
+func (s *stubOAuthService) ExchangeGoogleCode(ctx context.Context, code string) (*oauth.OAuthUser, error) {
+	return s.user, nil
+}
+
+// racingUserRepository loses every create to a conflicting row: winner, when
+// set, was created concurrently with the same provider identity.
+type racingUserRepository struct {
+	repository.UserRepository
+	winner  *models.User
+	created bool
+}
+
+func (r *racingUserRepository) FindByProvider(ctx context.Context, provider, providerID string) (*models.User, error) {
+	if r.winner == nil || !r.created {
+		return nil, repository.ErrNotFound
+	}
+	return r.winner, nil
+}
+
+func (r *racingUserRepository) Create(ctx context.Context, user *models.User) error {
+	r.created = true
+	return repository.ErrConflict
+}
+
+func TestOAuthLoginAfterConflict(t *testing.T) {
+	tests := []struct {
+		name    string
+		winner  *models.User
+		wantErr error
+	}{
+		{name: "same identity created concurrently", winner: &models.User{UUID: "usr-1", Email: "ada@example.com"}},
+		{name: "email taken by another account", wantErr: errors.ErrUserAlreadyExists},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := &racingUserRepository{winner: tt.winner}
+			provider := &stubOAuthService{user: &oauth.OAuthUser{ID: "g-1", Email: "ada@example.com", Name: "Ada"}}
+			s := NewAuthService(users, stubTxManager{}, auth.NewJWTAuth("secret", 1, nil, nil), provider, &recordingAuditor{}, newTestMetrics(t))
+
+			response, err := s.OAuthLogin(context.Background(), "google", "code")
+			if !stderrors.Is(err, tt.wantErr) {
+				t.Fatalf("OAuthLogin() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			token := response.Data.(*models.AuthResponse).Token
+			claims, err := auth.NewJWTAuth("secret", 1, nil, nil).ValidateToken(context.Background(), token)
+			if err != nil || claims.Subject != tt.winner.UUID {
+				t.Fatalf("token claims = %+v, %v, want a session for %s", claims, err, tt.winner.UUID)
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package service
+
+import (
+	stderrors "errors"
+
+	"github.com/jixlox0/studoto-backend/internal/repository"
+)
+
+// notFoundAs replaces a repository not-found error with the domain error
+// naming what was missing. Other errors, such as an unavailable database, are
+// returned unchanged so they are not mistaken for a missing record.
+func notFoundAs(err, domainErr error) error {
+	if stderrors.Is(err, repository.ErrNotFound) {
+		return domainErr
+	}
+	return err
+}
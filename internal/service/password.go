@@ -0,0 +1,30 @@
+package service
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var tracer = otel.Tracer("github.com/jixlox0/studoto-backend/internal/service")
+
+// hashPassword and comparePassword wrap bcrypt in spans, since hashing is
+// deliberately slow and often dominates sign-in latency.
+func hashPassword(ctx context.Context, password string) (string, error) {
+	_, span := tracer.Start(ctx, "bcrypt.hash")
+	defer span.End()
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashed), nil
+}
+
+func comparePassword(ctx context.Context, hash, password string) error {
+	_, span := tracer.Start(ctx, "bcrypt.compare")
+	defer span.End()
+
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+}
@@ -1,32 +1,155 @@
 package service
 
 import (
+	"context"
+	"fmt"
+
+	"github.com/jixlox0/studoto-backend/internal/errors"
 	"github.com/jixlox0/studoto-backend/internal/models"
+	"github.com/jixlox0/studoto-backend/internal/pagination"
 	"github.com/jixlox0/studoto-backend/internal/repository"
 	"github.com/jixlox0/studoto-backend/pkg/auth"
 )
 
 type UserService interface {
-	GetUserByID(id uint) (*models.UserResponse, error)
-	GetUserByEmail(email string) (*models.User, error)
+	GetUserByUUID(ctx context.Context, uuid string) (*models.UserResponse, error)
+	GetUserByID(ctx context.Context, id uint) (*models.UserResponse, error)
+	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
+	ChangePassword(ctx context.Context, userUUID string, req *models.ChangePasswordRequest) error
+	UpdateRole(ctx context.Context, targetUUID, role string) (*models.User, string, error)
+	RecentSecurityActivity(ctx context.Context, userUUID string, limit int) ([]models.AuditEvent, error)
+	ListUsers(ctx context.Context, params *pagination.Params) (*pagination.Page[models.User], error)
+}
+
+// UserPagination declares how administrators may page through, sort and
+// filter the user list.
+var UserPagination = &pagination.Spec{
+	Fields: []pagination.Field{
+		{Name: "created_at", Column: "created_at", Type: pagination.Time, Sortable: true,
+			Operators: []string{pagination.Gt, pagination.Gte, pagination.Lt, pagination.Lte}},
+		{Name: "email", Column: "email", Type: pagination.String, Sortable: true, Operators: []string{pagination.Eq}},
+		{Name: "name", Column: "name", Type: pagination.String, Sortable: true},
+		{Name: "role", Column: "role", Type: pagination.String, Operators: []string{pagination.Eq, pagination.Ne, pagination.In}},
+		{Name: "provider", Column: "provider", Type: pagination.String, Operators: []string{pagination.Eq, pagination.Ne, pagination.In}},
+	},
+	Key:          pagination.Field{Name: "id", Column: "id", Type: pagination.Int},
+	DefaultSort:  "-created_at",
+	DefaultLimit: 20,
+	MaxLimit:     100,
 }
 
 type userService struct {
 	userRepo repository.UserRepository
 	jwtAuth  *auth.JWTAuth
+	auditor  Auditor
 }
 
-func NewUserService(userRepo repository.UserRepository, jwtAuth *auth.JWTAuth) UserService {
+func NewUserService(userRepo repository.UserRepository, jwtAuth *auth.JWTAuth, auditor Auditor) UserService {
 	return &userService{
 		userRepo: userRepo,
 		jwtAuth:  jwtAuth,
+		auditor:  auditor,
+	}
+}
+
+func (s *userService) GetUserByUUID(ctx context.Context, uuid string) (*models.UserResponse, error) {
+	user, err := s.userRepo.FindByUUID(ctx, uuid)
+	if err != nil {
+		return nil, notFoundAs(err, errors.ErrUserNotFound)
+	}
+	return models.NewUserResponse(user), nil
+}
+
+// GetUserByID looks a user up by internal database ID. It only exists to
+// resolve tokens issued before public IDs became the token subject.
+func (s *userService) GetUserByID(ctx context.Context, id uint) (*models.UserResponse, error) {
+	user, err := s.userRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, notFoundAs(err, errors.ErrUserNotFound)
+	}
+	return models.NewUserResponse(user), nil
+}
+
+func (s *userService) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	user, err := s.userRepo.FindByEmail(ctx, email)
+	if err != nil {
+		return nil, notFoundAs(err, errors.ErrUserNotFound)
+	}
+	return user, nil
+}
+
+// ChangePassword replaces the user's password after verifying the current one
+// and signs the user out of every session, including the current one.
+func (s *userService) ChangePassword(ctx context.Context, userUUID string, req *models.ChangePasswordRequest) error {
+	user, err := s.userRepo.FindByUUID(ctx, userUUID)
+	if err != nil {
+		return notFoundAs(err, errors.ErrUserNotFound)
+	}
+
+	if err := comparePassword(ctx, user.PasswordHash, req.CurrentPassword); err != nil {
+		s.auditor.Record(ctx, &models.AuditEvent{
+			ActorID:  user.UUID,
+			TargetID: user.UUID,
+			Action:   models.AuditActionPasswordChangeFailed,
+			Metadata: models.JSONMap{"reason": "invalid_password"},
+		})
+		return errors.ErrInvalidPassword
+	}
+
+	hashedPassword, err := hashPassword(ctx, req.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	// Sessions are revoked first: if that fails the old password still
+	// works, rather than the new one working alongside stolen sessions
+	if err := s.jwtAuth.InvalidateUserTokens(ctx, user.UUID, user.ID); err != nil {
+		return fmt.Errorf("%w: %w", errors.ErrServiceUnavailable, err)
+	}
+
+	user.PasswordHash = hashedPassword
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return err
+	}
+
+	s.auditor.Record(ctx, &models.AuditEvent{
+		ActorID:  user.UUID,
+		TargetID: user.UUID,
+		Action:   models.AuditActionPasswordChanged,
+	})
+	return nil
+}
+
+// UpdateRole sets the role of the user identified by targetUUID and returns
+// the updated user together with its previous role.
+func (s *userService) UpdateRole(ctx context.Context, targetUUID, role string) (*models.User, string, error) {
+	user, err := s.userRepo.FindByUUID(ctx, targetUUID)
+	if err != nil {
+		return nil, "", notFoundAs(err, errors.ErrUserNotFound)
+	}
+
+	previous := user.Role
+	if previous == role {
+		return user, previous, nil
+	}
+
+	user.Role = role
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, "", err
 	}
+	return user, previous, nil
 }
 
-func (s *userService) GetUserByID(id uint) (*models.UserResponse, error) {
-	return s.userRepo.FindByID(id)
+// RecentSecurityActivity returns the latest audit events involving the user.
+func (s *userService) RecentSecurityActivity(ctx context.Context, userUUID string, limit int) ([]models.AuditEvent, error) {
+	if _, err := s.userRepo.FindByUUID(ctx, userUUID); err != nil {
+		return nil, notFoundAs(err, errors.ErrUserNotFound)
+	}
+	return s.auditor.RecentForUser(ctx, userUUID, limit)
 }
 
-func (s *userService) GetUserByEmail(email string) (*models.User, error) {
-	return s.userRepo.FindByEmail(email)
+// ListUsers returns one page of users for administrators. params must come
+// from UserPagination.
+func (s *userService) ListUsers(ctx context.Context, params *pagination.Params) (*pagination.Page[models.User], error) {
+	return s.userRepo.List(ctx, params)
 }
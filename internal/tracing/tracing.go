@@ -0,0 +1,86 @@
+// Package tracing configures OpenTelemetry tracing and instruments the
+// database layer. Tracing is off by default, in which case a no-op provider
+// is installed and instrumentation costs next to nothing.
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jixlox0/studoto-backend/internal/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// Exporters accepted in TracingConfig.Exporter
+const (
+	ExporterNone = "none"
+	ExporterOTLP = "otlp"
+)
+
+// Provider is the process-wide tracer provider. It is also installed as the
+// OpenTelemetry global so that libraries without explicit wiring use it.
+type Provider struct {
+	trace.TracerProvider
+	ServiceName string
+	shutdown    func(ctx context.Context) error
+}
+
+func NewProvider(cfg config.TracingConfig) (*Provider, error) {
+	// W3C trace context is propagated even when tracing is off, so that
+	// upstream trace IDs still reach downstream services
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	if cfg.Exporter != ExporterOTLP {
+		provider := &Provider{
+			TracerProvider: noop.NewTracerProvider(),
+			ServiceName:    cfg.ServiceName,
+			shutdown:       func(ctx context.Context) error { return nil },
+		}
+		otel.SetTracerProvider(provider.TracerProvider)
+		return provider, nil
+	}
+
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(cfg.OTLPEndpoint)}
+	if cfg.OTLPInsecure {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+	exporter, err := otlptracehttp.New(context.Background(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OTLP trace exporter: %w", err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", cfg.ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build trace resource: %w", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(tp)
+
+	return &Provider{
+		TracerProvider: tp,
+		ServiceName:    cfg.ServiceName,
+		shutdown:       tp.Shutdown,
+	}, nil
+}
+
+// Shutdown flushes buffered spans and stops the exporter.
+func (p *Provider) Shutdown(ctx context.Context) error {
+	return p.shutdown(ctx)
+}
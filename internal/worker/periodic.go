@@ -0,0 +1,92 @@
+// Package worker runs background jobs alongside the HTTP server.
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Periodic runs a task at a fixed interval until it is stopped.
+type Periodic struct {
+	name     string
+	interval time.Duration
+	task     func(ctx context.Context) error
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewPeriodic(name string, interval time.Duration, task func(ctx context.Context) error) *Periodic {
+	return &Periodic{
+		name:     name,
+		interval: interval,
+		task:     task,
+	}
+}
+
+// Name returns the worker name used in logs.
+func (p *Periodic) Name() string {
+	return p.name
+}
+
+// Start launches the worker in a new goroutine. The task runs once
+// immediately and then after every interval. Calling Start on a running
+// worker has no effect.
+func (p *Periodic) Start() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.cancel != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	p.done = make(chan struct{})
+
+	// run gets its own channel: after Stop, a new Start replaces p.done
+	// before this goroutine has necessarily finished
+	go p.run(ctx, p.done)
+}
+
+// Stop cancels the running task and waits for it to return or for ctx to expire.
+func (p *Periodic) Stop(ctx context.Context) error {
+	p.mu.Lock()
+	cancel, done := p.cancel, p.done
+	p.cancel = nil
+	p.mu.Unlock()
+
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (p *Periodic) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := p.task(ctx); err != nil && ctx.Err() == nil {
+			slog.Error("Worker failed", "worker", p.name, "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPeriodicRestartAfterStopTimeout(t *testing.T) {
+	p := NewPeriodic("test", time.Hour, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	expired, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// Stop gives up at once, so each Start replaces the state of a run that
+	// may not even have begun yet; every run must still signal its own end
+	for i := 0; i < 10; i++ {
+		p.Start()
+		if err := p.Stop(expired); err != nil && !errors.Is(err, context.Canceled) {
+			t.Fatalf("Stop() error = %v", err)
+		}
+	}
+
+	p.Start()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := p.Stop(ctx); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+}
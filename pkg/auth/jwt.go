@@ -3,40 +3,94 @@ package auth
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/jixlox0/studoto-backend/pkg/cache"
 )
 
+var (
+	// ErrTokenRevoked is returned for tokens revoked by signing out.
+	ErrTokenRevoked = errors.New("token revoked")
+	// ErrRevocationUnavailable is returned when it cannot be checked whether
+	// a token was revoked, e.g. because Redis is down.
+	ErrRevocationUnavailable = errors.New("token revocations unavailable")
+)
+
 type JWTAuth struct {
 	secretKey       string
 	expirationHours int
 	tokenCache      cache.TokenCache
+	cacheObserver   CacheObserver
+}
+
+// CacheObserver is notified of every token cache lookup, e.g. to export the
+// cache hit rate.
+type CacheObserver interface {
+	ObserveTokenCache(hit bool)
 }
 
+// Claims identifies the user by their public ID in the standard "sub" claim.
+// Tokens issued before the switch to public IDs carry the numeric database ID
+// in UserID instead and have no subject. IssuedAtNano repeats the issue time
+// in nanoseconds, since "iat" is whole seconds and revocations must tell
+// apart tokens issued within the same second.
 type Claims struct {
-	UserID uint   `json:"user_id"`
-	Email  string `json:"email"`
+	UserID       uint   `json:"user_id,omitempty"`
+	Email        string `json:"email"`
+	IssuedAtNano int64  `json:"iat_ns,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func NewJWTAuth(secretKey string, expirationHours int, tokenCache cache.TokenCache) *JWTAuth {
+// IsLegacy reports whether the token predates public-ID subjects.
+func (c *Claims) IsLegacy() bool {
+	return c.Subject == "" && c.UserID != 0
+}
+
+// cacheSubject returns the identifier the token is cached under.
+func (c *Claims) cacheSubject() string {
+	if c.IsLegacy() {
+		return strconv.FormatUint(uint64(c.UserID), 10)
+	}
+	return c.Subject
+}
+
+// issuedAt returns the most precise issue time the token carries. Tokens
+// without one cannot be told apart from the ones revoked with all of a user's
+// tokens, so they count as issued at the beginning of time.
+func (c *Claims) issuedAt() time.Time {
+	switch {
+	case c.IssuedAtNano != 0:
+		return time.Unix(0, c.IssuedAtNano)
+	case c.IssuedAt != nil:
+		return c.IssuedAt.Time
+	default:
+		return time.Time{}
+	}
+}
+
+func NewJWTAuth(secretKey string, expirationHours int, tokenCache cache.TokenCache, cacheObserver CacheObserver) *JWTAuth {
 	return &JWTAuth{
 		secretKey:       secretKey,
 		expirationHours: expirationHours,
 		tokenCache:      tokenCache,
+		cacheObserver:   cacheObserver,
 	}
 }
 
-func (j *JWTAuth) GenerateToken(ctx context.Context, userID uint, email string) (string, error) {
-	expirationTime := time.Now().Add(time.Duration(j.expirationHours) * time.Hour)
+// GenerateToken issues a token whose subject is the user's public ID.
+func (j *JWTAuth) GenerateToken(ctx context.Context, userID string, email string) (string, error) {
+	now := time.Now()
+	expirationTime := now.Add(time.Duration(j.expirationHours) * time.Hour)
 	claims := &Claims{
-		UserID: userID,
-		Email:  email,
+		Email:        email,
+		IssuedAtNano: now.UnixNano(),
 		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   userID,
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
 
@@ -58,40 +112,58 @@ func (j *JWTAuth) GenerateToken(ctx context.Context, userID uint, email string)
 	return tokenString, nil
 }
 
+// TokenTTL returns how long newly generated tokens remain valid.
+func (j *JWTAuth) TokenTTL() time.Duration {
+	return time.Duration(j.expirationHours) * time.Hour
+}
+
+// ValidateToken verifies the token's signature and expiry and refuses tokens
+// that were revoked, on their own by signing out or with all of the user's
+// tokens. Revocations are looked up on every call, so a token is refused even
+// after it dropped out of the cache; when they cannot be looked up the token
+// is refused as well, with ErrRevocationUnavailable.
 func (j *JWTAuth) ValidateToken(ctx context.Context, tokenString string) (*Claims, error) {
-	// First check if token exists in cache (for faster validation and logout support)
-	if j.tokenCache != nil {
-		cachedUserID, err := j.tokenCache.GetToken(ctx, tokenString)
-		if err == nil {
-			// Token is in cache, validate JWT structure
-			claims := &Claims{}
-			token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-				if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-					return nil, errors.New("invalid signing method")
-				}
-				return []byte(j.secretKey), nil
-			})
-
-			if err != nil {
-				return nil, err
-			}
-
-			if !token.Valid {
-				return nil, errors.New("invalid token")
-			}
-
-			// Verify cached userID matches token userID
-			if claims.UserID != cachedUserID {
-				return nil, errors.New("token user mismatch")
-			}
-
-			return claims, nil
+	claims, err := j.parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	if j.tokenCache == nil {
+		return claims, nil
+	}
+
+	revoked, err := j.tokenCache.IsRevoked(ctx, tokenString, claims.cacheSubject(), claims.issuedAt())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrRevocationUnavailable, err)
+	}
+	if revoked {
+		return nil, ErrTokenRevoked
+	}
+
+	cachedUserID, err := j.tokenCache.GetToken(ctx, tokenString)
+	if j.cacheObserver != nil {
+		j.cacheObserver.ObserveTokenCache(err == nil)
+	}
+	if err == nil {
+		// Verify cached userID matches token userID
+		if claims.cacheSubject() != cachedUserID {
+			return nil, errors.New("token user mismatch")
 		}
-		// If token not in cache but JWT is valid, it might have expired from cache
-		// Continue with JWT validation
+		return claims, nil
 	}
 
-	// Validate JWT token
+	// The token may have expired from the cache; cache it again
+	if claims.ExpiresAt != nil {
+		expirationTime := claims.ExpiresAt.Time
+		if expirationTime.After(time.Now()) {
+			expiration := time.Until(expirationTime)
+			j.tokenCache.SetToken(ctx, tokenString, claims.cacheSubject(), expiration)
+		}
+	}
+
+	return claims, nil
+}
+
+func (j *JWTAuth) parse(tokenString string) (*Claims, error) {
 	claims := &Claims{}
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
@@ -108,30 +180,46 @@ func (j *JWTAuth) ValidateToken(ctx context.Context, tokenString string) (*Claim
 		return nil, errors.New("invalid token")
 	}
 
-	// If cache is available and token is valid, cache it
-	if j.tokenCache != nil && claims.ExpiresAt != nil {
-		expirationTime := claims.ExpiresAt.Time
-		if expirationTime.After(time.Now()) {
-			expiration := time.Until(expirationTime)
-			j.tokenCache.SetToken(ctx, tokenString, claims.UserID, expiration)
-		}
-	}
-
 	return claims, nil
 }
 
-// InvalidateToken removes a token from the cache (for logout)
+// InvalidateToken revokes a token (for logout). The revocation is kept until
+// the token expires; tokens that are already invalid need none.
 func (j *JWTAuth) InvalidateToken(ctx context.Context, tokenString string) error {
-	if j.tokenCache != nil {
-		return j.tokenCache.DeleteToken(ctx, tokenString)
+	if j.tokenCache == nil {
+		return nil
 	}
-	return nil
+	claims, err := j.parse(tokenString)
+	if err != nil || claims.ExpiresAt == nil {
+		return nil
+	}
+	if err := j.tokenCache.RevokeToken(ctx, tokenString, time.Until(claims.ExpiresAt.Time)); err != nil {
+		return err
+	}
+	return j.tokenCache.DeleteToken(ctx, tokenString)
 }
 
-// InvalidateUserTokens removes all tokens for a user (for logout all devices)
-func (j *JWTAuth) InvalidateUserTokens(ctx context.Context, userID uint) error {
-	if j.tokenCache != nil {
-		return j.tokenCache.DeleteUserTokens(ctx, userID)
+// InvalidateUserTokens revokes every token issued to a user so far (for
+// logout on all devices, e.g. after a password change). Tokens issued later
+// are accepted; the revocation is kept until every earlier token has expired.
+// legacyID is the user's numeric database ID, the subject of tokens issued
+// before the switch to public-ID subjects; pass 0 to skip it.
+func (j *JWTAuth) InvalidateUserTokens(ctx context.Context, userID string, legacyID uint) error {
+	if j.tokenCache == nil {
+		return nil
+	}
+	subjects := []string{userID}
+	if legacyID != 0 {
+		subjects = append(subjects, strconv.FormatUint(uint64(legacyID), 10))
+	}
+	now := time.Now()
+	for _, subject := range subjects {
+		if err := j.tokenCache.RevokeUserTokens(ctx, subject, now, j.TokenTTL()); err != nil {
+			return err
+		}
+		if err := j.tokenCache.DeleteUserTokens(ctx, subject); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -0,0 +1,195 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// fakeCache is an in-memory cache.TokenCache. Setting err makes every call
+// fail, as when Redis is down.
+type fakeCache struct {
+	tokens        map[string]string
+	revoked       map[string]bool
+	revokedBefore map[string]time.Time
+	err           error
+}
+
+func newFakeCache() *fakeCache {
+	return &fakeCache{
+		tokens:        make(map[string]string),
+		revoked:       make(map[string]bool),
+		revokedBefore: make(map[string]time.Time),
+	}
+}
+
+func (f *fakeCache) SetToken(ctx context.Context, token string, userID string, expiration time.Duration) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.tokens[token] = userID
+	return nil
+}
+
+func (f *fakeCache) GetToken(ctx context.Context, token string) (string, error) {
+	if f.err != nil {
+		return "", f.err
+	}
+	userID, ok := f.tokens[token]
+	if !ok {
+		return "", errors.New("token not found in cache")
+	}
+	return userID, nil
+}
+
+func (f *fakeCache) DeleteToken(ctx context.Context, token string) error {
+	if f.err != nil {
+		return f.err
+	}
+	delete(f.tokens, token)
+	return nil
+}
+
+func (f *fakeCache) DeleteUserTokens(ctx context.Context, userID string) error {
+	if f.err != nil {
+		return f.err
+	}
+	for token, owner := range f.tokens {
+		if owner == userID {
+			delete(f.tokens, token)
+		}
+	}
+	return nil
+}
+
+func (f *fakeCache) RevokeToken(ctx context.Context, token string, expiration time.Duration) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.revoked[token] = true
+	return nil
+}
+
+func (f *fakeCache) RevokeUserTokens(ctx context.Context, userID string, before time.Time, expiration time.Duration) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.revokedBefore[userID] = before
+	return nil
+}
+
+func (f *fakeCache) IsRevoked(ctx context.Context, token string, userID string, issuedAt time.Time) (bool, error) {
+	if f.err != nil {
+		return false, f.err
+	}
+	before, ok := f.revokedBefore[userID]
+	return f.revoked[token] || ok && issuedAt.Before(before), nil
+}
+
+func (f *fakeCache) Close() error { return nil }
+
+func TestInvalidateTokenRevokesUncachedToken(t *testing.T) {
+	ctx := context.Background()
+	cache := newFakeCache()
+	j := NewJWTAuth("secret", 1, cache, nil)
+
+	token, err := j.GenerateToken(ctx, "usr-1", "a@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := j.InvalidateToken(ctx, token); err != nil {
+		t.Fatal(err)
+	}
+
+	// A cache miss used to fall back to signature validation and accept
+	// the token again
+	delete(cache.tokens, token)
+	if _, err := j.ValidateToken(ctx, token); !errors.Is(err, ErrTokenRevoked) {
+		t.Fatalf("ValidateToken() error = %v, want ErrTokenRevoked", err)
+	}
+}
+
+func TestValidateTokenFailsClosedWithoutRedis(t *testing.T) {
+	ctx := context.Background()
+	cache := newFakeCache()
+	j := NewJWTAuth("secret", 1, cache, nil)
+
+	token, err := j.GenerateToken(ctx, "usr-1", "a@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := j.ValidateToken(ctx, token); err != nil {
+		t.Fatalf("ValidateToken() error = %v", err)
+	}
+
+	cache.err = errors.New("connection refused")
+	if _, err := j.ValidateToken(ctx, token); !errors.Is(err, ErrRevocationUnavailable) {
+		t.Fatalf("ValidateToken() error = %v, want ErrRevocationUnavailable", err)
+	}
+}
+
+func TestInvalidateUserTokensRevokesEarlierTokens(t *testing.T) {
+	ctx := context.Background()
+	cache := newFakeCache()
+	j := NewJWTAuth("secret", 1, cache, nil)
+
+	token, err := j.GenerateToken(ctx, "usr-1", "a@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := j.GenerateToken(ctx, "usr-2", "b@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := j.InvalidateUserTokens(ctx, "usr-1", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := j.ValidateToken(ctx, token); !errors.Is(err, ErrTokenRevoked) {
+		t.Fatalf("ValidateToken(earlier token) error = %v, want ErrTokenRevoked", err)
+	}
+	if _, err := j.ValidateToken(ctx, other); err != nil {
+		t.Fatalf("ValidateToken(other user's token) error = %v", err)
+	}
+
+	// Issued right after the revocation, most likely within the same second
+	later, err := j.GenerateToken(ctx, "usr-1", "a@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := j.ValidateToken(ctx, later); err != nil {
+		t.Fatalf("ValidateToken(later token) error = %v", err)
+	}
+}
+
+func TestClaimsIssuedAt(t *testing.T) {
+	issued := time.Date(2026, 10, 18, 12, 0, 0, 500, time.UTC)
+	tests := []struct {
+		name   string
+		claims Claims
+		want   time.Time
+	}{
+		{
+			name:   "nanoseconds",
+			claims: Claims{IssuedAtNano: issued.UnixNano(), RegisteredClaims: jwt.RegisteredClaims{IssuedAt: jwt.NewNumericDate(issued)}},
+			want:   issued,
+		},
+		{
+			name:   "seconds only",
+			claims: Claims{RegisteredClaims: jwt.RegisteredClaims{IssuedAt: jwt.NewNumericDate(issued)}},
+			want:   issued.Truncate(time.Second),
+		},
+		{name: "no issue time"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.claims.issuedAt(); !got.Equal(tt.want) {
+				t.Fatalf("issuedAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,7 +2,10 @@ package cache
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -10,10 +13,19 @@ import (
 
 // TokenCache provides methods for caching authentication tokens
 type TokenCache interface {
-	SetToken(ctx context.Context, token string, userID uint, expiration time.Duration) error
-	GetToken(ctx context.Context, token string) (uint, error)
+	SetToken(ctx context.Context, token string, userID string, expiration time.Duration) error
+	GetToken(ctx context.Context, token string) (string, error)
 	DeleteToken(ctx context.Context, token string) error
-	DeleteUserTokens(ctx context.Context, userID uint) error
+	DeleteUserTokens(ctx context.Context, userID string) error
+	// RevokeToken records that token must be refused until expiration has
+	// passed, whether or not it is cached.
+	RevokeToken(ctx context.Context, token string, expiration time.Duration) error
+	// RevokeUserTokens records that every token of the user issued before
+	// the given time must be refused until expiration has passed.
+	RevokeUserTokens(ctx context.Context, userID string, before time.Time, expiration time.Duration) error
+	// IsRevoked reports whether token, issued to userID at issuedAt, was
+	// revoked on its own or together with the user's other tokens.
+	IsRevoked(ctx context.Context, token string, userID string, issuedAt time.Time) (bool, error)
 	Close() error
 }
 
@@ -31,12 +43,11 @@ func NewRedisCache(client *redis.Client) TokenCache {
 }
 
 // SetToken stores a token in Redis with the associated user ID
-func (r *redisCache) SetToken(ctx context.Context, token string, userID uint, expiration time.Duration) error {
+func (r *redisCache) SetToken(ctx context.Context, token string, userID string, expiration time.Duration) error {
 	key := r.getKey(token)
-	userIDStr := fmt.Sprintf("%d", userID)
 	
 	// Store token -> userID mapping
-	if err := r.client.Set(ctx, key, userIDStr, expiration).Err(); err != nil {
+	if err := r.client.Set(ctx, key, userID, expiration).Err(); err != nil {
 		return fmt.Errorf("failed to cache token: %w", err)
 	}
 	
@@ -55,19 +66,14 @@ func (r *redisCache) SetToken(ctx context.Context, token string, userID uint, ex
 }
 
 // GetToken retrieves the user ID associated with a token
-func (r *redisCache) GetToken(ctx context.Context, token string) (uint, error) {
+func (r *redisCache) GetToken(ctx context.Context, token string) (string, error) {
 	key := r.getKey(token)
-	userIDStr, err := r.client.Get(ctx, key).Result()
+	userID, err := r.client.Get(ctx, key).Result()
 	if err == redis.Nil {
-		return 0, fmt.Errorf("token not found in cache")
+		return "", fmt.Errorf("token not found in cache")
 	}
 	if err != nil {
-		return 0, fmt.Errorf("failed to get token from cache: %w", err)
-	}
-	
-	var userID uint
-	if _, err := fmt.Sscanf(userIDStr, "%d", &userID); err != nil {
-		return 0, fmt.Errorf("failed to parse user ID: %w", err)
+		return "", fmt.Errorf("failed to get token from cache: %w", err)
 	}
 	
 	return userID, nil
@@ -78,13 +84,10 @@ func (r *redisCache) DeleteToken(ctx context.Context, token string) error {
 	key := r.getKey(token)
 	
 	// Get userID before deleting to clean up user key
-	userIDStr, err := r.client.Get(ctx, key).Result()
+	userID, err := r.client.Get(ctx, key).Result()
 	if err == nil {
-		var userID uint
-		if _, err := fmt.Sscanf(userIDStr, "%d", &userID); err == nil {
-			userKey := r.getUserKey(userID)
-			r.client.SRem(ctx, userKey, token)
-		}
+		userKey := r.getUserKey(userID)
+		r.client.SRem(ctx, userKey, token)
 	}
 	
 	if err := r.client.Del(ctx, key).Err(); err != nil {
@@ -95,7 +98,7 @@ func (r *redisCache) DeleteToken(ctx context.Context, token string) error {
 }
 
 // DeleteUserTokens removes all tokens for a specific user
-func (r *redisCache) DeleteUserTokens(ctx context.Context, userID uint) error {
+func (r *redisCache) DeleteUserTokens(ctx context.Context, userID string) error {
 	userKey := r.getUserKey(userID)
 	
 	// Get all tokens for this user
@@ -118,6 +121,60 @@ func (r *redisCache) DeleteUserTokens(ctx context.Context, userID uint) error {
 	return nil
 }
 
+// RevokeToken stores a revocation record for the token. Records are keyed by
+// the token's digest, so the revocation list holds no usable credentials.
+func (r *redisCache) RevokeToken(ctx context.Context, token string, expiration time.Duration) error {
+	if expiration <= 0 {
+		return nil
+	}
+	if err := r.client.Set(ctx, r.getRevokedKey(token), 1, expiration).Err(); err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+	return nil
+}
+
+// RevokeUserTokens stores the time before which the user's tokens are refused,
+// in nanoseconds, so a token issued in the same second but before the
+// revocation is refused while a sign-in right after it is still accepted.
+func (r *redisCache) RevokeUserTokens(ctx context.Context, userID string, before time.Time, expiration time.Duration) error {
+	if err := r.client.Set(ctx, r.getUserRevokedKey(userID), before.UnixNano(), expiration).Err(); err != nil {
+		return fmt.Errorf("failed to revoke user tokens: %w", err)
+	}
+	return nil
+}
+
+// IsRevoked looks up the token's revocation record and the user's revocation
+// times in one round trip. Revocations stored in whole seconds before the
+// switch to nanoseconds are still honoured until they expire.
+func (r *redisCache) IsRevoked(ctx context.Context, token string, userID string, issuedAt time.Time) (bool, error) {
+	values, err := r.client.MGet(ctx, r.getRevokedKey(token), r.getUserRevokedKey(userID), r.getLegacyUserRevokedKey(userID)).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to check token revocation: %w", err)
+	}
+	if values[0] != nil {
+		return true, nil
+	}
+	if values[1] != nil {
+		before, err := strconv.ParseInt(values[1].(string), 10, 64)
+		if err != nil {
+			return false, fmt.Errorf("invalid user token revocation: %w", err)
+		}
+		if issuedAt.UnixNano() < before {
+			return true, nil
+		}
+	}
+	if values[2] != nil {
+		before, err := strconv.ParseInt(values[2].(string), 10, 64)
+		if err != nil {
+			return false, fmt.Errorf("invalid user token revocation: %w", err)
+		}
+		if issuedAt.Unix() < before {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 // Close closes the Redis connection
 func (r *redisCache) Close() error {
 	return r.client.Close()
@@ -128,7 +185,25 @@ func (r *redisCache) getKey(token string) string {
 	return r.prefix + token
 }
 
+// getRevokedKey returns the Redis key of a token's revocation record
+func (r *redisCache) getRevokedKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return "auth:revoked:" + hex.EncodeToString(sum[:])
+}
+
+// getUserRevokedKey returns the Redis key of the time, in nanoseconds, before
+// which a user's tokens are revoked
+func (r *redisCache) getUserRevokedKey(userID string) string {
+	return fmt.Sprintf("auth:user:%s:revoked_before_ns", userID)
+}
+
+// getLegacyUserRevokedKey returns the Redis key of the time, in whole
+// seconds, before which a user's tokens were revoked by earlier releases
+func (r *redisCache) getLegacyUserRevokedKey(userID string) string {
+	return fmt.Sprintf("auth:user:%s:revoked_before", userID)
+}
+
 // getUserKey returns the Redis key for a user's token set
-func (r *redisCache) getUserKey(userID uint) string {
-	return fmt.Sprintf("auth:user:%d:tokens", userID)
+func (r *redisCache) getUserKey(userID string) string {
+	return fmt.Sprintf("auth:user:%s:tokens", userID)
 }
@@ -1,20 +1,28 @@
 package oauth
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
+	"time"
 
 	"github.com/jixlox0/studoto-backend/internal/config"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// requestTimeout bounds each call to a provider
+const requestTimeout = 10 * time.Second
+
 type OAuthService interface {
 	GetGoogleAuthURL(state string) string
 	GetGitHubAuthURL(state string) string
-	ExchangeGoogleCode(code string) (*OAuthUser, error)
-	ExchangeGitHubCode(code string) (*OAuthUser, error)
+	ExchangeGoogleCode(ctx context.Context, code string) (*OAuthUser, error)
+	ExchangeGitHubCode(ctx context.Context, code string) (*OAuthUser, error)
 }
 
 type OAuthUser struct {
@@ -31,15 +39,24 @@ type oauthService struct {
 	githubClientID     string
 	githubClientSecret string
 	redirectURL        string
+	httpClient         *http.Client
 }
 
-func NewOAuthService(cfg config.OAuthConfig) OAuthService {
+// NewOAuthService creates the provider client. Outbound calls are traced
+// with tracerProvider and carry the trace context of the incoming request.
+func NewOAuthService(cfg config.OAuthConfig, tracerProvider trace.TracerProvider) OAuthService {
 	return &oauthService{
 		googleClientID:     cfg.Google.ClientID,
 		googleClientSecret: cfg.Google.ClientSecret,
 		githubClientID:     cfg.GitHub.ClientID,
 		githubClientSecret: cfg.GitHub.ClientSecret,
 		redirectURL:        cfg.RedirectURL,
+		httpClient: &http.Client{
+			Timeout: requestTimeout,
+			Transport: otelhttp.NewTransport(http.DefaultTransport,
+				otelhttp.WithTracerProvider(tracerProvider),
+			),
+		},
 	}
 }
 
@@ -65,7 +82,7 @@ func (s *oauthService) GetGitHubAuthURL(state string) string {
 	return fmt.Sprintf("https://github.com/login/oauth/authorize?%s", params.Encode())
 }
 
-func (s *oauthService) ExchangeGoogleCode(code string) (*OAuthUser, error) {
+func (s *oauthService) ExchangeGoogleCode(ctx context.Context, code string) (*OAuthUser, error) {
 	// Exchange code for token
 	tokenURL := "https://oauth2.googleapis.com/token"
 	data := url.Values{}
@@ -75,7 +92,10 @@ func (s *oauthService) ExchangeGoogleCode(code string) (*OAuthUser, error) {
 	data.Set("redirect_uri", s.redirectURL)
 	data.Set("grant_type", "authorization_code")
 
-	resp, err := http.PostForm(tokenURL, data)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(data.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to exchange code: %w", err)
 	}
@@ -90,11 +110,10 @@ func (s *oauthService) ExchangeGoogleCode(code string) (*OAuthUser, error) {
 
 	// Get user info
 	userInfoURL := "https://www.googleapis.com/oauth2/v2/userinfo"
-	req, _ := http.NewRequest("GET", userInfoURL, nil)
+	req, _ = http.NewRequestWithContext(ctx, http.MethodGet, userInfoURL, nil)
 	req.Header.Set("Authorization", "Bearer "+tokenResp.AccessToken)
 
-	client := &http.Client{}
-	resp, err = client.Do(req)
+	resp, err = s.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user info: %w", err)
 	}
@@ -119,7 +138,7 @@ func (s *oauthService) ExchangeGoogleCode(code string) (*OAuthUser, error) {
 	}, nil
 }
 
-func (s *oauthService) ExchangeGitHubCode(code string) (*OAuthUser, error) {
+func (s *oauthService) ExchangeGitHubCode(ctx context.Context, code string) (*OAuthUser, error) {
 	// Exchange code for token
 	tokenURL := "https://github.com/login/oauth/access_token"
 	data := url.Values{}
@@ -128,13 +147,11 @@ func (s *oauthService) ExchangeGitHubCode(code string) (*OAuthUser, error) {
 	data.Set("client_secret", s.githubClientSecret)
 	data.Set("redirect_uri", s.redirectURL)
 
-	req, _ := http.NewRequest("POST", tokenURL, nil)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(data.Encode()))
 	req.Header.Set("Accept", "application/json")
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	req.PostForm = data
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := s.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to exchange code: %w", err)
 	}
@@ -149,11 +166,11 @@ func (s *oauthService) ExchangeGitHubCode(code string) (*OAuthUser, error) {
 
 	// Get user info
 	userInfoURL := "https://api.github.com/user"
-	req, _ = http.NewRequest("GET", userInfoURL, nil)
+	req, _ = http.NewRequestWithContext(ctx, http.MethodGet, userInfoURL, nil)
 	req.Header.Set("Authorization", "Bearer "+tokenResp.AccessToken)
 	req.Header.Set("Accept", "application/vnd.github.v3+json")
 
-	resp, err = client.Do(req)
+	resp, err = s.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user info: %w", err)
 	}
@@ -178,11 +195,11 @@ func (s *oauthService) ExchangeGitHubCode(code string) (*OAuthUser, error) {
 	// Get email if not in user info
 	if userInfo.Email == "" {
 		emailURL := "https://api.github.com/user/emails"
-		req, _ = http.NewRequest("GET", emailURL, nil)
+		req, _ = http.NewRequestWithContext(ctx, http.MethodGet, emailURL, nil)
 		req.Header.Set("Authorization", "Bearer "+tokenResp.AccessToken)
 		req.Header.Set("Accept", "application/vnd.github.v3+json")
 
-		resp, err = client.Do(req)
+		resp, err = s.httpClient.Do(req)
 		if err == nil {
 			defer resp.Body.Close()
 			var emails []struct {
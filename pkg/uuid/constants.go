@@ -11,4 +11,8 @@ const (
 	PrefixFile    = "fil" // File
 	PrefixComment = "cmt" // Comment
 	PrefixPost    = "pst" // Post
+	PrefixAudit   = "aud" // Audit event
+	PrefixSchool  = "sch" // School
+	PrefixCourse  = "crs" // Course
+	PrefixRequest = "req" // Request ID
 )